@@ -3,7 +3,9 @@ package main
 import (
 	"hotel-management/database"
 	_ "hotel-management/docs"
+	"hotel-management/internal/gateway"
 	"hotel-management/internal/middleware"
+	"hotel-management/internal/storage"
 	"hotel-management/internal/utils"
 	"hotel-management/router"
 	"log"
@@ -48,7 +50,13 @@ func main() {
 
 	store := cookie.NewStore([]byte(os.Getenv(	"SECRET_KEY")))
 	r.Use(sessions.Sessions("mysession", store))
-	router.SetupRoutes(r)
+	fileStorage, err := storage.NewStorageFromEnv()
+	if err != nil {
+		log.Fatal("Failed to init file storage:", err)
+	}
+	paymentGateways := gateway.NewRegistryFromEnv()
+	router.SetupRoutes(r, fileStorage, paymentGateways)
+	mountVnpaySandbox(r)
 
 	err = r.Run(":8080")
 	if err != nil {
@@ -0,0 +1,26 @@
+//go:build vnpaysandbox
+
+package main
+
+import (
+	"hotel-management/internal/gateway/vnpaysandbox"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mountVnpaySandbox serves the fake VNPay under /vnpay-sandbox so the payment
+// flow can run without reaching VNPay. It only exists in binaries built with
+// the vnpaysandbox tag, and is never served in release mode.
+func mountVnpaySandbox(r *gin.Engine) {
+	sandbox := vnpaysandbox.NewServerFromEnv()
+	if sandbox == nil {
+		return
+	}
+	if gin.Mode() == gin.ReleaseMode {
+		log.Println("vnpay sandbox: not served in release mode")
+		return
+	}
+	r.Any("/vnpay-sandbox/*path", gin.WrapH(http.StripPrefix("/vnpay-sandbox", sandbox)))
+}
@@ -0,0 +1,9 @@
+//go:build !vnpaysandbox
+
+package main
+
+import "github.com/gin-gonic/gin"
+
+// mountVnpaySandbox does nothing: the VNPay sandbox is only built into
+// binaries built with the vnpaysandbox tag.
+func mountVnpaySandbox(r *gin.Engine) {}
@@ -1,24 +1,235 @@
 package database
 
 import (
+	"hotel-management/internal/constant"
 	"hotel-management/internal/models"
 	"log"
+
+	"gorm.io/gorm"
 )
 
 func AutoMigrate() {
+	// Columns backfilled from older data are only backfilled on the start
+	// that adds them. Afterwards a zero amount or a missing bill is real.
+	migrator := DB.Migrator()
+	addsPaymentAmount := !migrator.HasColumn(&models.Payment{}, "Amount")
+	addsPaidAmount := !migrator.HasColumn(&models.Booking{}, "PaidAmount")
+	addsPaymentBill := !migrator.HasColumn(&models.Payment{}, "BillID")
+	addsRefundedAmount := !migrator.HasColumn(&models.Booking{}, "RefundedAmount")
+	addsRatingCriteria := migrator.HasTable(&models.RoomRating{}) && !migrator.HasColumn(&models.RoomRating{}, "AvgCleanliness")
+
+	// Room numbers must be filled in and unique before AutoMigrate adds the
+	// unique index over them.
+	if err := prepareRoomNumbers(DB); err != nil {
+		log.Fatal("Room number migration failed:", err)
+	}
+
 	err := DB.AutoMigrate(
+		&models.Property{},
 		&models.User{},
 		&models.Room{},
+		&models.RoomConnection{},
 		&models.RoomImage{},
+		&models.RatePlan{},
 		&models.Booking{},
 		&models.BookingRoom{},
+		&models.BookingCharge{},
 		&models.Review{},
+		&models.ReviewPhoto{},
+		&models.ReviewRevision{},
+		&models.RoomRating{},
 		&models.Bill{},
 		&models.Shift{},
 		&models.Payment{},
+		&models.PaymentAllocation{},
+		&models.PaymentCallback{},
+		&models.Refund{},
+		&models.PaymentReconciliation{},
+		&models.PaymentReconciliationEntry{},
+		&models.IdempotencyKey{},
+		&models.WorkOrder{},
+		&models.WorkOrderPhoto{},
+		&models.Amenity{},
+		&models.AmenityTranslation{},
 	)
 
 	if err != nil {
 		log.Fatal("AutoMigrate failed:", err)
 	}
+	if err := assignDefaultProperty(DB); err != nil {
+		log.Fatal("Property migration failed:", err)
+	}
+	if err := backfillRoomRatings(DB, addsRatingCriteria); err != nil {
+		log.Fatal("Room rating migration failed:", err)
+	}
+	if addsPaymentAmount {
+		if err := backfillPaymentAmounts(DB); err != nil {
+			log.Fatal("Payment amount migration failed:", err)
+		}
+	}
+	if addsPaidAmount {
+		if err := backfillPaidAmounts(DB); err != nil {
+			log.Fatal("Paid amount migration failed:", err)
+		}
+	}
+	if addsPaymentBill {
+		if err := linkPaymentBills(DB); err != nil {
+			log.Fatal("Payment bill migration failed:", err)
+		}
+	}
+	if addsRefundedAmount {
+		if err := backfillRefundedAmounts(DB); err != nil {
+			log.Fatal("Refunded amount migration failed:", err)
+		}
+	}
+}
+
+// assignDefaultProperty moves rooms and bookings created before properties
+// existed into a default property, so every room belongs to a hotel.
+func assignDefaultProperty(db *gorm.DB) error {
+	var orphanRooms int64
+	if err := db.Unscoped().Model(&models.Room{}).Where("property_id IS NULL").Count(&orphanRooms).Error; err != nil {
+		return err
+	}
+	if orphanRooms == 0 {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		property := models.Property{Code: constant.DefaultPropertyCode}
+		err := tx.Where(models.Property{Code: constant.DefaultPropertyCode}).
+			Attrs(models.Property{Name: constant.DefaultPropertyName, IsActive: true}).
+			FirstOrCreate(&property).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Room{}).Where("property_id IS NULL").Update("property_id", property.ID).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE bookings SET property_id = (
+			SELECT MIN(rooms.property_id) FROM booking_rooms
+			JOIN rooms ON rooms.id = booking_rooms.room_id
+			WHERE booking_rooms.booking_id = bookings.id
+		) WHERE property_id IS NULL`).Error
+	})
+}
+
+// prepareRoomNumbers readies existing rooms for the unique index on property
+// and room number. It adds the number column if needed, numbers rooms created
+// before numbers existed, renumbers duplicates and drops the earlier
+// non-unique index of the same name so AutoMigrate recreates it as unique.
+func prepareRoomNumbers(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.Room{}) {
+		return nil
+	}
+	if !migrator.HasColumn(&models.Room{}, "Number") {
+		if err := migrator.AddColumn(&models.Room{}, "Number"); err != nil {
+			return err
+		}
+	}
+	if err := assignRoomNumbers(db); err != nil {
+		return err
+	}
+	if err := renumberDuplicateRooms(db); err != nil {
+		return err
+	}
+
+	indexes, err := migrator.GetIndexes(&models.Room{})
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if index.Name() != "idx_rooms_property_number" {
+			continue
+		}
+		if unique, ok := index.Unique(); ok && !unique {
+			return migrator.DropIndex(&models.Room{}, index.Name())
+		}
+	}
+	return nil
+}
+
+// renumberDuplicateRooms appends the room ID to the number of every room but
+// the first that shares its number with another room of the property.
+// Archived rooms count, since they keep their number.
+func renumberDuplicateRooms(db *gorm.DB) error {
+	return db.Exec(`UPDATE rooms
+		JOIN (
+			SELECT property_id, number, MIN(id) AS first_id FROM rooms
+			GROUP BY property_id, number HAVING COUNT(*) > 1
+		) duplicates ON duplicates.property_id = rooms.property_id AND duplicates.number = rooms.number
+		SET rooms.number = CONCAT(rooms.number, '-', rooms.id)
+		WHERE rooms.id <> duplicates.first_id`).Error
+}
+
+// assignRoomNumbers gives rooms created before room numbers existed their ID
+// as number, which is unique within any property.
+func assignRoomNumbers(db *gorm.DB) error {
+	return db.Unscoped().Model(&models.Room{}).
+		Where("number = ''").
+		Update("number", gorm.Expr("CAST(id AS CHAR)")).Error
+}
+
+// backfillRoomRatings stores the rating aggregate of rooms reviewed before
+// aggregates were kept. With recomputeAll, aggregates stored before the
+// per-criterion averages existed are rebuilt as well.
+func backfillRoomRatings(db *gorm.DB, recomputeAll bool) error {
+	missingOnly := "AND room_id NOT IN (SELECT room_id FROM room_ratings)"
+	if recomputeAll {
+		missingOnly = ""
+	}
+	return db.Exec(`INSERT INTO room_ratings (room_id, review_count, avg_rating,
+			rating1_count, rating2_count, rating3_count, rating4_count, rating5_count,
+			avg_cleanliness, avg_service, avg_location, avg_value, avg_comfort, updated_at)
+		SELECT room_id, COUNT(*), AVG(rating),
+			SUM(rating = 1), SUM(rating = 2), SUM(rating = 3), SUM(rating = 4), SUM(rating = 5),
+			COALESCE(AVG(NULLIF(cleanliness_rating, 0)), 0), COALESCE(AVG(NULLIF(service_rating, 0)), 0),
+			COALESCE(AVG(NULLIF(location_rating, 0)), 0), COALESCE(AVG(NULLIF(value_rating, 0)), 0),
+			COALESCE(AVG(NULLIF(comfort_rating, 0)), 0), NOW()
+		FROM reviews
+		WHERE deleted_at IS NULL AND status <> 'hidden' ` + missingOnly + `
+		GROUP BY room_id
+		ON DUPLICATE KEY UPDATE review_count = VALUES(review_count), avg_rating = VALUES(avg_rating),
+			rating1_count = VALUES(rating1_count), rating2_count = VALUES(rating2_count),
+			rating3_count = VALUES(rating3_count), rating4_count = VALUES(rating4_count),
+			rating5_count = VALUES(rating5_count), avg_cleanliness = VALUES(avg_cleanliness),
+			avg_service = VALUES(avg_service), avg_location = VALUES(avg_location),
+			avg_value = VALUES(avg_value), avg_comfort = VALUES(avg_comfort), updated_at = VALUES(updated_at)`).Error
+}
+
+// backfillPaymentAmounts sets the amount of payments created before it was
+// stored to their booking's total, which is what they were created for.
+func backfillPaymentAmounts(db *gorm.DB) error {
+	return db.Exec(`UPDATE payments
+		JOIN bookings ON bookings.id = payments.booking_id
+		SET payments.amount = bookings.total_price
+		WHERE payments.amount = 0`).Error
+}
+
+// backfillPaidAmounts stores what was paid on bookings paid before paid
+// amounts were kept.
+func backfillPaidAmounts(db *gorm.DB) error {
+	return db.Exec(`UPDATE bookings SET paid_amount = (
+		SELECT COALESCE(SUM(payments.amount), 0) FROM payments
+		WHERE payments.booking_id = bookings.id AND payments.payment_status = 'success' AND payments.deleted_at IS NULL
+	) WHERE paid_amount = 0`).Error
+}
+
+// linkPaymentBills links payments made before payments were billed one by
+// one to the bill of their booking.
+func linkPaymentBills(db *gorm.DB) error {
+	return db.Exec(`UPDATE payments
+		JOIN bills ON bills.booking_id = payments.booking_id
+		SET payments.bill_id = bills.id
+		WHERE payments.bill_id IS NULL AND payments.payment_status = 'success'`).Error
+}
+
+// backfillRefundedAmounts stores what was refunded on bookings refunded
+// before refunded amounts were kept.
+func backfillRefundedAmounts(db *gorm.DB) error {
+	return db.Exec(`UPDATE bookings SET refunded_amount = (
+		SELECT COALESCE(SUM(refunds.amount), 0) FROM refunds
+		JOIN payments ON payments.id = refunds.payment_id
+		WHERE payments.booking_id = bookings.id AND refunds.status = 'success' AND refunds.deleted_at IS NULL
+	) WHERE refunded_amount = 0`).Error
 }
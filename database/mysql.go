@@ -21,7 +21,9 @@ func InitDB() {
 		os.Getenv("DB_NAME"),
 	)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// TranslateError turns driver errors such as duplicate keys into gorm's
+	// portable errors (gorm.ErrDuplicatedKey).
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatal("Cannot connect to DB:", err)
 	}
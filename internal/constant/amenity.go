@@ -0,0 +1,27 @@
+package constant
+
+const (
+	AMENITY_CATEGORY_GENERAL       = "general"
+	AMENITY_CATEGORY_BEDROOM       = "bedroom"
+	AMENITY_CATEGORY_BATHROOM      = "bathroom"
+	AMENITY_CATEGORY_ENTERTAINMENT = "entertainment"
+	AMENITY_CATEGORY_KITCHEN       = "kitchen"
+	AMENITY_CATEGORY_OUTDOOR       = "outdoor"
+)
+
+var AmenityCategories = []string{
+	AMENITY_CATEGORY_GENERAL,
+	AMENITY_CATEGORY_BEDROOM,
+	AMENITY_CATEGORY_BATHROOM,
+	AMENITY_CATEGORY_ENTERTAINMENT,
+	AMENITY_CATEGORY_KITCHEN,
+	AMENITY_CATEGORY_OUTDOOR,
+}
+
+// TranslatedLanguages lists the languages an amenity name can be translated
+// into. The amenity's own Name is the English default.
+var TranslatedLanguages = []string{"vi"}
+
+func IsValidAmenityCategory(category string) bool {
+	return contains(AmenityCategories, category)
+}
@@ -6,8 +6,15 @@ const (
 	CANCELLED   = "cancelled"
 	CHECKED_IN  = "checked_in"
 	NO_SHOW     = "no_show"
+	// PENDING_PAYMENT holds the rooms of a pay-now or deposit booking until
+	// the payment succeeds or the hold runs out.
+	PENDING_PAYMENT = "pending_payment"
 )
 
+// OccupyingBookingStatuses are the statuses in which a booking keeps its
+// rooms from being booked again.
+var OccupyingBookingStatuses = []string{BOOKED, CHECKED_IN, PENDING_PAYMENT}
+
 var validBookingStatuses = map[string]bool{
 	BOOKED:      true,
 	CHECKED_IN:  true,
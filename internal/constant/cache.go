@@ -0,0 +1,7 @@
+package constant
+
+import "time"
+
+// PublicCacheMaxAge is how long shared caches may keep public catalogue
+// responses before revalidating them with their ETag.
+const PublicCacheMaxAge = 60 * time.Second
@@ -0,0 +1,19 @@
+package constant
+
+import "time"
+
+// IdempotencyKeyHeader names the header clients send so that retrying a
+// request does not repeat it.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses replayed from an earlier
+// request with the same key.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+const (
+	// IdempotencyKeyTTL is how long a key is remembered. Reusing it after
+	// that runs the request again.
+	IdempotencyKeyTTL          = 24 * time.Hour
+	MaxIdempotencyKeyLength    = 255
+	IdempotencyCleanupInterval = time.Hour
+)
@@ -0,0 +1,26 @@
+package constant
+
+const (
+	// MaxImageBytes caps the size of a single uploaded image.
+	MaxImageBytes = 2 * 1024 * 1024
+	// MaxImagePixels rejects images whose decoded size would be unreasonably
+	// large, even if the compressed file is small.
+	MaxImagePixels = 40_000_000
+
+	IMAGE_VARIANT_THUMBNAIL = "thumbnail"
+	IMAGE_VARIANT_MEDIUM    = "medium"
+	IMAGE_VARIANT_LARGE     = "large"
+)
+
+type ImageVariant struct {
+	Name string
+	// MaxDimension bounds the longest edge of the variant. Smaller images are
+	// never upscaled.
+	MaxDimension int
+}
+
+var ImageVariants = []ImageVariant{
+	{Name: IMAGE_VARIANT_THUMBNAIL, MaxDimension: 320},
+	{Name: IMAGE_VARIANT_MEDIUM, MaxDimension: 800},
+	{Name: IMAGE_VARIANT_LARGE, MaxDimension: 1600},
+}
@@ -0,0 +1,6 @@
+package constant
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
@@ -0,0 +1,84 @@
+package constant
+
+// Payment methods, each handled by the payment gateway of the same name.
+const (
+	PAYMENT_METHOD_VNPAY         = "vnpay"
+	PAYMENT_METHOD_CASH          = "cash"
+	PAYMENT_METHOD_CARD_TERMINAL = "card_terminal"
+	PAYMENT_METHOD_FAKE          = "fake"
+	PAYMENT_METHOD_BANK_TRANSFER = "bank_transfer"
+)
+
+// Columns of an imported bank statement. The description is searched for
+// the transfer reference; the transaction ID is the bank's.
+const (
+	STATEMENT_COLUMN_TRANSACTION_ID = "transaction_id"
+	STATEMENT_COLUMN_AMOUNT         = "amount"
+	STATEMENT_COLUMN_DESCRIPTION    = "description"
+)
+
+// BankStatementColumns must be present in the header row of a statement.
+var BankStatementColumns = []string{
+	STATEMENT_COLUMN_TRANSACTION_ID,
+	STATEMENT_COLUMN_AMOUNT,
+	STATEMENT_COLUMN_DESCRIPTION,
+}
+
+// Outcomes of a bank statement line.
+const (
+	STATEMENT_ROW_MATCHED = "matched"
+	// STATEMENT_ROW_DUPLICATE is a transfer to a payment already settled,
+	// such as a line imported twice.
+	STATEMENT_ROW_DUPLICATE = "duplicate"
+	STATEMENT_ROW_UNMATCHED = "unmatched"
+	STATEMENT_ROW_FAILED    = "failed"
+)
+
+// Kinds of booking line items a payment can be allocated to.
+const (
+	LINE_ITEM_ROOM   = "room"
+	LINE_ITEM_CHARGE = "charge"
+)
+
+// Refund statuses.
+const (
+	REFUND_PENDING = "pending"
+	REFUND_SUCCESS = "success"
+	REFUND_FAILED  = "failed"
+)
+
+// Refund types an admin can choose.
+const (
+	REFUND_TYPE_FULL    = "full"
+	REFUND_TYPE_PARTIAL = "partial"
+)
+
+// Outcomes of reconciling a pending payment.
+const (
+	RECONCILE_SETTLED   = "settled"
+	RECONCILE_FAILED    = "failed"
+	RECONCILE_EXPIRED   = "expired"
+	RECONCILE_UNCHANGED = "unchanged"
+	RECONCILE_ERROR     = "error"
+)
+
+// Defaults of the payment reconciler, in minutes, unless the
+// PAYMENT_RECONCILE_* variables say otherwise. VNPay payment URLs expire
+// after 15 minutes.
+const (
+	DefaultReconcileIntervalMinutes = 5
+	DefaultReconcileAfterMinutes    = 15
+	DefaultPaymentExpiryMinutes     = 30
+	ReconcileBatchSize              = 100
+	// MaxReconcileErrors is how many failed lookups in a row a payment gets,
+	// an hour of runs at the default interval, before it is left to an
+	// admin.
+	MaxReconcileErrors = 12
+	// RefundQueryAfterMinutes is how long a refund whose outcome is unknown
+	// waits before it is looked up with its gateway.
+	RefundQueryAfterMinutes = 5
+)
+
+// PaymentSearchLimit caps the payments listed on the admin payments page;
+// narrower filters find older ones.
+const PaymentSearchLimit = 500
@@ -0,0 +1,12 @@
+package constant
+
+const (
+	// DefaultPropertyCode is the property existing rooms are moved to when
+	// multi-property support is first migrated.
+	DefaultPropertyCode = "MAIN"
+	DefaultPropertyName = "Main property"
+
+	// AllProperties is the property switcher value for "every property"; only
+	// admins may select it.
+	AllProperties uint = 0
+)
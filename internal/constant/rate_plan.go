@@ -0,0 +1,32 @@
+package constant
+
+// When a rate plan's guests pay. Pay-at-hotel bookings are paid after
+// checkout; pay-now bookings are paid in full when booking, and deposit
+// bookings pay DepositPercent of the price when booking and the rest after
+// checkout.
+const (
+	PAYMENT_TIMING_PAY_AT_HOTEL = "pay_at_hotel"
+	PAYMENT_TIMING_PAY_NOW      = "pay_now"
+	PAYMENT_TIMING_DEPOSIT      = "deposit"
+
+	// DefaultBookingHoldMinutes is how long a pay-now or deposit booking
+	// holds its rooms while waiting for payment, unless BOOKING_HOLD_MINUTES
+	// says otherwise.
+	DefaultBookingHoldMinutes = 30
+
+	HoldReleaseBatchSize = 100
+
+	MinDepositPercent = 1
+	MaxDepositPercent = 99
+)
+
+var PaymentTimings = []string{PAYMENT_TIMING_PAY_AT_HOTEL, PAYMENT_TIMING_PAY_NOW, PAYMENT_TIMING_DEPOSIT}
+
+func IsValidPaymentTiming(timing string) bool {
+	for _, t := range PaymentTimings {
+		if t == timing {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package constant
+
+const (
+	REVIEW_SORT_NEWEST  = "newest"
+	REVIEW_SORT_HIGHEST = "highest"
+
+	// New reviews are pending until a moderator looks at them. Pending,
+	// approved and flagged reviews are public; hidden ones are not.
+	REVIEW_STATUS_PENDING  = "pending"
+	REVIEW_STATUS_APPROVED = "approved"
+	REVIEW_STATUS_FLAGGED  = "flagged"
+	REVIEW_STATUS_HIDDEN   = "hidden"
+
+	REVIEW_ACTION_APPROVE = "approve"
+	REVIEW_ACTION_FLAG    = "flag"
+	REVIEW_ACTION_HIDE    = "hide"
+
+	// Actions recorded in a review's edit history.
+	REVIEW_REVISION_EDITED  = "edited"
+	REVIEW_REVISION_DELETED = "deleted"
+
+	// DefaultReviewEditWindowDays is how long the author may edit or delete
+	// a review unless REVIEW_EDIT_WINDOW_DAYS says otherwise.
+	DefaultReviewEditWindowDays = 14
+
+	MaxReviewReasonLength   = 500
+	MaxReviewResponseLength = 2000
+	MaxReviewPhotos         = 5
+)
+
+var ReviewStatuses = []string{
+	REVIEW_STATUS_PENDING,
+	REVIEW_STATUS_APPROVED,
+	REVIEW_STATUS_FLAGGED,
+	REVIEW_STATUS_HIDDEN,
+}
+
+var ReviewActions = []string{
+	REVIEW_ACTION_APPROVE,
+	REVIEW_ACTION_FLAG,
+	REVIEW_ACTION_HIDE,
+}
+
+// reviewActionStatuses maps each moderation action to the status it sets.
+var reviewActionStatuses = map[string]string{
+	REVIEW_ACTION_APPROVE: REVIEW_STATUS_APPROVED,
+	REVIEW_ACTION_FLAG:    REVIEW_STATUS_FLAGGED,
+	REVIEW_ACTION_HIDE:    REVIEW_STATUS_HIDDEN,
+}
+
+func IsValidReviewStatus(status string) bool {
+	return contains(ReviewStatuses, status)
+}
+
+// ReviewActionStatus returns the status a moderation action sets.
+func ReviewActionStatus(action string) (string, bool) {
+	status, ok := reviewActionStatuses[action]
+	return status, ok
+}
+
+// ReviewActionNeedsReason reports whether a moderator must explain the
+// action; approving needs no reason.
+func ReviewActionNeedsReason(action string) bool {
+	return action == REVIEW_ACTION_FLAG || action == REVIEW_ACTION_HIDE
+}
@@ -0,0 +1,62 @@
+package constant
+
+const (
+	ROOM_FILE_FORMAT_CSV  = "csv"
+	ROOM_FILE_FORMAT_XLSX = "xlsx"
+
+	ROOM_IMPORT_ACTION_CREATE = "create"
+	ROOM_IMPORT_ACTION_UPDATE = "update"
+
+	ROOM_COLUMN_NAME         = "name"
+	ROOM_COLUMN_NUMBER       = "room_number"
+	ROOM_COLUMN_FLOOR        = "floor"
+	ROOM_COLUMN_BUILDING     = "building"
+	ROOM_COLUMN_TYPE         = "type"
+	ROOM_COLUMN_PRICE        = "price_per_night"
+	ROOM_COLUMN_BED_NUM      = "bed_num"
+	ROOM_COLUMN_HAS_AIRCON   = "has_aircon"
+	ROOM_COLUMN_VIEW_TYPE    = "view_type"
+	ROOM_COLUMN_DESCRIPTION  = "description"
+	ROOM_COLUMN_IS_AVAILABLE = "is_available"
+	ROOM_COLUMN_AMENITIES    = "amenities"
+	ROOM_COLUMN_IMAGES       = "images"
+)
+
+// RoomFileColumns is the column order used for export and the template file.
+var RoomFileColumns = []string{
+	ROOM_COLUMN_NAME,
+	ROOM_COLUMN_NUMBER,
+	ROOM_COLUMN_FLOOR,
+	ROOM_COLUMN_BUILDING,
+	ROOM_COLUMN_TYPE,
+	ROOM_COLUMN_PRICE,
+	ROOM_COLUMN_BED_NUM,
+	ROOM_COLUMN_HAS_AIRCON,
+	ROOM_COLUMN_VIEW_TYPE,
+	ROOM_COLUMN_DESCRIPTION,
+	ROOM_COLUMN_IS_AVAILABLE,
+	ROOM_COLUMN_AMENITIES,
+	ROOM_COLUMN_IMAGES,
+}
+
+// RequiredRoomColumns must be present in the header row of an import file.
+var RequiredRoomColumns = []string{
+	ROOM_COLUMN_NAME,
+	ROOM_COLUMN_TYPE,
+	ROOM_COLUMN_PRICE,
+	ROOM_COLUMN_BED_NUM,
+	ROOM_COLUMN_VIEW_TYPE,
+}
+
+const (
+	MaxImportFileBytes   = 10 << 20
+	MaxImportBundleBytes = 200 << 20
+	MaxImportRows        = 1000
+	MaxImportImages      = 5
+	// ImportListSeparator separates amenity codes and image references within
+	// one cell.
+	ImportListSeparator = ";"
+	// DefaultRoomImportDir is where image paths in an import file are looked up
+	// when no zip bundle is uploaded. Override it with ROOM_IMPORT_DIR.
+	DefaultRoomImportDir = "imports"
+)
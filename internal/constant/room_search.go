@@ -0,0 +1,19 @@
+package constant
+
+const (
+	ROOM_SORT_PRICE_ASC     = "price_asc"
+	ROOM_SORT_PRICE_DESC    = "price_desc"
+	ROOM_SORT_RATING_DESC   = "rating_desc"
+	ROOM_SORT_CAPACITY_ASC  = "capacity_asc"
+	ROOM_SORT_CAPACITY_DESC = "capacity_desc"
+)
+
+const (
+	// ROOM_GROUP_BY_FLOOR groups the admin room list into one section per
+	// building and floor.
+	ROOM_GROUP_BY_FLOOR = "floor"
+
+	// MaxConnectingRoomSuggestions caps the connecting-room pairs suggested
+	// to families in a room search.
+	MaxConnectingRoomSuggestions = 5
+)
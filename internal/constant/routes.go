@@ -1,14 +1,22 @@
 package constant
 
 const (
-	AdminHomePath         = "/admin"
-	AdminLoginPath        = "/admin/login"
-	StaffDashboardPath    = "/staff"
-	UploadDir             = "web/assets/uploads"
-	ImageURL              = "/assets/uploads/"
-	RoomManagementPath    = "/admin/rooms"
-	BookingManagementPath = "/admin/bookings"
-	StaffManagementPath   = "/admin/staffs"
+	AdminHomePath          = "/admin"
+	AdminLoginPath         = "/admin/login"
+	StaffDashboardPath     = "/staff"
+	UploadDir              = "web/assets/uploads"
+	ImageURL               = "/assets/uploads/"
+	RoomManagementPath     = "/admin/rooms"
+	RoomTrashPath          = "/admin/rooms/trash"
+	BookingManagementPath  = "/admin/bookings"
+	StaffManagementPath    = "/admin/staffs"
+	WorkOrderPath          = "/admin/work-orders"
+	AmenityManagementPath  = "/admin/amenities"
+	PropertyManagementPath = "/admin/properties"
+	ReviewManagementPath   = "/admin/reviews"
+	RatePlanManagementPath = "/admin/rate-plans"
+	PaymentManagementPath  = "/admin/payments"
+	BankTransferPath       = "/admin/payments/bank-transfers"
 
 	ADMIN    = "admin"
 	CUSTOMER = "customer"
@@ -17,6 +25,9 @@ const (
 	PAYMENT_SUCCESS = "success"
 	PAYMENT_PENDING = "pending"
 	PAYMENT_FAILED  = "failed"
+	// PAYMENT_EXPIRED is a payment the customer abandoned, closed by the
+	// reconciler once the provider's payment window has passed.
+	PAYMENT_EXPIRED = "expired"
 
 	HOTEL_ORDER_TYPE = "170003"
 )
@@ -0,0 +1,15 @@
+package constant
+
+const (
+	// VNPAY_CODE_SUCCESS is the vnp_ResponseCode and vnp_TransactionStatus of
+	// a successful payment.
+	VNPAY_CODE_SUCCESS = "00"
+
+	// RspCode values VNPay expects in the IPN response.
+	VNPAY_RSP_CONFIRMED         = "00"
+	VNPAY_RSP_ORDER_NOT_FOUND   = "01"
+	VNPAY_RSP_ALREADY_CONFIRMED = "02"
+	VNPAY_RSP_INVALID_AMOUNT    = "04"
+	VNPAY_RSP_INVALID_SIGNATURE = "97"
+	VNPAY_RSP_UNKNOWN_ERROR     = "99"
+)
@@ -0,0 +1,88 @@
+package constant
+
+const (
+	WORK_ORDER_OPEN        = "open"
+	WORK_ORDER_ASSIGNED    = "assigned"
+	WORK_ORDER_IN_PROGRESS = "in_progress"
+	WORK_ORDER_RESOLVED    = "resolved"
+	WORK_ORDER_CLOSED      = "closed"
+	WORK_ORDER_CANCELLED   = "cancelled"
+
+	WORK_ORDER_PRIORITY_LOW    = "low"
+	WORK_ORDER_PRIORITY_MEDIUM = "medium"
+	WORK_ORDER_PRIORITY_HIGH   = "high"
+	WORK_ORDER_PRIORITY_URGENT = "urgent"
+
+	WORK_ORDER_CATEGORY_AIRCON     = "aircon"
+	WORK_ORDER_CATEGORY_PLUMBING   = "plumbing"
+	WORK_ORDER_CATEGORY_ELECTRICAL = "electrical"
+	WORK_ORDER_CATEGORY_FURNITURE  = "furniture"
+	WORK_ORDER_CATEGORY_CLEANING   = "cleaning"
+	WORK_ORDER_CATEGORY_OTHER      = "other"
+)
+
+var WorkOrderStatuses = []string{
+	WORK_ORDER_OPEN,
+	WORK_ORDER_ASSIGNED,
+	WORK_ORDER_IN_PROGRESS,
+	WORK_ORDER_RESOLVED,
+	WORK_ORDER_CLOSED,
+	WORK_ORDER_CANCELLED,
+}
+
+var WorkOrderPriorities = []string{
+	WORK_ORDER_PRIORITY_LOW,
+	WORK_ORDER_PRIORITY_MEDIUM,
+	WORK_ORDER_PRIORITY_HIGH,
+	WORK_ORDER_PRIORITY_URGENT,
+}
+
+var WorkOrderCategories = []string{
+	WORK_ORDER_CATEGORY_AIRCON,
+	WORK_ORDER_CATEGORY_PLUMBING,
+	WORK_ORDER_CATEGORY_ELECTRICAL,
+	WORK_ORDER_CATEGORY_FURNITURE,
+	WORK_ORDER_CATEGORY_CLEANING,
+	WORK_ORDER_CATEGORY_OTHER,
+}
+
+// Work orders in these statuses no longer block the room they belong to.
+var FinishedWorkOrderStatuses = []string{WORK_ORDER_CLOSED, WORK_ORDER_CANCELLED}
+
+var workOrderTransitions = map[string][]string{
+	WORK_ORDER_OPEN:        {WORK_ORDER_ASSIGNED, WORK_ORDER_IN_PROGRESS, WORK_ORDER_CANCELLED},
+	WORK_ORDER_ASSIGNED:    {WORK_ORDER_IN_PROGRESS, WORK_ORDER_CANCELLED},
+	WORK_ORDER_IN_PROGRESS: {WORK_ORDER_RESOLVED, WORK_ORDER_CANCELLED},
+	WORK_ORDER_RESOLVED:    {WORK_ORDER_CLOSED, WORK_ORDER_IN_PROGRESS},
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidWorkOrderStatus(status string) bool {
+	return contains(WorkOrderStatuses, status)
+}
+
+func IsValidWorkOrderPriority(priority string) bool {
+	return contains(WorkOrderPriorities, priority)
+}
+
+func IsValidWorkOrderCategory(category string) bool {
+	return contains(WorkOrderCategories, category)
+}
+
+func CanTransitionWorkOrder(from, to string) bool {
+	return contains(workOrderTransitions[from], to)
+}
+
+// IsBlockingPriority reports whether a new work order with this priority
+// takes the room out of availability by default.
+func IsBlockingPriority(priority string) bool {
+	return priority == WORK_ORDER_PRIORITY_HIGH || priority == WORK_ORDER_PRIORITY_URGENT
+}
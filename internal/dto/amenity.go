@@ -0,0 +1,38 @@
+package dto
+
+import "hotel-management/internal/models"
+
+type AmenityRequest struct {
+	Code         string
+	Name         string
+	Icon         string
+	Category     string
+	Translations map[string]string
+}
+
+type AmenityResponse struct {
+	ID       uint   `json:"id"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Icon     string `json:"icon"`
+	Category string `json:"category"`
+}
+
+// NewAmenityResponse picks the amenity name for lang, falling back to the
+// default name when no translation exists.
+func NewAmenityResponse(amenity models.Amenity, lang string) AmenityResponse {
+	name := amenity.Name
+	for _, t := range amenity.Translations {
+		if t.Lang == lang && t.Name != "" {
+			name = t.Name
+			break
+		}
+	}
+	return AmenityResponse{
+		ID:       amenity.ID,
+		Code:     amenity.Code,
+		Name:     name,
+		Icon:     amenity.Icon,
+		Category: amenity.Category,
+	}
+}
@@ -8,26 +8,97 @@ type SearchRoomRequest struct {
 	BedNum    *int      `json:"bed_num"`
 	HasAircon *bool     `json:"has_aircon"`
 	ViewType  *string   `json:"view_type"`
-	MinPrice  *float64  `json:"min_price"`
-	MaxPrice  *float64  `json:"max_price"`
+	// ViewTypes matches rooms with any of the listed view types. ViewType is
+	// kept for older clients and is merged into this list.
+	ViewTypes []string `json:"view_types"`
+	MinPrice  *float64 `json:"min_price" binding:"omitempty,gte=0"`
+	MaxPrice  *float64 `json:"max_price" binding:"omitempty,gte=0"`
+	MinRating *float64 `json:"min_rating" binding:"omitempty,gte=0,lte=5"`
+	// Keyword is matched against the room name and description.
+	Keyword string `json:"q" binding:"max=100"`
+	Sort    string `json:"sort" binding:"omitempty,oneof=price_asc price_desc rating_desc capacity_asc capacity_desc"`
+	Page    int    `json:"page" binding:"gte=0"`
+	Limit   int    `json:"limit" binding:"gte=0"`
+	// AmenityIDs only matches rooms that have all of the listed amenities.
+	AmenityIDs []uint `json:"amenity_ids" binding:"omitempty,dive,gt=0"`
+	PropertyID *uint  `json:"property_id" binding:"omitempty,gt=0"`
+	City       string `json:"city" binding:"max=100"`
+	// Family adds pairs of available connecting rooms to the result. When
+	// BedNum is set it applies to the pair's beds combined.
+	Family bool `json:"family"`
 }
 
 type SearchRoomResponse struct {
-	ID            uint     `json:"id"`
-	Name          string   `json:"name"`
-	Type          string   `json:"type"`
-	PricePerNight float64  `json:"price_per_night"`
-	BedNum        int      `json:"bed_num"`
-	HasAircon     bool     `json:"has_aircon"`
-	ViewType      string   `json:"view_type"`
-	Description   string   `json:"description"`
-	ImageURLs     []string `json:"image_urls"`
+	ID            uint                `json:"id"`
+	Name          string              `json:"name"`
+	Type          string              `json:"type"`
+	PricePerNight float64             `json:"price_per_night"`
+	BedNum        int                 `json:"bed_num"`
+	HasAircon     bool                `json:"has_aircon"`
+	ViewType      string              `json:"view_type"`
+	Description   string              `json:"description"`
+	ImageURLs     []string            `json:"image_urls"`
+	Images        []RoomImageResponse `json:"images"`
+	Amenities     []AmenityResponse   `json:"amenities"`
+	AvgRating     float64             `json:"avg_rating"`
+	ReviewCount   int64               `json:"review_count"`
+	Property      *PropertyResponse   `json:"property"`
+	Number        string              `json:"number"`
+	Floor         int                 `json:"floor"`
+	Building      string              `json:"building"`
+	// ConnectingRoomIDs lists the rooms sharing an inner door with this one.
+	ConnectingRoomIDs []uint `json:"connecting_room_ids"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// SearchFacets counts the rooms matching the search by a few attributes so
+// clients can show how many results each refinement would leave.
+type SearchFacets struct {
+	ViewTypes []FacetCount `json:"view_types"`
+	Types     []FacetCount `json:"types"`
+	BedNums   []FacetCount `json:"bed_nums"`
+	HasAircon []FacetCount `json:"has_aircon"`
+}
+
+// ConnectingRoomSuggestion is a pair of available rooms joined by an inner
+// door, offered to families who want to book them together.
+type ConnectingRoomSuggestion struct {
+	Rooms              []SearchRoomResponse `json:"rooms"`
+	TotalPricePerNight float64              `json:"total_price_per_night"`
+	TotalBedNum        int                  `json:"total_bed_num"`
+}
+
+type SearchRoomResult struct {
+	Rooms      []SearchRoomResponse `json:"rooms"`
+	Pagination PageMeta             `json:"pagination"`
+	Facets     SearchFacets         `json:"facets"`
+	// ConnectingRooms is only filled for family searches.
+	ConnectingRooms []ConnectingRoomSuggestion `json:"connecting_rooms,omitempty"`
 }
 
 type CreateBookingRequest struct {
 	StartDate time.Time `json:"start_date" binding:"required"`
 	EndDate   time.Time `json:"end_date" binding:"required"`
 	RoomIDs   []int     `json:"room_ids" binding:"required,min=1"`
+	// RatePlanID decides when the booking is paid; without it the guest pays
+	// at the hotel.
+	RatePlanID *uint `json:"rate_plan_id" binding:"omitempty,gt=0"`
+}
+
+type CreateBookingResponse struct {
+	ID            uint    `json:"id"`
+	Status        string  `json:"status"`
+	TotalPrice    float64 `json:"total_price"`
+	PaymentTiming string  `json:"payment_timing"`
+	DepositAmount float64 `json:"deposit_amount"`
+	// AmountDueNow is what must be paid before HoldExpiresAt to keep the
+	// booking; it is 0 for pay-at-hotel bookings.
+	AmountDueNow  float64    `json:"amount_due_now"`
+	HoldExpiresAt *time.Time `json:"hold_expires_at"`
 }
 
 type BookingHistoryResponse struct {
@@ -38,6 +109,15 @@ type BookingHistoryResponse struct {
 	Status     string               `json:"status"`
 	IsPaid     bool                 `json:"is_paid"`
 	Rooms      []BookingHistoryRoom `json:"rooms"`
+
+	PaymentTiming string     `json:"payment_timing"`
+	DepositAmount float64    `json:"deposit_amount"`
+	HoldExpiresAt *time.Time `json:"hold_expires_at"`
+
+	PaidAmount     float64                `json:"paid_amount"`
+	RefundedAmount float64                `json:"refunded_amount"`
+	Balance        float64                `json:"balance"`
+	Charges        []BookingHistoryCharge `json:"charges"`
 }
 
 type BookingHistoryRoom struct {
@@ -46,4 +126,24 @@ type BookingHistoryRoom struct {
 	Type   string  `json:"type"`
 	BedNum int     `json:"bed_num"`
 	Price  float64 `json:"price"`
+	// BookingRoomID identifies the room as a line item to pay for.
+	BookingRoomID uint `json:"booking_room_id"`
+}
+
+// BookingHistoryCharge is an extra charged on a booking, identified by ID as
+// a line item to pay for.
+type BookingHistoryCharge struct {
+	ID          uint    `json:"id"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+}
+
+// BookingLineItem is a room or a charge of a booking on the admin booking
+// page, with what payments allocated to it have paid.
+type BookingLineItem struct {
+	Type        string
+	ID          uint
+	Description string
+	Amount      float64
+	Paid        float64
 }
@@ -0,0 +1,82 @@
+package dto
+
+import "time"
+
+type PublicRoomQuery struct {
+	PageQuery
+	Type       string `form:"type"`
+	PropertyID uint   `form:"property_id"`
+	City       string `form:"city" binding:"max=100"`
+}
+
+type RatingSummary struct {
+	RoomID      uint    `json:"room_id"`
+	AvgRating   float64 `json:"avg_rating"`
+	ReviewCount int64   `json:"review_count"`
+}
+
+type RoomTypeSummary struct {
+	Type      string
+	RoomCount int64
+	MinPrice  float64
+	MaxPrice  float64
+}
+
+type PublicRoomResponse struct {
+	ID            uint                `json:"id"`
+	Name          string              `json:"name"`
+	Type          string              `json:"type"`
+	PricePerNight float64             `json:"price_per_night"`
+	BedNum        int                 `json:"bed_num"`
+	HasAircon     bool                `json:"has_aircon"`
+	ViewType      string              `json:"view_type"`
+	Images        []RoomImageResponse `json:"images"`
+	Amenities     []AmenityResponse   `json:"amenities"`
+	AvgRating     float64             `json:"avg_rating"`
+	ReviewCount   int64               `json:"review_count"`
+	Property      *PropertyResponse   `json:"property"`
+	Number        string              `json:"number"`
+	Floor         int                 `json:"floor"`
+	Building      string              `json:"building"`
+	// ConnectingRoomIDs lists the rooms sharing an inner door with this one.
+	ConnectingRoomIDs []uint `json:"connecting_room_ids"`
+}
+
+type PublicRoomDetailResponse struct {
+	PublicRoomResponse
+	Description        string                 `json:"description"`
+	RecentReviews      []PublicReviewResponse `json:"recent_reviews"`
+	RatingDistribution []RatingBucket         `json:"rating_distribution"`
+	CriteriaRatings    CriteriaRatings        `json:"criteria_ratings"`
+}
+
+type PublicReviewResponse struct {
+	ID           uint      `json:"id"`
+	Rating       int       `json:"rating"`
+	Comment      string    `json:"comment"`
+	ReviewerName string    `json:"reviewer_name"`
+	CreatedAt    time.Time `json:"created_at"`
+	// Criteria is nil for reviews written before sub-scores existed.
+	Criteria           *CriteriaRatings      `json:"criteria"`
+	Photos             []ReviewPhotoResponse `json:"photos"`
+	ManagementResponse *ManagementResponse   `json:"management_response"`
+}
+
+// ManagementResponse is the hotel's public reply to a review.
+type ManagementResponse struct {
+	Comment     string    `json:"comment"`
+	RespondedAt time.Time `json:"responded_at"`
+}
+
+type RoomTypeResponse struct {
+	Type       string             `json:"type"`
+	RoomCount  int64              `json:"room_count"`
+	MinPrice   float64            `json:"min_price"`
+	MaxPrice   float64            `json:"max_price"`
+	CoverImage *RoomImageResponse `json:"cover_image"`
+}
+
+type PublicRoomListResponse struct {
+	Rooms []PublicRoomResponse `json:"rooms"`
+	PageMeta
+}
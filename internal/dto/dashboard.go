@@ -4,5 +4,7 @@ type StatisticDashboard struct {
 	TotalRooms     int64
 	TotalCustomers int64
 	TotalBookings  int64
-	TotalRevenue   float64
+	// TotalRevenue is net of TotalRefunded.
+	TotalRevenue  float64
+	TotalRefunded float64
 }
@@ -0,0 +1,40 @@
+package dto
+
+import "hotel-management/internal/constant"
+
+type PageQuery struct {
+	Page  int `form:"page" json:"page"`
+	Limit int `form:"limit" json:"limit"`
+}
+
+// Normalize fills in defaults and caps the page size.
+func (q *PageQuery) Normalize() {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Limit < 1 {
+		q.Limit = constant.DefaultPageSize
+	}
+	if q.Limit > constant.MaxPageSize {
+		q.Limit = constant.MaxPageSize
+	}
+}
+
+func (q PageQuery) Offset() int {
+	return (q.Page - 1) * q.Limit
+}
+
+type PageMeta struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+func NewPageMeta(q PageQuery, total int64) PageMeta {
+	totalPages := 0
+	if q.Limit > 0 {
+		totalPages = int((total + int64(q.Limit) - 1) / int64(q.Limit))
+	}
+	return PageMeta{Page: q.Page, Limit: q.Limit, Total: total, TotalPages: totalPages}
+}
@@ -0,0 +1,140 @@
+package dto
+
+import "time"
+
+// CreatePaymentRequest pays the booking's balance, or when the bill is split,
+// the given line items or amount on behalf of one payer.
+type CreatePaymentRequest struct {
+	PaymentMethod string `json:"payment_method" binding:"required"`
+	PayerName     string `json:"payer_name" binding:"max=100"`
+	// Amount pays part of the balance. It is ignored when line items are
+	// given.
+	Amount    float64           `json:"amount" binding:"gte=0"`
+	LineItems []PaymentLineItem `json:"line_items" binding:"dive"`
+}
+
+// PaymentLineItem is a booked room or a charge of the booking, by the ID of
+// its booking room or charge.
+type PaymentLineItem struct {
+	Type string `json:"type" binding:"required,oneof=room charge"`
+	ID   uint   `json:"id" binding:"required"`
+}
+
+// IsSplit reports whether the payment covers only part of the balance.
+func (r CreatePaymentRequest) IsSplit() bool {
+	return r.Amount > 0 || len(r.LineItems) > 0
+}
+
+type CreatePaymentResponse struct {
+	PaymentID     uint    `json:"payment_id"`
+	BookingID     uint    `json:"booking_id"`
+	TxnRef        string  `json:"txn_ref"`
+	PaymentMethod string  `json:"payment_method"`
+	PayerName     string  `json:"payer_name"`
+	Amount        float64 `json:"amount"`
+	PaymentStatus string  `json:"payment_status"`
+	// PaymentURL is where to pay online; it is empty for payments made at
+	// the front desk.
+	PaymentURL string `json:"payment_url"`
+	// BankTransfer is set for payments made by bank transfer.
+	BankTransfer *BankTransferResponse `json:"bank_transfer,omitempty"`
+}
+
+// BankTransferResponse tells the guest how to pay by bank transfer. Showing
+// QRPayload as a QR code lets banking apps prefill the transfer; the
+// description must keep the reference for the transfer to be matched.
+type BankTransferResponse struct {
+	BankBIN       string `json:"bank_bin"`
+	AccountNumber string `json:"account_number"`
+	AccountName   string `json:"account_name"`
+	Reference     string `json:"reference"`
+	QRPayload     string `json:"qr_payload"`
+}
+
+// ConfirmPaymentRequest is filled in by staff taking a payment at the desk.
+type ConfirmPaymentRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	// Reference is the card terminal's approval code or the bank's
+	// transaction ID of a transfer.
+	Reference string `json:"reference" binding:"max=100"`
+}
+
+type VnpayReturnResponse struct {
+	BookingID uint    `json:"booking_id"`
+	TxnRef    string  `json:"txn_ref"`
+	Amount    float64 `json:"amount"`
+	// PaymentStatus is the status recorded from VNPay's IPN; it stays
+	// pending until the IPN arrives.
+	PaymentStatus string `json:"payment_status"`
+	ResponseCode  string `json:"response_code"`
+}
+
+// CreateRefundRequest is the refund form on the admin booking page. Amount
+// is only read for partial refunds.
+type CreateRefundRequest struct {
+	PaymentID uint    `form:"payment_id" binding:"required"`
+	Type      string  `form:"type" binding:"required,oneof=full partial"`
+	Amount    float64 `form:"amount" binding:"gte=0"`
+	Reason    string  `form:"reason" binding:"required,max=500"`
+}
+
+// CreateChargeRequest is the extra charge form on the admin booking page.
+type CreateChargeRequest struct {
+	Description string  `form:"description" binding:"required,max=255"`
+	Amount      float64 `form:"amount" binding:"required,gt=0"`
+}
+
+// PaymentFilter holds the filters of the admin payments page. Dates are
+// inclusive days.
+type PaymentFilter struct {
+	PaymentMethod string    `form:"payment_method" binding:"max=50"`
+	PaymentStatus string    `form:"payment_status" binding:"omitempty,oneof=success pending failed expired"`
+	BookingID     uint      `form:"booking_id"`
+	From          time.Time `form:"from" time_format:"2006-01-02"`
+	To            time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+// PaymentResponse is a payment as shown to the customer who made it.
+type PaymentResponse struct {
+	ID             uint      `json:"id"`
+	BookingID      uint      `json:"booking_id"`
+	TxnRef         string    `json:"txn_ref"`
+	TransactionID  string    `json:"transaction_id"`
+	PaymentMethod  string    `json:"payment_method"`
+	PayerName      string    `json:"payer_name"`
+	Amount         float64   `json:"amount"`
+	RefundedAmount float64   `json:"refunded_amount"`
+	PaymentStatus  string    `json:"payment_status"`
+	CreatedAt      time.Time `json:"created_at"`
+	PaidAt         time.Time `json:"paid_at"`
+}
+
+// BankStatementImportRequest is a bank statement uploaded to confirm the
+// transfers it lists.
+type BankStatementImportRequest struct {
+	FileName string
+	Data     []byte
+	// PropertyID limits matching to the payments of the property being
+	// managed.
+	PropertyID uint
+}
+
+type BankStatementRowResult struct {
+	Line          int
+	TransactionID string
+	Amount        float64
+	Description   string
+	PaymentID     uint
+	BookingID     uint
+	Outcome       string
+	// Error is the translation key of why the line was not matched.
+	Error string
+}
+
+type BankStatementReport struct {
+	Rows      []BankStatementRowResult
+	Matched   int
+	Duplicate int
+	Unmatched int
+	Failed    int
+}
@@ -0,0 +1,44 @@
+package dto
+
+import "hotel-management/internal/models"
+
+type PropertyRequest struct {
+	Code     string
+	Name     string
+	City     string
+	Address  string
+	Phone    string
+	IsActive bool
+	StaffIDs []uint
+}
+
+type PropertyResponse struct {
+	ID      uint   `json:"id"`
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	City    string `json:"city"`
+	Address string `json:"address"`
+	Phone   string `json:"phone"`
+}
+
+func NewPropertyResponse(property models.Property) PropertyResponse {
+	return PropertyResponse{
+		ID:      property.ID,
+		Code:    property.Code,
+		Name:    property.Name,
+		City:    property.City,
+		Address: property.Address,
+		Phone:   property.Phone,
+	}
+}
+
+type PropertyQuery struct {
+	City string `form:"city" binding:"max=100"`
+}
+
+// PropertySwitcher feeds the property selector in the admin header.
+type PropertySwitcher struct {
+	CurrentID  uint               `json:"current_id"`
+	AllowAll   bool               `json:"allow_all"`
+	Properties []PropertyResponse `json:"properties"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import "hotel-management/internal/models"
+
+type RatePlanResponse struct {
+	ID             uint   `json:"id"`
+	PropertyID     uint   `json:"property_id"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	PaymentTiming  string `json:"payment_timing"`
+	DepositPercent int    `json:"deposit_percent"`
+}
+
+func NewRatePlanResponse(ratePlan models.RatePlan) RatePlanResponse {
+	return RatePlanResponse{
+		ID:             ratePlan.ID,
+		PropertyID:     ratePlan.PropertyID,
+		Name:           ratePlan.Name,
+		Description:    ratePlan.Description,
+		PaymentTiming:  ratePlan.PaymentTiming,
+		DepositPercent: ratePlan.DepositPercent,
+	}
+}
+
+type RatePlanQuery struct {
+	PropertyID uint `form:"property_id" binding:"required"`
+}
+
+// RatePlanRequest is the admin rate plan form.
+type RatePlanRequest struct {
+	PropertyID     uint
+	Name           string
+	Description    string
+	PaymentTiming  string
+	DepositPercent int
+	IsActive       bool
+}
@@ -1,8 +1,98 @@
 package dto
 
+import (
+	"mime/multipart"
+	"time"
+)
+
 type CreateReviewRequest struct {
-	BookingID uint   `json:"booking_id" binding:"required"`
-	RoomID    uint   `json:"room_id" binding:"required"`
-	Rating    int    `json:"rating" binding:"required,min=1,max=5"`
-	Comment   string `json:"comment"`
+	BookingID   uint   `json:"booking_id" form:"booking_id" binding:"required"`
+	RoomID      uint   `json:"room_id" form:"room_id" binding:"required"`
+	Rating      int    `json:"rating" form:"rating" binding:"required,min=1,max=5"`
+	Comment     string `json:"comment" form:"comment"`
+	Cleanliness int    `json:"cleanliness" form:"cleanliness" binding:"required,min=1,max=5"`
+	Service     int    `json:"service" form:"service" binding:"required,min=1,max=5"`
+	Location    int    `json:"location" form:"location" binding:"required,min=1,max=5"`
+	Value       int    `json:"value" form:"value" binding:"required,min=1,max=5"`
+	Comfort     int    `json:"comfort" form:"comfort" binding:"required,min=1,max=5"`
+	// Photos are only accepted on multipart requests.
+	Photos []*multipart.FileHeader `json:"-" form:"-"`
+}
+
+// UpdateReviewRequest replaces the text and scores of a review; photos
+// cannot be changed.
+type UpdateReviewRequest struct {
+	Rating      int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment     string `json:"comment"`
+	Cleanliness int    `json:"cleanliness" binding:"required,min=1,max=5"`
+	Service     int    `json:"service" binding:"required,min=1,max=5"`
+	Location    int    `json:"location" binding:"required,min=1,max=5"`
+	Value       int    `json:"value" binding:"required,min=1,max=5"`
+	Comfort     int    `json:"comfort" binding:"required,min=1,max=5"`
+}
+
+// PendingReviewResponse is a room of a checked-out booking the customer has
+// not reviewed yet.
+type PendingReviewResponse struct {
+	BookingID  uint      `json:"booking_id"`
+	RoomID     uint      `json:"room_id"`
+	RoomName   string    `json:"room_name"`
+	RoomNumber string    `json:"room_number"`
+	RoomType   string    `json:"room_type"`
+	StartDate  time.Time `json:"start_date"`
+	EndDate    time.Time `json:"end_date"`
+}
+
+// CriteriaRatings holds a score per review criterion: a review's sub-scores
+// or a room's averages.
+type CriteriaRatings struct {
+	Cleanliness float64 `json:"cleanliness"`
+	Service     float64 `json:"service"`
+	Location    float64 `json:"location"`
+	Value       float64 `json:"value"`
+	Comfort     float64 `json:"comfort"`
+}
+
+type ReviewPhotoResponse struct {
+	ImageURL     string `json:"image_url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+type RoomReviewQuery struct {
+	PageQuery
+	Sort string `form:"sort" binding:"omitempty,oneof=newest highest"`
+}
+
+// RatingBucket is one bar of a room's rating histogram.
+type RatingBucket struct {
+	Rating int   `json:"rating"`
+	Count  int64 `json:"count"`
+}
+
+type RoomReviewListResponse struct {
+	RoomID             uint                   `json:"room_id"`
+	AvgRating          float64                `json:"avg_rating"`
+	ReviewCount        int64                  `json:"review_count"`
+	RatingDistribution []RatingBucket         `json:"rating_distribution"`
+	CriteriaRatings    CriteriaRatings        `json:"criteria_ratings"`
+	Reviews            []PublicReviewResponse `json:"reviews"`
+	PageMeta
+}
+
+type ReviewQuery struct {
+	RoomID int    `form:"room_id"`
+	Rating int    `form:"rating"`
+	Status string `form:"status"`
+	// PropertyID is the admin's current property, not a query parameter.
+	PropertyID uint `form:"-"`
+}
+
+type ModerateReviewRequest struct {
+	Action string `form:"action" binding:"required"`
+	Reason string `form:"reason" binding:"max=500"`
+}
+
+type ReviewReplyRequest struct {
+	// Response is the public reply; an empty response removes it.
+	Response string `form:"response" binding:"max=2000"`
 }
@@ -15,10 +15,18 @@ type CreateRoomRequest struct {
 	Description   string
 	IsAvailable   bool
 	ImageFiles    []*multipart.FileHeader
+	AmenityIDs    []uint
+	PropertyID    uint
+	Number        string
+	Floor         int
+	Building      string
+	// ConnectingRoomIDs replaces the room's connecting rooms.
+	ConnectingRoomIDs []uint
 }
 
 type EditRoomRequest struct {
 	ID            int
+	PropertyID    uint
 	Name          string
 	Type          string
 	PricePerNight float64
@@ -29,6 +37,21 @@ type EditRoomRequest struct {
 	IsAvailable   bool
 	ImageFiles    []*multipart.FileHeader
 	ImageDeletes  []int
+	AmenityIDs    []uint
+	ImageMeta     []RoomImageMeta
+	Number        string
+	Floor         int
+	Building      string
+	// ConnectingRoomIDs replaces the room's connecting rooms.
+	ConnectingRoomIDs []uint
+	// PrimaryImageID is 0 when the primary image should be left as is.
+	PrimaryImageID int
+}
+
+type RoomImageMeta struct {
+	ID        int
+	AltText   string
+	SortOrder int
 }
 
 type RoomQuery struct {
@@ -36,9 +59,58 @@ type RoomQuery struct {
 	HasAircon string  `form:"has_aircon"`
 	MinPrice  float64 `form:"min_price"`
 	MaxPrice  float64 `form:"max_price"`
+	Floor     string  `form:"floor"`
+	Building  string  `form:"building"`
+	// GroupBy is empty for a flat list or "floor".
+	GroupBy string `form:"group_by"`
+	// PropertyID is the admin's current property, not a query parameter.
+	PropertyID uint `form:"-"`
+}
+
+// RoomFloorGroup is one floor of one building in the admin room list.
+type RoomFloorGroup struct {
+	Building string
+	Floor    int
+	Rooms    []models.Room
 }
 
 type RoomDetailResponse struct {
 	Room           *models.Room
 	ActiveBookings []models.Booking
 }
+
+type RoomImageResponse struct {
+	ID           uint   `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	MediumURL    string `json:"medium_url"`
+	LargeURL     string `json:"large_url"`
+	AltText      string `json:"alt_text"`
+	IsPrimary    bool   `json:"is_primary"`
+	SortOrder    int    `json:"sort_order"`
+}
+
+// NewRoomImageResponse falls back to the original URL for images uploaded
+// before variants were generated.
+func NewRoomImageResponse(image models.RoomImage) RoomImageResponse {
+	res := RoomImageResponse{
+		ID:           image.ID,
+		URL:          image.ImageURL,
+		ThumbnailURL: image.ThumbnailURL,
+		MediumURL:    image.MediumURL,
+		LargeURL:     image.LargeURL,
+		AltText:      image.AltText,
+		IsPrimary:    image.IsPrimary,
+		SortOrder:    image.SortOrder,
+	}
+	if res.ThumbnailURL == "" {
+		res.ThumbnailURL = image.ImageURL
+	}
+	if res.MediumURL == "" {
+		res.MediumURL = image.ImageURL
+	}
+	if res.LargeURL == "" {
+		res.LargeURL = image.ImageURL
+	}
+	return res
+}
@@ -0,0 +1,43 @@
+package dto
+
+import "archive/zip"
+
+type RoomImportRequest struct {
+	FileName string
+	Data     []byte
+	// Bundle is an optional zip archive the image references are read from.
+	Bundle *zip.Reader
+	DryRun bool
+	// PropertyID is the property the rooms are imported into; names are only
+	// matched against that property's rooms.
+	PropertyID uint
+}
+
+// RoomImportError is a translation key plus the offending value, if any.
+type RoomImportError struct {
+	Column string `json:"column"`
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+}
+
+type RoomImportRowResult struct {
+	Line   int               `json:"line"`
+	Name   string            `json:"name"`
+	Action string            `json:"action"`
+	Errors []RoomImportError `json:"errors"`
+}
+
+type RoomImportReport struct {
+	DryRun  bool                  `json:"dry_run"`
+	Applied bool                  `json:"applied"`
+	Rows    []RoomImportRowResult `json:"rows"`
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Failed  int                   `json:"failed"`
+}
+
+type RoomExportFile struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "mime/multipart"
+
+type CreateWorkOrderRequest struct {
+	RoomID      uint                    `form:"room_id" binding:"required"`
+	Title       string                  `form:"title" binding:"required,max=150"`
+	Description string                  `form:"description"`
+	Category    string                  `form:"category" binding:"required"`
+	Priority    string                  `form:"priority" binding:"required"`
+	AssigneeID  *uint                   `form:"assignee_id"`
+	BlocksRoom  *bool                   `form:"blocks_room"`
+	Photos      []*multipart.FileHeader `form:"-"`
+}
+
+type UpdateWorkOrderStatusRequest struct {
+	Status         string `json:"status" form:"status" binding:"required"`
+	ResolutionNote string `json:"resolution_note" form:"resolution_note"`
+}
+
+type AssignWorkOrderRequest struct {
+	AssigneeID uint `json:"assignee_id" form:"assignee_id" binding:"required"`
+}
+
+type WorkOrderQuery struct {
+	RoomID     int    `form:"room_id"`
+	Status     string `form:"status"`
+	Priority   string `form:"priority"`
+	Category   string `form:"category"`
+	AssigneeID int    `form:"assignee_id"`
+	PropertyID uint   `form:"-"`
+}
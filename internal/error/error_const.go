@@ -17,3 +17,180 @@ var (
 	ErrFailedToUpdateBooking   = errors.New("error.failed_to_update_booking")
 	ErrFailedToCreateBill      = errors.New("error.failed_to_create_bill")
 )
+
+var (
+	ErrWorkOrderNotFound                = errors.New("error.work_order_not_found")
+	ErrFailedToGetWorkOrder             = errors.New("error.failed_to_get_work_order")
+	ErrFailedToCreateWorkOrder          = errors.New("error.failed_to_create_work_order")
+	ErrFailedToUpdateWorkOrder          = errors.New("error.failed_to_update_work_order")
+	ErrInvalidWorkOrderStatus           = errors.New("error.invalid_work_order_status")
+	ErrInvalidWorkOrderStatusTransition = errors.New("error.invalid_work_order_status_transition")
+	ErrInvalidWorkOrderPriority         = errors.New("error.invalid_work_order_priority")
+	ErrInvalidWorkOrderCategory         = errors.New("error.invalid_work_order_category")
+	ErrResolutionNoteRequired           = errors.New("error.resolution_note_required")
+	ErrInvalidAssignee                  = errors.New("error.invalid_assignee")
+	ErrRoomNotFound                     = errors.New("error.room_not_found")
+	ErrFailedToSaveFile                 = errors.New("error.failed_to_save_file")
+)
+
+var (
+	ErrAmenityNotFound        = errors.New("error.amenity_not_found")
+	ErrFailedToGetAmenity     = errors.New("error.failed_to_get_amenity")
+	ErrFailedToSaveAmenity    = errors.New("error.failed_to_save_amenity")
+	ErrFailedToDeleteAmenity  = errors.New("error.failed_to_delete_amenity")
+	ErrAmenityCodeExists      = errors.New("error.amenity_code_exists")
+	ErrInvalidAmenityCategory = errors.New("error.invalid_amenity_category")
+	ErrInvalidAmenityIDs      = errors.New("error.invalid_amenity_ids")
+)
+
+var (
+	ErrInvalidImage            = errors.New("error.invalid_image")
+	ErrInvalidImageType        = errors.New("error.invalid_image_type")
+	ErrImageTooLarge           = errors.New("error.image_too_large")
+	ErrImageDimensionsTooLarge = errors.New("error.image_dimensions_too_large")
+	ErrFailedToSaveRoomImage   = errors.New("error.failed_to_save_room_image")
+	ErrRoomImageNotFound       = errors.New("error.room_image_not_found")
+)
+
+var (
+	ErrRoomHasUpcomingBookings = errors.New("error.room_has_upcoming_bookings")
+	ErrFailedToArchiveRoom     = errors.New("error.failed_to_archive_room")
+	ErrFailedToRestoreRoom     = errors.New("error.failed_to_restore_room")
+	ErrArchivedRoomNotFound    = errors.New("error.archived_room_not_found")
+)
+
+var (
+	ErrUnsupportedSpreadsheet  = errors.New("error.unsupported_spreadsheet_format")
+	ErrInvalidSpreadsheet      = errors.New("error.invalid_spreadsheet")
+	ErrImportFileRequired      = errors.New("error.import_file_required")
+	ErrImportFileTooLarge      = errors.New("error.import_file_too_large")
+	ErrInvalidImportBundle     = errors.New("error.invalid_import_bundle")
+	ErrImportMissingColumns    = errors.New("error.import_missing_columns")
+	ErrImportTooManyRows       = errors.New("error.import_too_many_rows")
+	ErrImportEmpty             = errors.New("error.import_empty")
+	ErrFailedToImportRooms     = errors.New("error.failed_to_import_rooms")
+	ErrFailedToExportRooms     = errors.New("error.failed_to_export_rooms")
+	ErrImportRequiredField     = errors.New("error.import_required_field")
+	ErrImportValueTooLong      = errors.New("error.import_value_too_long")
+	ErrImportInvalidPrice      = errors.New("error.invalid_price_per_night")
+	ErrImportInvalidBedNum     = errors.New("error.invalid_bed_num")
+	ErrImportInvalidBoolean    = errors.New("error.import_invalid_boolean")
+	ErrImportDuplicateName     = errors.New("error.import_duplicate_name")
+	ErrImportAmbiguousRoom     = errors.New("error.import_ambiguous_room")
+	ErrImportUnknownAmenity    = errors.New("error.import_unknown_amenity")
+	ErrImportTooManyImages     = errors.New("error.too_many_images")
+	ErrInvalidImportImagePath  = errors.New("error.invalid_import_image_path")
+	ErrImportImageNotFound     = errors.New("error.import_image_not_found")
+	ErrUnsupportedExportFormat = errors.New("error.unsupported_export_format")
+)
+
+var (
+	ErrPropertyNotFound           = errors.New("error.property_not_found")
+	ErrFailedToGetProperty        = errors.New("error.failed_to_get_property")
+	ErrFailedToSaveProperty       = errors.New("error.failed_to_save_property")
+	ErrPropertyCodeExists         = errors.New("error.property_code_exists")
+	ErrInvalidStaffIDs            = errors.New("error.invalid_staff_ids")
+	ErrNoPropertyAssigned         = errors.New("error.no_property_assigned")
+	ErrPropertyAccessDenied       = errors.New("error.property_access_denied")
+	ErrPropertyRequired           = errors.New("error.property_required")
+	ErrRoomsInDifferentProperties = errors.New("error.rooms_in_different_properties")
+)
+
+var (
+	ErrRoomNumberRequired          = errors.New("error.room_number_required")
+	ErrRoomNumberExists            = errors.New("error.room_number_exists")
+	ErrInvalidFloor                = errors.New("error.invalid_floor")
+	ErrInvalidConnectingRooms      = errors.New("error.invalid_connecting_rooms")
+	ErrFailedToSaveConnectingRooms = errors.New("error.failed_to_save_connecting_rooms")
+	ErrImportDuplicateNumber       = errors.New("error.import_duplicate_number")
+	ErrImportInvalidFloor          = errors.New("error.invalid_floor")
+)
+
+var (
+	ErrFailedToGetReviews = errors.New("error.failed_to_get_reviews")
+)
+
+var (
+	ErrReviewNotFound           = errors.New("error.review_not_found")
+	ErrInvalidReviewStatus      = errors.New("error.invalid_review_status")
+	ErrInvalidReviewRating      = errors.New("error.invalid_review_rating")
+	ErrInvalidReviewAction      = errors.New("error.invalid_review_action")
+	ErrModerationReasonRequired = errors.New("error.moderation_reason_required")
+	ErrFailedToUpdateReview     = errors.New("error.failed_to_update_review")
+)
+
+var (
+	ErrRoomNotInBooking    = errors.New("error.room_not_in_booking")
+	ErrTooManyReviewPhotos = errors.New("error.too_many_review_photos")
+)
+
+var (
+	ErrReviewNotOwned          = errors.New("error.review_not_owned")
+	ErrReviewEditWindowExpired = errors.New("error.review_edit_window_expired")
+	ErrFailedToDeleteReview    = errors.New("error.failed_to_delete_review")
+)
+
+var (
+	ErrFailedToGetPendingReviews = errors.New("error.failed_to_get_pending_reviews")
+)
+
+var (
+	ErrInvalidVnpaySignature = errors.New("error.invalid_vnpay_signature")
+	ErrPaymentAmountMismatch = errors.New("error.payment_amount_mismatch")
+)
+
+var (
+	ErrUnknownPaymentMethod        = errors.New("error.unknown_payment_method")
+	ErrPaymentOperationUnsupported = errors.New("error.payment_operation_unsupported")
+	ErrPaymentReferenceRequired    = errors.New("error.payment_reference_required")
+	ErrPaymentGatewayUnavailable   = errors.New("error.payment_gateway_unavailable")
+	ErrGatewayTransactionNotFound  = errors.New("error.gateway_transaction_not_found")
+	ErrPaymentNotManual            = errors.New("error.payment_not_manual")
+	ErrFailedToCreatePayment       = errors.New("error.failed_to_create_payment")
+)
+
+var (
+	ErrPaymentNotRefundable = errors.New("error.payment_not_refundable")
+	ErrPaymentFullyRefunded = errors.New("error.payment_fully_refunded")
+	ErrInvalidRefundAmount  = errors.New("error.invalid_refund_amount")
+	ErrInvalidRefundType    = errors.New("error.invalid_refund_type")
+	ErrRefundReasonRequired = errors.New("error.refund_reason_required")
+	ErrFailedToCreateRefund = errors.New("error.failed_to_create_refund")
+	ErrFailedToUpdateRefund = errors.New("error.failed_to_update_refund")
+	ErrRefundFailed         = errors.New("error.refund_failed")
+	ErrRefundPending        = errors.New("error.refund_pending")
+)
+
+var (
+	ErrFailedToSaveReconciliation = errors.New("error.failed_to_save_reconciliation")
+	ErrFailedToGetReconciliations = errors.New("error.failed_to_get_reconciliations")
+)
+
+var (
+	ErrRatePlanNotFound       = errors.New("error.rate_plan_not_found")
+	ErrRatePlanNotForProperty = errors.New("error.rate_plan_not_for_property")
+	ErrFailedToGetRatePlan    = errors.New("error.failed_to_get_rate_plan")
+	ErrFailedToSaveRatePlan   = errors.New("error.failed_to_save_rate_plan")
+	ErrInvalidPaymentTiming   = errors.New("error.invalid_payment_timing")
+	ErrInvalidDepositPercent  = errors.New("error.invalid_deposit_percent")
+	ErrBookingHoldExpired     = errors.New("error.booking_hold_expired")
+)
+
+var (
+	ErrInvalidLineItem        = errors.New("error.invalid_line_item")
+	ErrLineItemAlreadyPaid    = errors.New("error.line_item_already_paid")
+	ErrPaymentExceedsBalance  = errors.New("error.payment_exceeds_balance")
+	ErrInvalidPaymentAmount   = errors.New("error.invalid_payment_amount")
+	ErrSplitPaymentNotAllowed = errors.New("error.split_payment_not_allowed")
+	ErrHoldNeedsOnlinePayment = errors.New("error.hold_needs_online_payment")
+	ErrPaymentInProgress      = errors.New("error.payment_in_progress")
+	ErrBookingNotChargeable   = errors.New("error.booking_not_chargeable")
+	ErrFailedToAddCharge      = errors.New("error.failed_to_add_charge")
+)
+
+var (
+	ErrInvalidTransferReference = errors.New("error.invalid_transfer_reference")
+	ErrPaymentNotBankTransfer   = errors.New("error.payment_not_bank_transfer")
+	ErrInvalidStatementAmount   = errors.New("error.invalid_statement_amount")
+	ErrTransferNotMatched       = errors.New("error.transfer_not_matched")
+)
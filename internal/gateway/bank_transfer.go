@@ -0,0 +1,135 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	appError "hotel-management/internal/error"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// BANK_TRANSFER_PARAM_DESCRIPTION carries the bank statement line a transfer
+// was matched from, so that it is kept with the stored callback.
+const BANK_TRANSFER_PARAM_DESCRIPTION = "description"
+
+const (
+	transferReferencePrefix = "HMS"
+	// transferReferenceIDLength is how many characters of the payment's
+	// UUID the reference keeps.
+	transferReferenceIDLength = 8
+)
+
+// transferReferencePattern finds a transfer reference in a bank statement
+// description: the booking ID, then the start of the payment's UUID.
+var transferReferencePattern = regexp.MustCompile(transferReferencePrefix + `(\d+)X([0-9A-F]{8})`)
+
+type BankTransferConfig struct {
+	// BankBIN is the NAPAS bank identification number of the hotel's bank.
+	BankBIN       string
+	AccountNumber string
+	AccountName   string
+}
+
+// BankTransferInstructions tell the guest where to transfer the money and
+// with which description.
+type BankTransferInstructions struct {
+	BankBIN       string
+	AccountNumber string
+	AccountName   string
+	Reference     string
+	// QRPayload is the VietQR code to show; banking apps scan it and prefill
+	// the transfer.
+	QRPayload string
+}
+
+// BankTransferGateway takes payments by transfer to the hotel's bank account.
+// The guest scans a VietQR code carrying the amount and the payment's
+// transfer reference. No bank notifies the system: staff confirm the
+// transfer, by hand or by importing a bank statement.
+type BankTransferGateway struct {
+	config BankTransferConfig
+	// manual reads confirmations; the bank's transaction ID is required.
+	manual *ManualGateway
+}
+
+func NewBankTransferGateway(config BankTransferConfig) *BankTransferGateway {
+	return &BankTransferGateway{
+		config: config,
+		manual: &ManualGateway{method: constant.PAYMENT_METHOD_BANK_TRANSFER, requireReference: true},
+	}
+}
+
+func (g *BankTransferGateway) Method() string {
+	return constant.PAYMENT_METHOD_BANK_TRANSFER
+}
+
+// CreatePayment builds the VietQR code of the transfer.
+func (g *BankTransferGateway) CreatePayment(ctx context.Context, req PaymentRequest) (*CreatePaymentResult, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("error.invalid_amount")
+	}
+	if g.config.BankBIN == "" || g.config.AccountNumber == "" {
+		return nil, errors.New("error.vietqr_configuration_missing")
+	}
+	reference := TransferReference(req.TxnRef)
+	if reference == "" {
+		return nil, appError.ErrInvalidTransferReference
+	}
+	return &CreatePaymentResult{BankTransfer: &BankTransferInstructions{
+		BankBIN:       g.config.BankBIN,
+		AccountNumber: g.config.AccountNumber,
+		AccountName:   g.config.AccountName,
+		Reference:     reference,
+		QRPayload:     VietqrPayload(g.config.BankBIN, g.config.AccountNumber, req.Amount, reference),
+	}}, nil
+}
+
+// VerifyCallback reads a staff confirmation of a transfer; the caller has
+// already checked that the staff member may confirm payments.
+func (g *BankTransferGateway) VerifyCallback(ctx context.Context, params url.Values) (*Result, error) {
+	return g.manual.VerifyCallback(ctx, params)
+}
+
+func (g *BankTransferGateway) QueryStatus(ctx context.Context, txn Transaction) (*Result, error) {
+	return nil, appError.ErrPaymentOperationUnsupported
+}
+
+// Refund succeeds at once: the staff member recording the refund transfers
+// the money back.
+func (g *BankTransferGateway) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	return &RefundResult{Status: constant.PAYMENT_SUCCESS}, nil
+}
+
+func (g *BankTransferGateway) QueryRefund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	return g.manual.QueryRefund(ctx, req)
+}
+
+// TransferReference is the description a transfer paying the payment of the
+// given txn ref carries. It only uses letters and digits, which every bank
+// keeps, and fits in a VietQR purpose of transaction.
+func TransferReference(txnRef string) string {
+	bookingID, id, ok := strings.Cut(txnRef, "-")
+	id = strings.ReplaceAll(id, "-", "")
+	if !ok || bookingID == "" || len(id) < transferReferenceIDLength {
+		return ""
+	}
+	reference := transferReferencePrefix + bookingID + "X" + strings.ToUpper(id[:transferReferenceIDLength])
+	if len(reference) > VietqrMaxPurposeLength {
+		return ""
+	}
+	return reference
+}
+
+// ParseTransferReference finds a transfer reference in a bank statement
+// description and returns the start of the txn ref of its payment. Banks may
+// upper-case descriptions or break them with spaces.
+func ParseTransferReference(description string) (string, bool) {
+	text := strings.ToUpper(strings.Join(strings.Fields(description), ""))
+	match := transferReferencePattern.FindStringSubmatch(text)
+	if match == nil {
+		return "", false
+	}
+	return match[1] + "-" + strings.ToLower(match[2]), true
+}
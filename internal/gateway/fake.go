@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"hotel-management/internal/constant"
+	appError "hotel-management/internal/error"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// Parameters of a fake gateway callback.
+const (
+	FAKE_PARAM_TXN_REF = "txn_ref"
+	FAKE_PARAM_AMOUNT  = "amount"
+	FAKE_PARAM_STATUS  = "status"
+)
+
+// FakeGateway is a deterministic in-memory provider for tests and local
+// development. Payments stay pending until a callback or SetStatus settles
+// them, transaction IDs and refund IDs are numbered in order, and refunds
+// always succeed.
+type FakeGateway struct {
+	mu           sync.Mutex
+	transactions map[string]*Result
+	nextID       int
+	Refunds      []RefundRequest
+}
+
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{transactions: map[string]*Result{}}
+}
+
+func (g *FakeGateway) Method() string {
+	return constant.PAYMENT_METHOD_FAKE
+}
+
+func (g *FakeGateway) CreatePayment(ctx context.Context, req PaymentRequest) (*CreatePaymentResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.transactions[req.TxnRef] = &Result{TxnRef: req.TxnRef, Amount: req.Amount, Status: constant.PAYMENT_PENDING}
+	return &CreatePaymentResult{RedirectURL: "fake://pay/" + url.PathEscape(req.TxnRef)}, nil
+}
+
+// VerifyCallback accepts any callback; the status defaults to success.
+func (g *FakeGateway) VerifyCallback(ctx context.Context, params url.Values) (*Result, error) {
+	amount, err := strconv.ParseInt(params.Get(FAKE_PARAM_AMOUNT), 10, 64)
+	if err != nil {
+		return nil, appError.ErrPaymentAmountMismatch
+	}
+	status := params.Get(FAKE_PARAM_STATUS)
+	if status == "" {
+		status = constant.PAYMENT_SUCCESS
+	}
+	result := g.SetStatus(params.Get(FAKE_PARAM_TXN_REF), amount, status)
+	return &result, nil
+}
+
+func (g *FakeGateway) QueryStatus(ctx context.Context, txn Transaction) (*Result, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	result, ok := g.transactions[txn.TxnRef]
+	if !ok {
+		return nil, appError.ErrGatewayTransactionNotFound
+	}
+	copied := *result
+	return &copied, nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Refunds = append(g.Refunds, req)
+	return &RefundResult{RefundID: fmt.Sprintf("FAKE-REFUND-%d", len(g.Refunds)), Status: constant.PAYMENT_SUCCESS}, nil
+}
+
+// QueryRefund reports a refund as made when Refund received it.
+func (g *FakeGateway) QueryRefund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, refund := range g.Refunds {
+		if refund.TxnRef == req.TxnRef && refund.RefundAmount == req.RefundAmount {
+			return &RefundResult{RefundID: fmt.Sprintf("FAKE-REFUND-%d", i+1), Status: constant.PAYMENT_SUCCESS}, nil
+		}
+	}
+	return &RefundResult{Status: constant.PAYMENT_FAILED}, nil
+}
+
+// SetStatus settles a transaction as if the provider had reported it.
+func (g *FakeGateway) SetStatus(txnRef string, amount int64, status string) Result {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	result, ok := g.transactions[txnRef]
+	if !ok {
+		result = &Result{TxnRef: txnRef}
+		g.transactions[txnRef] = result
+	}
+	result.Amount = amount
+	result.Status = status
+	if status == constant.PAYMENT_SUCCESS && result.TransactionID == "" {
+		g.nextID++
+		result.TransactionID = fmt.Sprintf("FAKE-%d", g.nextID)
+	}
+	return *result
+}
@@ -0,0 +1,140 @@
+package gateway
+
+import (
+	"context"
+	appError "hotel-management/internal/error"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PaymentRequest asks a provider to start collecting a payment.
+type PaymentRequest struct {
+	TxnRef    string
+	BookingID uint
+	// Amount is in dong.
+	Amount   int64
+	ClientIP string
+}
+
+// CreatePaymentResult tells the customer how to pay.
+type CreatePaymentResult struct {
+	// RedirectURL is the provider's payment page; it is empty for payments
+	// settled in person.
+	RedirectURL string
+	// BankTransfer is set for payments made by bank transfer.
+	BankTransfer *BankTransferInstructions
+}
+
+// Transaction identifies a payment already sent to a provider.
+type Transaction struct {
+	TxnRef        string
+	TransactionID string
+	Amount        int64
+	CreatedAt     time.Time
+	ClientIP      string
+}
+
+// Result is the state of a transaction as reported by its provider. Status
+// is one of constant.PAYMENT_SUCCESS, PAYMENT_PENDING or PAYMENT_FAILED.
+type Result struct {
+	TxnRef        string
+	TransactionID string
+	Amount        int64
+	Status        string
+	ResponseCode  string
+}
+
+type RefundRequest struct {
+	Transaction
+	// RefundAmount is in dong; Full is set when it is the whole payment.
+	RefundAmount int64
+	Full         bool
+	Reason       string
+	CreatedBy    string
+}
+
+// RefundResult is the outcome of a refund. Status is constant.PAYMENT_SUCCESS,
+// PAYMENT_FAILED only when the provider definitely rejected the refund, or
+// PAYMENT_PENDING while it has not decided or its answer was not understood.
+type RefundResult struct {
+	RefundID string
+	Status   string
+}
+
+// Gateway is a payment provider. Payment.PaymentMethod names the gateway a
+// payment goes through.
+type Gateway interface {
+	Method() string
+	CreatePayment(ctx context.Context, req PaymentRequest) (*CreatePaymentResult, error)
+	// VerifyCallback authenticates a notification about a payment and reads
+	// its result. It does not change anything.
+	VerifyCallback(ctx context.Context, params url.Values) (*Result, error)
+	// QueryStatus returns ErrGatewayTransactionNotFound when the provider
+	// definitely has no record of the transaction; any other error leaves
+	// its state unknown.
+	QueryStatus(ctx context.Context, txn Transaction) (*Result, error)
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+	// QueryRefund asks the provider how a refund whose outcome was not known,
+	// for instance because the refund call timed out, ended.
+	QueryRefund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+}
+
+type Registry struct {
+	gateways map[string]Gateway
+}
+
+func NewRegistry(gateways ...Gateway) *Registry {
+	registry := &Registry{gateways: make(map[string]Gateway, len(gateways))}
+	for _, gateway := range gateways {
+		registry.gateways[gateway.Method()] = gateway
+	}
+	return registry
+}
+
+// NewRegistryFromEnv registers VNPay, cash, card terminal and bank transfer
+// payments. The
+// fake gateway is only added when PAYMENT_FAKE_ENABLED is "true", so it can
+// never take real bookings by accident.
+func NewRegistryFromEnv() *Registry {
+	gateways := []Gateway{
+		NewVnpayGateway(VnpayConfig{
+			TmnCode:    os.Getenv("VNPAY_TMN_CODE"),
+			HashSecret: os.Getenv("VNPAY_HASH_SECRET"),
+			PayURL:     os.Getenv("VNPAY_URL"),
+			ReturnURL:  os.Getenv("VNPAY_RETURN_URL"),
+			APIURL:     os.Getenv("VNPAY_API_URL"),
+		}),
+		NewCashGateway(),
+		NewCardTerminalGateway(),
+		NewBankTransferGateway(BankTransferConfig{
+			BankBIN:       os.Getenv("VIETQR_BANK_BIN"),
+			AccountNumber: os.Getenv("VIETQR_ACCOUNT_NO"),
+			AccountName:   os.Getenv("VIETQR_ACCOUNT_NAME"),
+		}),
+	}
+	if strings.TrimSpace(os.Getenv("PAYMENT_FAKE_ENABLED")) == "true" {
+		gateways = append(gateways, NewFakeGateway())
+	}
+	return NewRegistry(gateways...)
+}
+
+func (r *Registry) Get(method string) (Gateway, error) {
+	gateway, ok := r.gateways[method]
+	if !ok {
+		return nil, appError.ErrUnknownPaymentMethod
+	}
+	return gateway, nil
+}
+
+// Methods lists the registered payment methods in name order.
+func (r *Registry) Methods() []string {
+	methods := make([]string, 0, len(r.gateways))
+	for method := range r.gateways {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
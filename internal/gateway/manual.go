@@ -0,0 +1,89 @@
+package gateway
+
+import (
+	"context"
+	"hotel-management/internal/constant"
+	appError "hotel-management/internal/error"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Parameters of a manual payment confirmation.
+const (
+	MANUAL_PARAM_TXN_REF   = "txn_ref"
+	MANUAL_PARAM_AMOUNT    = "amount"
+	MANUAL_PARAM_REFERENCE = "reference"
+)
+
+// ManualGateway covers payments settled in person: cash at the front desk,
+// or a card terminal that is not connected to the system. Nothing is sent
+// anywhere; a staff member confirming the payment is the callback, and only
+// the database knows the status.
+type ManualGateway struct {
+	method string
+	// requireReference makes staff enter the terminal's approval code.
+	requireReference bool
+}
+
+func NewCashGateway() *ManualGateway {
+	return &ManualGateway{method: constant.PAYMENT_METHOD_CASH}
+}
+
+func NewCardTerminalGateway() *ManualGateway {
+	return &ManualGateway{method: constant.PAYMENT_METHOD_CARD_TERMINAL, requireReference: true}
+}
+
+// IsManual reports whether payments through the gateway are confirmed by
+// staff rather than by the provider. Bank transfers are, since no bank
+// notifies the system.
+func IsManual(gateway Gateway) bool {
+	switch gateway.(type) {
+	case *ManualGateway, *BankTransferGateway:
+		return true
+	}
+	return false
+}
+
+func (g *ManualGateway) Method() string {
+	return g.method
+}
+
+// CreatePayment has nothing to send: the guest pays at the desk.
+func (g *ManualGateway) CreatePayment(ctx context.Context, req PaymentRequest) (*CreatePaymentResult, error) {
+	return &CreatePaymentResult{}, nil
+}
+
+// VerifyCallback reads a staff confirmation; the caller has already checked
+// that the staff member may confirm payments.
+func (g *ManualGateway) VerifyCallback(ctx context.Context, params url.Values) (*Result, error) {
+	amount, err := strconv.ParseInt(params.Get(MANUAL_PARAM_AMOUNT), 10, 64)
+	if err != nil || amount < 0 {
+		return nil, appError.ErrPaymentAmountMismatch
+	}
+	reference := strings.TrimSpace(params.Get(MANUAL_PARAM_REFERENCE))
+	if g.requireReference && reference == "" {
+		return nil, appError.ErrPaymentReferenceRequired
+	}
+	return &Result{
+		TxnRef:        params.Get(MANUAL_PARAM_TXN_REF),
+		TransactionID: reference,
+		Amount:        amount,
+		Status:        constant.PAYMENT_SUCCESS,
+	}, nil
+}
+
+func (g *ManualGateway) QueryStatus(ctx context.Context, txn Transaction) (*Result, error) {
+	return nil, appError.ErrPaymentOperationUnsupported
+}
+
+// Refund succeeds at once: the money is handed back at the desk or on the
+// terminal by the staff member recording the refund.
+func (g *ManualGateway) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	return &RefundResult{Status: constant.PAYMENT_SUCCESS}, nil
+}
+
+// QueryRefund is not needed: manual refunds never stay pending.
+func (g *ManualGateway) QueryRefund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	return nil, appError.ErrPaymentOperationUnsupported
+}
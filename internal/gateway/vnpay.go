@@ -0,0 +1,458 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hotel-management/internal/constant"
+	appError "hotel-management/internal/error"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	vnpayVersion    = "2.1.0"
+	vnpayTimeLayout = "20060102150405"
+	vnpayMaxAmount  = 999999999
+	// vnpayTransactionPending is the vnp_TransactionStatus of a payment the
+	// customer has not finished.
+	vnpayTransactionPending = "01"
+	vnpayRefundFull         = "02"
+	vnpayRefundPartial      = "03"
+	// vnp_TransactionStatus of a refund VNPay or the bank is still
+	// processing, and of one the bank rejected.
+	vnpayRefundProcessing = "05"
+	vnpayRefundSentToBank = "06"
+	vnpayRefundDeclined   = "09"
+	// vnpayTxnNotFound is the querydr response code for a transaction VNPay
+	// has no record of, such as one whose payment page was never submitted.
+	vnpayTxnNotFound = "91"
+)
+
+// vnpayRefundRejectedCodes are the refund API response codes saying the
+// refund was not made: bad terminal, bad request, unknown transaction, a
+// transaction that cannot be refunded and a bad checksum. Other codes, such
+// as 94 (a refund of the transaction is already in progress) and 99, leave
+// the outcome open.
+var vnpayRefundRejectedCodes = map[string]bool{
+	"02": true, "03": true, "91": true, "95": true, "97": true,
+}
+
+// vnpayZone is the time zone VNPay reads and writes dates in.
+var vnpayZone = time.FixedZone("GMT+7", 7*60*60)
+
+// Fields signed, in order, by merchant API requests and responses.
+var (
+	VnpayQueryRequestFields = []string{
+		"vnp_RequestId", "vnp_Version", "vnp_Command", "vnp_TmnCode", "vnp_TxnRef",
+		"vnp_TransactionDate", "vnp_CreateDate", "vnp_IpAddr", "vnp_OrderInfo",
+	}
+	VnpayQueryResponseFields = []string{
+		"vnp_ResponseId", "vnp_Command", "vnp_ResponseCode", "vnp_Message", "vnp_TmnCode", "vnp_TxnRef",
+		"vnp_Amount", "vnp_BankCode", "vnp_PayDate", "vnp_TransactionNo", "vnp_TransactionType",
+		"vnp_TransactionStatus", "vnp_OrderInfo", "vnp_PromotionCode", "vnp_PromotionAmount",
+	}
+	VnpayRefundRequestFields = []string{
+		"vnp_RequestId", "vnp_Version", "vnp_Command", "vnp_TmnCode", "vnp_TransactionType", "vnp_TxnRef",
+		"vnp_Amount", "vnp_TransactionNo", "vnp_TransactionDate", "vnp_CreateBy", "vnp_CreateDate",
+		"vnp_IpAddr", "vnp_OrderInfo",
+	}
+	VnpayRefundResponseFields = []string{
+		"vnp_ResponseId", "vnp_Command", "vnp_ResponseCode", "vnp_Message", "vnp_TmnCode", "vnp_TxnRef",
+		"vnp_Amount", "vnp_BankCode", "vnp_PayDate", "vnp_TransactionNo", "vnp_TransactionType",
+		"vnp_TransactionStatus", "vnp_OrderInfo",
+	}
+)
+
+type VnpayConfig struct {
+	TmnCode    string
+	HashSecret string
+	// PayURL is the payment page customers are redirected to.
+	PayURL    string
+	ReturnURL string
+	// APIURL is the merchant API used to query and refund transactions.
+	APIURL string
+}
+
+type VnpayGateway struct {
+	config VnpayConfig
+	client *http.Client
+}
+
+func NewVnpayGateway(config VnpayConfig) *VnpayGateway {
+	return &VnpayGateway{config: config, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (g *VnpayGateway) Method() string {
+	return constant.PAYMENT_METHOD_VNPAY
+}
+
+func (g *VnpayGateway) CreatePayment(ctx context.Context, req PaymentRequest) (*CreatePaymentResult, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("error.invalid_amount")
+	}
+	if req.Amount > vnpayMaxAmount {
+		return nil, errors.New("error.amount_exceeds_limit")
+	}
+	clientIP, err := normalizeClientIP(req.ClientIP)
+	if err != nil {
+		return nil, err
+	}
+	if g.config.TmnCode == "" || g.config.HashSecret == "" || g.config.PayURL == "" || g.config.ReturnURL == "" {
+		return nil, errors.New("error.vnpay_configuration_missing")
+	}
+
+	now := time.Now().In(vnpayZone)
+	params := url.Values{}
+	params.Set("vnp_Version", vnpayVersion)
+	params.Set("vnp_Command", "pay")
+	params.Set("vnp_TmnCode", g.config.TmnCode)
+	params.Set("vnp_Amount", strconv.FormatInt(req.Amount*100, 10))
+	params.Set("vnp_CurrCode", "VND")
+	params.Set("vnp_TxnRef", req.TxnRef)
+	params.Set("vnp_OrderInfo", url.QueryEscape(fmt.Sprintf("Thanh toan dat phong %d", req.BookingID)))
+	params.Set("vnp_OrderType", constant.HOTEL_ORDER_TYPE)
+	params.Set("vnp_Locale", "vn")
+	params.Set("vnp_ReturnUrl", g.config.ReturnURL)
+	params.Set("vnp_IpAddr", clientIP)
+	params.Set("vnp_CreateDate", now.Format(vnpayTimeLayout))
+	params.Set("vnp_ExpireDate", now.Add(15*time.Minute).Format(vnpayTimeLayout))
+
+	rawData := vnpayHashData(params)
+	secureHash := vnpaySign(g.config.HashSecret, rawData)
+	query := rawData + "&vnp_SecureHashType=HMACSHA512&vnp_SecureHash=" + secureHash
+	return &CreatePaymentResult{RedirectURL: fmt.Sprintf("%s?%s", g.config.PayURL, query)}, nil
+}
+
+// VerifyCallback checks the vnp_SecureHash of a return URL or IPN request
+// and reads the payment result from it.
+func (g *VnpayGateway) VerifyCallback(ctx context.Context, params url.Values) (*Result, error) {
+	if g.config.HashSecret == "" {
+		return nil, errors.New("error.vnpay_configuration_missing")
+	}
+	if err := VerifyVnpaySignature(g.config.HashSecret, params); err != nil {
+		return nil, err
+	}
+	amount, err := ParseVnpayAmount(params.Get("vnp_Amount"))
+	if err != nil {
+		return nil, err
+	}
+	status := constant.PAYMENT_FAILED
+	if params.Get("vnp_ResponseCode") == constant.VNPAY_CODE_SUCCESS &&
+		params.Get("vnp_TransactionStatus") == constant.VNPAY_CODE_SUCCESS {
+		status = constant.PAYMENT_SUCCESS
+	}
+	return &Result{
+		TxnRef:        params.Get("vnp_TxnRef"),
+		TransactionID: params.Get("vnp_TransactionNo"),
+		Amount:        amount,
+		Status:        status,
+		ResponseCode:  params.Get("vnp_ResponseCode"),
+	}, nil
+}
+
+// QueryStatus asks VNPay's querydr API for the state of a transaction.
+func (g *VnpayGateway) QueryStatus(ctx context.Context, txn Transaction) (*Result, error) {
+	res, err := g.queryTransaction(ctx, txn)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := ParseVnpayAmount(res["vnp_Amount"])
+	if err != nil {
+		return nil, err
+	}
+	status := constant.PAYMENT_FAILED
+	switch res["vnp_TransactionStatus"] {
+	case constant.VNPAY_CODE_SUCCESS:
+		status = constant.PAYMENT_SUCCESS
+	case vnpayTransactionPending:
+		status = constant.PAYMENT_PENDING
+	}
+	return &Result{
+		TxnRef:        res["vnp_TxnRef"],
+		TransactionID: res["vnp_TransactionNo"],
+		Amount:        amount,
+		Status:        status,
+		ResponseCode:  res["vnp_TransactionStatus"],
+	}, nil
+}
+
+// queryTransaction calls querydr and returns VNPay's signed answer about a
+// transaction it knows. A transaction VNPay does not know gives
+// ErrGatewayTransactionNotFound.
+func (g *VnpayGateway) queryTransaction(ctx context.Context, txn Transaction) (map[string]string, error) {
+	now := time.Now().In(vnpayZone)
+	req := map[string]string{
+		"vnp_RequestId":       newVnpayRequestID(),
+		"vnp_Version":         vnpayVersion,
+		"vnp_Command":         "querydr",
+		"vnp_TmnCode":         g.config.TmnCode,
+		"vnp_TxnRef":          txn.TxnRef,
+		"vnp_OrderInfo":       "Truy van giao dich " + txn.TxnRef,
+		"vnp_TransactionDate": txn.CreatedAt.In(vnpayZone).Format(vnpayTimeLayout),
+		"vnp_CreateDate":      now.Format(vnpayTimeLayout),
+		"vnp_IpAddr":          serverIP(txn.ClientIP),
+	}
+	req["vnp_SecureHash"] = SignVnpayFields(g.config.HashSecret, req, VnpayQueryRequestFields)
+
+	res, err := g.callAPI(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyVnpayFields(g.config.HashSecret, res, VnpayQueryResponseFields); err != nil {
+		return nil, err
+	}
+	switch res["vnp_ResponseCode"] {
+	case constant.VNPAY_CODE_SUCCESS:
+		return res, nil
+	case vnpayTxnNotFound:
+		return nil, appError.ErrGatewayTransactionNotFound
+	default:
+		return nil, fmt.Errorf("%w: querydr response code %s", appError.ErrPaymentGatewayUnavailable, res["vnp_ResponseCode"])
+	}
+}
+
+// Refund sends a full or partial refund through VNPay's refund API.
+func (g *VnpayGateway) Refund(ctx context.Context, refund RefundRequest) (*RefundResult, error) {
+	transactionType := vnpayRefundPartial
+	if refund.Full {
+		transactionType = vnpayRefundFull
+	}
+	now := time.Now().In(vnpayZone)
+	req := map[string]string{
+		"vnp_RequestId":       newVnpayRequestID(),
+		"vnp_Version":         vnpayVersion,
+		"vnp_Command":         "refund",
+		"vnp_TmnCode":         g.config.TmnCode,
+		"vnp_TransactionType": transactionType,
+		"vnp_TxnRef":          refund.TxnRef,
+		"vnp_Amount":          strconv.FormatInt(refund.RefundAmount*100, 10),
+		"vnp_TransactionNo":   refund.TransactionID,
+		"vnp_TransactionDate": refund.CreatedAt.In(vnpayZone).Format(vnpayTimeLayout),
+		"vnp_CreateBy":        refund.CreatedBy,
+		"vnp_CreateDate":      now.Format(vnpayTimeLayout),
+		"vnp_IpAddr":          serverIP(refund.ClientIP),
+		"vnp_OrderInfo":       "Hoan tien giao dich " + refund.TxnRef,
+	}
+	req["vnp_SecureHash"] = SignVnpayFields(g.config.HashSecret, req, VnpayRefundRequestFields)
+
+	res, err := g.callAPI(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyVnpayFields(g.config.HashSecret, res, VnpayRefundResponseFields); err != nil {
+		return nil, err
+	}
+	status := constant.PAYMENT_PENDING
+	switch code := res["vnp_ResponseCode"]; {
+	case code == constant.VNPAY_CODE_SUCCESS:
+		status = constant.PAYMENT_SUCCESS
+	case vnpayRefundRejectedCodes[code]:
+		status = constant.PAYMENT_FAILED
+	}
+	return &RefundResult{RefundID: res["vnp_TransactionNo"], Status: status}, nil
+}
+
+// QueryRefund looks the transaction up with querydr, which reports the last
+// operation on it. A transaction still reported as a plain payment never
+// received the refund. A refund of another amount than the one asked about
+// is a different refund, so the outcome stays open.
+func (g *VnpayGateway) QueryRefund(ctx context.Context, refund RefundRequest) (*RefundResult, error) {
+	res, err := g.queryTransaction(ctx, refund.Transaction)
+	if errors.Is(err, appError.ErrGatewayTransactionNotFound) {
+		return &RefundResult{Status: constant.PAYMENT_FAILED}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch res["vnp_TransactionType"] {
+	case vnpayRefundFull, vnpayRefundPartial:
+	default:
+		return &RefundResult{Status: constant.PAYMENT_FAILED}, nil
+	}
+	amount, err := ParseVnpayAmount(res["vnp_Amount"])
+	if err != nil || amount != refund.RefundAmount {
+		return &RefundResult{Status: constant.PAYMENT_PENDING}, nil
+	}
+	status := constant.PAYMENT_PENDING
+	switch res["vnp_TransactionStatus"] {
+	case constant.VNPAY_CODE_SUCCESS:
+		status = constant.PAYMENT_SUCCESS
+	case vnpayRefundDeclined:
+		status = constant.PAYMENT_FAILED
+	case vnpayRefundProcessing, vnpayRefundSentToBank:
+	}
+	return &RefundResult{RefundID: res["vnp_TransactionNo"], Status: status}, nil
+}
+
+func (g *VnpayGateway) callAPI(ctx context.Context, payload map[string]string) (map[string]string, error) {
+	if g.config.TmnCode == "" || g.config.HashSecret == "" || g.config.APIURL == "" {
+		return nil, errors.New("error.vnpay_configuration_missing")
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.config.APIURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", appError.ErrPaymentGatewayUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", appError.ErrPaymentGatewayUnavailable, resp.StatusCode)
+	}
+	// Every field of the response is a string, but decode loosely in case
+	// numbers come back unquoted.
+	var raw map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", appError.ErrPaymentGatewayUnavailable, err)
+	}
+	res := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if value != nil {
+			res[key] = fmt.Sprint(value)
+		}
+	}
+	return res, nil
+}
+
+// SignVnpayFields signs the "|"-joined fields of a merchant API request or
+// response.
+func SignVnpayFields(secret string, values map[string]string, fields []string) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = values[field]
+	}
+	return vnpaySign(secret, strings.Join(parts, "|"))
+}
+
+// VerifyVnpayFields checks the vnp_SecureHash of a merchant API request or
+// response.
+func VerifyVnpayFields(secret string, values map[string]string, fields []string) error {
+	expected := SignVnpayFields(secret, values, fields)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToUpper(values["vnp_SecureHash"]))) {
+		return appError.ErrInvalidVnpaySignature
+	}
+	return nil
+}
+
+// VerifyVnpaySignature checks the vnp_SecureHash of a return URL or IPN
+// request.
+func VerifyVnpaySignature(secret string, params url.Values) error {
+	received := params.Get("vnp_SecureHash")
+	if received == "" {
+		return appError.ErrInvalidVnpaySignature
+	}
+	expected := vnpaySign(secret, vnpayHashData(params))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToUpper(received))) {
+		return appError.ErrInvalidVnpaySignature
+	}
+	return nil
+}
+
+// SignVnpayParams adds vnp_SecureHash to params, as VNPay does on its
+// redirects and IPN requests.
+func SignVnpayParams(secret string, params url.Values) {
+	params.Del("vnp_SecureHash")
+	params.Del("vnp_SecureHashType")
+	params.Set("vnp_SecureHash", vnpaySign(secret, vnpayHashData(params)))
+}
+
+// VnpayIPNResponse maps the outcome of handling an IPN to the RspCode and
+// message VNPay expects in the response.
+func VnpayIPNResponse(err error) (string, string) {
+	switch {
+	case err == nil:
+		return constant.VNPAY_RSP_CONFIRMED, "Confirm Success"
+	case errors.Is(err, appError.ErrInvalidVnpaySignature):
+		return constant.VNPAY_RSP_INVALID_SIGNATURE, "Invalid signature"
+	case errors.Is(err, appError.ErrPaymentNotFound):
+		return constant.VNPAY_RSP_ORDER_NOT_FOUND, "Order not found"
+	case errors.Is(err, appError.ErrPaymentAmountMismatch):
+		return constant.VNPAY_RSP_INVALID_AMOUNT, "Invalid amount"
+	case errors.Is(err, appError.ErrPaymentAlreadyProcessed):
+		return constant.VNPAY_RSP_ALREADY_CONFIRMED, "Order already confirmed"
+	default:
+		return constant.VNPAY_RSP_UNKNOWN_ERROR, "Unknown error"
+	}
+}
+
+// ParseVnpayAmount converts vnp_Amount, which VNPay sends in hundredths of a
+// dong, back to dong.
+func ParseVnpayAmount(value string) (int64, error) {
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || amount < 0 || amount%100 != 0 {
+		return 0, appError.ErrPaymentAmountMismatch
+	}
+	return amount / 100, nil
+}
+
+// vnpayHashData joins the vnp_ parameters in key order, leaving out the hash
+// itself. Values are encoded like PHP's urlencode, as in VNPay's samples.
+func vnpayHashData(params url.Values) string {
+	var keys []string
+	for k := range params {
+		if strings.HasPrefix(k, "vnp_") && k != "vnp_SecureHash" && k != "vnp_SecureHashType" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var rawData strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			rawData.WriteString("&")
+		}
+		rawData.WriteString(k + "=" + url.QueryEscape(params.Get(k)))
+	}
+	return rawData.String()
+}
+
+func vnpaySign(secret, data string) string {
+	h := hmac.New(sha512.New, []byte(secret))
+	h.Write([]byte(data))
+	return strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+}
+
+func newVnpayRequestID() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")[:32]
+}
+
+func normalizeClientIP(clientIP string) (string, error) {
+	clientIP = strings.TrimSpace(clientIP)
+	if clientIP == "" {
+		return "", errors.New("error.client_ip_empty")
+	}
+	if clientIP == "::1" {
+		clientIP = "127.0.0.1"
+	}
+	if strings.Count(clientIP, ".") != 3 && !strings.Contains(clientIP, ":") {
+		return "", errors.New("error.invalid_ip_address")
+	}
+	return clientIP, nil
+}
+
+// serverIP is the vnp_IpAddr of merchant API calls, which come from the
+// server rather than the customer.
+func serverIP(clientIP string) string {
+	if ip, err := normalizeClientIP(clientIP); err == nil {
+		return ip
+	}
+	return "127.0.0.1"
+}
@@ -0,0 +1,233 @@
+package gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hotel-management/internal/constant"
+	appError "hotel-management/internal/error"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testVnpaySecret = "SECRETKEYFORTESTS"
+
+// vnpayDocQuery is the payment URL query from VNPay's "Thanh toán PAY"
+// integration guide, without vnp_SecureHash. The hash is computed over
+// exactly this string, so it pins how values are encoded: spaces as "+"
+// (PHP urlencode, Go url.QueryEscape), not "%20".
+const vnpayDocQuery = "vnp_Amount=1806000&vnp_Command=pay&vnp_CreateDate=20210801153333" +
+	"&vnp_CurrCode=VND&vnp_IpAddr=127.0.0.1&vnp_Locale=vn" +
+	"&vnp_OrderInfo=Thanh+toan+don+hang+%3A5&vnp_OrderType=other" +
+	"&vnp_ReturnUrl=https%3A%2F%2Fdomainmerchant.vn%2FReturnUrl" +
+	"&vnp_TmnCode=DEMOV210&vnp_TxnRef=5&vnp_Version=2.1.0"
+
+func TestVnpayHashDataMatchesDocumentation(t *testing.T) {
+	params, err := url.ParseQuery(vnpayDocQuery)
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+	params.Set("vnp_SecureHashType", "HMACSHA512")
+	params.Set("vnp_SecureHash", "ignored")
+
+	if got := vnpayHashData(params); got != vnpayDocQuery {
+		t.Errorf("hash data:\n%s\nwant:\n%s", got, vnpayDocQuery)
+	}
+}
+
+func TestVnpaySignIsUpperCaseHMACSHA512(t *testing.T) {
+	mac := hmac.New(sha512.New, []byte(testVnpaySecret))
+	mac.Write([]byte(vnpayDocQuery))
+	want := strings.ToUpper(hex.EncodeToString(mac.Sum(nil)))
+
+	if got := vnpaySign(testVnpaySecret, vnpayDocQuery); got != want {
+		t.Errorf("vnpaySign = %s, want %s", got, want)
+	}
+}
+
+func signedDocParams(t *testing.T) url.Values {
+	t.Helper()
+	params, err := url.ParseQuery(vnpayDocQuery)
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+	SignVnpayParams(testVnpaySecret, params)
+	return params
+}
+
+func TestVerifyVnpaySignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(params url.Values)
+		wantErr bool
+	}{
+		{name: "valid", tamper: func(url.Values) {}},
+		{
+			name: "lower case hash",
+			tamper: func(params url.Values) {
+				params.Set("vnp_SecureHash", strings.ToLower(params.Get("vnp_SecureHash")))
+			},
+		},
+		{
+			name: "hash type is not signed",
+			tamper: func(params url.Values) {
+				params.Set("vnp_SecureHashType", "HMACSHA512")
+			},
+		},
+		{
+			name: "non vnp parameters are not signed",
+			tamper: func(params url.Values) {
+				params.Set("lang", "vi")
+			},
+		},
+		{
+			name: "tampered amount",
+			tamper: func(params url.Values) {
+				params.Set("vnp_Amount", "1")
+			},
+			wantErr: true,
+		},
+		{
+			name: "added vnp parameter",
+			tamper: func(params url.Values) {
+				params.Set("vnp_BankCode", "NCB")
+			},
+			wantErr: true,
+		},
+		{
+			name: "tampered hash",
+			tamper: func(params url.Values) {
+				hash := []byte(params.Get("vnp_SecureHash"))
+				if hash[0] == 'A' {
+					hash[0] = 'B'
+				} else {
+					hash[0] = 'A'
+				}
+				params.Set("vnp_SecureHash", string(hash))
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing hash",
+			tamper: func(params url.Values) {
+				params.Del("vnp_SecureHash")
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := signedDocParams(t)
+			tt.tamper(params)
+
+			err := VerifyVnpaySignature(testVnpaySecret, params)
+			if tt.wantErr && !errors.Is(err, appError.ErrInvalidVnpaySignature) {
+				t.Errorf("err = %v, want ErrInvalidVnpaySignature", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("err = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestVerifyVnpaySignatureWrongSecret(t *testing.T) {
+	params := signedDocParams(t)
+	if err := VerifyVnpaySignature("ANOTHERSECRET", params); !errors.Is(err, appError.ErrInvalidVnpaySignature) {
+		t.Errorf("err = %v, want ErrInvalidVnpaySignature", err)
+	}
+}
+
+func TestParseVnpayAmount(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "1806000", want: 18060},
+		{value: "0", want: 0},
+		{value: "99999999900", want: 999999999},
+		{value: "1806050", wantErr: true},
+		{value: "-100", wantErr: true},
+		{value: "", wantErr: true},
+		{value: "18060.00", wantErr: true},
+		{value: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseVnpayAmount(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, appError.ErrPaymentAmountMismatch) {
+					t.Errorf("err = %v, want ErrPaymentAmountMismatch", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseVnpayAmount(%q) = %d, %v, want %d", tt.value, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVnpayIPNResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "settled", err: nil, want: constant.VNPAY_RSP_CONFIRMED},
+		{name: "bad signature", err: appError.ErrInvalidVnpaySignature, want: constant.VNPAY_RSP_INVALID_SIGNATURE},
+		{name: "unknown payment", err: appError.ErrPaymentNotFound, want: constant.VNPAY_RSP_ORDER_NOT_FOUND},
+		{name: "amount mismatch", err: appError.ErrPaymentAmountMismatch, want: constant.VNPAY_RSP_INVALID_AMOUNT},
+		{name: "already processed", err: appError.ErrPaymentAlreadyProcessed, want: constant.VNPAY_RSP_ALREADY_CONFIRMED},
+		{name: "wrapped", err: fmt.Errorf("settle: %w", appError.ErrPaymentNotFound), want: constant.VNPAY_RSP_ORDER_NOT_FOUND},
+		{name: "anything else", err: appError.ErrFailedToUpdatePayment, want: constant.VNPAY_RSP_UNKNOWN_ERROR},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := VnpayIPNResponse(tt.err); got != tt.want {
+				t.Errorf("RspCode = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVnpayVerifyCallbackStatus(t *testing.T) {
+	g := NewVnpayGateway(VnpayConfig{HashSecret: testVnpaySecret})
+	tests := []struct {
+		responseCode      string
+		transactionStatus string
+		want              string
+	}{
+		{responseCode: "00", transactionStatus: "00", want: constant.PAYMENT_SUCCESS},
+		{responseCode: "24", transactionStatus: "02", want: constant.PAYMENT_FAILED},
+		{responseCode: "00", transactionStatus: "02", want: constant.PAYMENT_FAILED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.responseCode+"/"+tt.transactionStatus, func(t *testing.T) {
+			params := url.Values{}
+			params.Set("vnp_TxnRef", "5")
+			params.Set("vnp_Amount", "1806000")
+			params.Set("vnp_TransactionNo", "14422574")
+			params.Set("vnp_ResponseCode", tt.responseCode)
+			params.Set("vnp_TransactionStatus", tt.transactionStatus)
+			SignVnpayParams(testVnpaySecret, params)
+
+			result, err := g.VerifyCallback(context.Background(), params)
+			if err != nil {
+				t.Fatalf("VerifyCallback: %v", err)
+			}
+			if result.Status != tt.want || result.Amount != 18060 || result.TxnRef != "5" {
+				t.Errorf("result = %+v, want status %s, amount 18060, txn ref 5", result, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,491 @@
+// Package vnpaysandbox is a small stand-in for VNPay, so the payment flow can
+// run offline. It serves the payment page the VNPay gateway redirects to,
+// checks the signature of the payment URL, lets the customer pay or fail (or
+// answers on its own), then calls the IPN and redirects back with correctly
+// signed parameters, as VNPay does. It also answers the querydr and refund
+// calls of the merchant API for the transactions it has seen.
+//
+// Tests use it directly. The server only mounts it in binaries built with the
+// vnpaysandbox tag; point VNPAY_URL at <sandbox>/paymentv2/vpcpay.html and
+// VNPAY_API_URL at <sandbox>/merchant_webapi/api/transaction to use it.
+package vnpaysandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hotel-management/internal/gateway"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Paths served by the sandbox, relative to where it is mounted.
+const (
+	PayPath      = "/paymentv2/vpcpay.html"
+	CompletePath = "/paymentv2/complete"
+	APIPath      = "/merchant_webapi/api/transaction"
+)
+
+// Outcomes of a payment, chosen on the payment page or by AutoRespond.
+const (
+	OutcomePay  = "pay"
+	OutcomeFail = "fail"
+)
+
+// Codes the sandbox answers with. Only the ones the application reads are
+// modelled: success, pending, a customer cancelling, and the merchant API
+// rejecting a request.
+const (
+	codeSuccess            = "00"
+	codePending            = "01"
+	codeFailed             = "02"
+	codeCustomerCancelled  = "24"
+	codeTxnNotFound        = "91"
+	codeRefundRejected     = "95"
+	codeInvalidSignature   = "97"
+	codeInvalidRequest     = "99"
+	transactionTypePayment = "01"
+	transactionTypeRefund  = "03"
+)
+
+const timeLayout = "20060102150405"
+
+var zone = time.FixedZone("GMT+7", 7*60*60)
+
+type Config struct {
+	TmnCode    string
+	HashSecret string
+	// IPNURL is called server to server once a payment is finished. Leave it
+	// empty to only redirect the browser.
+	IPNURL string
+	// AutoRespond skips the payment page and finishes every payment with the
+	// given outcome, OutcomePay or OutcomeFail.
+	AutoRespond string
+	// BankCode is reported as the bank the customer paid with.
+	BankCode string
+}
+
+// Transaction is the sandbox's record of a payment.
+type Transaction struct {
+	TxnRef        string
+	Amount        int64
+	OrderInfo     string
+	ReturnURL     string
+	CreateDate    string
+	TransactionNo string
+	PayDate       string
+	Status        string
+	ResponseCode  string
+	Refunded      int64
+	// LastRefund is the amount of the last refund, which querydr reports
+	// once the transaction has been refunded.
+	LastRefund int64
+	// IPNResponse is the RspCode the merchant answered the IPN with.
+	IPNResponse string
+}
+
+type Server struct {
+	config Config
+	client *http.Client
+	mux    *http.ServeMux
+
+	mu           sync.Mutex
+	transactions map[string]*Transaction
+	nextID       int
+}
+
+func NewServer(config Config) *Server {
+	if config.BankCode == "" {
+		config.BankCode = "NCB"
+	}
+	s := &Server{
+		config:       config,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		mux:          http.NewServeMux(),
+		transactions: map[string]*Transaction{},
+	}
+	s.mux.HandleFunc(PayPath, s.handlePay)
+	s.mux.HandleFunc(CompletePath, s.handleComplete)
+	s.mux.HandleFunc(APIPath, s.handleAPI)
+	return s
+}
+
+// NewServerFromEnv builds a sandbox that signs with the application's own
+// VNPay credentials. It returns nil unless VNPAY_SANDBOX_ENABLED is "true".
+func NewServerFromEnv() *Server {
+	if strings.TrimSpace(os.Getenv("VNPAY_SANDBOX_ENABLED")) != "true" {
+		return nil
+	}
+	return NewServer(Config{
+		TmnCode:     os.Getenv("VNPAY_TMN_CODE"),
+		HashSecret:  os.Getenv("VNPAY_HASH_SECRET"),
+		IPNURL:      os.Getenv("VNPAY_SANDBOX_IPN_URL"),
+		AutoRespond: strings.TrimSpace(os.Getenv("VNPAY_SANDBOX_AUTO_RESPOND")),
+	})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Transaction returns a copy of the sandbox's record of a payment.
+func (s *Server) Transaction(txnRef string) (Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txn, ok := s.transactions[txnRef]
+	if !ok {
+		return Transaction{}, false
+	}
+	return *txn, true
+}
+
+// handlePay validates a payment URL and shows the payment page, or finishes
+// the payment at once when AutoRespond is set.
+func (s *Server) handlePay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	params := r.URL.Query()
+	if err := gateway.VerifyVnpaySignature(s.config.HashSecret, params); err != nil {
+		http.Error(w, "invalid signature", http.StatusBadRequest)
+		return
+	}
+	txn, err := s.startTransaction(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch s.config.AutoRespond {
+	case OutcomePay, OutcomeFail:
+		s.finish(w, r, txn.TxnRef, s.config.AutoRespond)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := payPage.Execute(w, map[string]any{
+		"Txn":          txn,
+		"CompletePath": strings.TrimSuffix(r.URL.Path, PayPath) + CompletePath,
+		"Pay":          OutcomePay,
+		"Fail":         OutcomeFail,
+	}); err != nil {
+		log.Printf("vnpay sandbox: render payment page: %v", err)
+	}
+}
+
+// handleComplete finishes a payment with the outcome chosen on the page.
+func (s *Server) handleComplete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	outcome := r.PostFormValue("outcome")
+	if outcome != OutcomePay && outcome != OutcomeFail {
+		http.Error(w, "invalid outcome", http.StatusBadRequest)
+		return
+	}
+	s.finish(w, r, r.PostFormValue("txn_ref"), outcome)
+}
+
+// startTransaction records a new payment, or returns the unfinished one with
+// the same reference when the customer opens the payment URL again.
+func (s *Server) startTransaction(params url.Values) (Transaction, error) {
+	if params.Get("vnp_Command") != "pay" || params.Get("vnp_TmnCode") != s.config.TmnCode {
+		return Transaction{}, fmt.Errorf("unexpected command or terminal")
+	}
+	txnRef := params.Get("vnp_TxnRef")
+	if txnRef == "" {
+		return Transaction{}, fmt.Errorf("missing vnp_TxnRef")
+	}
+	amount, err := gateway.ParseVnpayAmount(params.Get("vnp_Amount"))
+	if err != nil || amount <= 0 {
+		return Transaction{}, fmt.Errorf("invalid vnp_Amount")
+	}
+	returnURL, err := url.Parse(params.Get("vnp_ReturnUrl"))
+	if err != nil || returnURL.Scheme == "" || returnURL.Host == "" {
+		return Transaction{}, fmt.Errorf("invalid vnp_ReturnUrl")
+	}
+	if expire, err := time.ParseInLocation(timeLayout, params.Get("vnp_ExpireDate"), zone); err == nil && time.Now().After(expire) {
+		return Transaction{}, fmt.Errorf("payment URL expired")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if txn, ok := s.transactions[txnRef]; ok {
+		if txn.Status != codePending {
+			return Transaction{}, fmt.Errorf("transaction already finished")
+		}
+		return *txn, nil
+	}
+	txn := &Transaction{
+		TxnRef:     txnRef,
+		Amount:     amount,
+		OrderInfo:  params.Get("vnp_OrderInfo"),
+		ReturnURL:  returnURL.String(),
+		CreateDate: params.Get("vnp_CreateDate"),
+		Status:     codePending,
+	}
+	s.transactions[txnRef] = txn
+	return *txn, nil
+}
+
+// finish settles a transaction, calls the IPN and redirects the browser to
+// the return URL, both with the same signed result.
+func (s *Server) finish(w http.ResponseWriter, r *http.Request, txnRef, outcome string) {
+	params, returnURL, err := s.settle(txnRef, outcome)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.config.IPNURL != "" {
+		rspCode := s.callIPN(r.Context(), params)
+		s.mu.Lock()
+		s.transactions[txnRef].IPNResponse = rspCode
+		s.mu.Unlock()
+	}
+	http.Redirect(w, r, returnURL+"?"+params.Encode(), http.StatusFound)
+}
+
+func (s *Server) settle(txnRef, outcome string) (url.Values, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txn, ok := s.transactions[txnRef]
+	if !ok {
+		return nil, "", fmt.Errorf("transaction not found")
+	}
+	if txn.Status != codePending {
+		return nil, "", fmt.Errorf("transaction already finished")
+	}
+	txn.PayDate = time.Now().In(zone).Format(timeLayout)
+	if outcome == OutcomePay {
+		s.nextID++
+		txn.TransactionNo = strconv.Itoa(14000000 + s.nextID)
+		txn.Status = codeSuccess
+		txn.ResponseCode = codeSuccess
+	} else {
+		txn.TransactionNo = "0"
+		txn.Status = codeFailed
+		txn.ResponseCode = codeCustomerCancelled
+	}
+
+	params := url.Values{}
+	params.Set("vnp_Amount", strconv.FormatInt(txn.Amount*100, 10))
+	params.Set("vnp_BankCode", s.config.BankCode)
+	params.Set("vnp_CardType", "ATM")
+	params.Set("vnp_OrderInfo", txn.OrderInfo)
+	params.Set("vnp_PayDate", txn.PayDate)
+	params.Set("vnp_ResponseCode", txn.ResponseCode)
+	params.Set("vnp_TmnCode", s.config.TmnCode)
+	params.Set("vnp_TransactionNo", txn.TransactionNo)
+	params.Set("vnp_TransactionStatus", txn.Status)
+	params.Set("vnp_TxnRef", txn.TxnRef)
+	if txn.Status == codeSuccess {
+		params.Set("vnp_BankTranNo", "VNP"+txn.TransactionNo)
+	}
+	gateway.SignVnpayParams(s.config.HashSecret, params)
+	return params, txn.ReturnURL, nil
+}
+
+// callIPN sends the result to the merchant's IPN URL and returns its RspCode.
+// VNPay retries unconfirmed IPNs; the sandbox only logs them.
+func (s *Server) callIPN(ctx context.Context, params url.Values) string {
+	ipnURL, err := url.Parse(s.config.IPNURL)
+	if err != nil {
+		log.Printf("vnpay sandbox: invalid IPN URL %q: %v", s.config.IPNURL, err)
+		return ""
+	}
+	ipnURL.RawQuery = params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ipnURL.String(), nil)
+	if err != nil {
+		log.Printf("vnpay sandbox: build IPN request: %v", err)
+		return ""
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Printf("vnpay sandbox: IPN for %s failed: %v", params.Get("vnp_TxnRef"), err)
+		return ""
+	}
+	defer resp.Body.Close()
+	var body struct {
+		RspCode string
+		Message string
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body); err != nil {
+		log.Printf("vnpay sandbox: IPN for %s answered status %d without JSON", params.Get("vnp_TxnRef"), resp.StatusCode)
+		return ""
+	}
+	if body.RspCode != codeSuccess {
+		log.Printf("vnpay sandbox: IPN for %s answered %s %s", params.Get("vnp_TxnRef"), body.RspCode, body.Message)
+	}
+	return body.RspCode
+}
+
+// handleAPI answers the querydr and refund commands of the merchant API.
+func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req map[string]string
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil {
+		s.writeAPIError(w, req, codeInvalidRequest, "Invalid request")
+		return
+	}
+	switch req["vnp_Command"] {
+	case "querydr":
+		if gateway.VerifyVnpayFields(s.config.HashSecret, req, gateway.VnpayQueryRequestFields) != nil {
+			s.writeAPIError(w, req, codeInvalidSignature, "Invalid checksum")
+			return
+		}
+		s.query(w, req)
+	case "refund":
+		if gateway.VerifyVnpayFields(s.config.HashSecret, req, gateway.VnpayRefundRequestFields) != nil {
+			s.writeAPIError(w, req, codeInvalidSignature, "Invalid checksum")
+			return
+		}
+		s.refund(w, req)
+	default:
+		s.writeAPIError(w, req, codeInvalidRequest, "Unknown command")
+	}
+}
+
+func (s *Server) query(w http.ResponseWriter, req map[string]string) {
+	s.mu.Lock()
+	txn, ok := s.transactions[req["vnp_TxnRef"]]
+	var copied Transaction
+	if ok {
+		copied = *txn
+	}
+	s.mu.Unlock()
+	if !ok {
+		s.writeAPIError(w, req, codeTxnNotFound, "Transaction not found")
+		return
+	}
+	transactionType, amount := transactionTypePayment, copied.Amount
+	if copied.LastRefund > 0 {
+		transactionType, amount = transactionTypeRefund, copied.LastRefund
+	}
+	s.writeAPI(w, gateway.VnpayQueryResponseFields, map[string]string{
+		"vnp_ResponseId":        req["vnp_RequestId"],
+		"vnp_Command":           "querydr",
+		"vnp_ResponseCode":      codeSuccess,
+		"vnp_Message":           "QueryDR Success",
+		"vnp_TmnCode":           s.config.TmnCode,
+		"vnp_TxnRef":            copied.TxnRef,
+		"vnp_Amount":            strconv.FormatInt(amount*100, 10),
+		"vnp_BankCode":          s.config.BankCode,
+		"vnp_PayDate":           copied.PayDate,
+		"vnp_TransactionNo":     copied.TransactionNo,
+		"vnp_TransactionType":   transactionType,
+		"vnp_TransactionStatus": copied.Status,
+		"vnp_OrderInfo":         copied.OrderInfo,
+		"vnp_PromotionCode":     "",
+		"vnp_PromotionAmount":   "",
+	})
+}
+
+// refund accepts refunds of successful transactions up to the amount paid.
+func (s *Server) refund(w http.ResponseWriter, req map[string]string) {
+	amount, err := gateway.ParseVnpayAmount(req["vnp_Amount"])
+	if err != nil || amount <= 0 {
+		s.writeAPIError(w, req, codeInvalidRequest, "Invalid amount")
+		return
+	}
+	s.mu.Lock()
+	txn, ok := s.transactions[req["vnp_TxnRef"]]
+	if !ok {
+		s.mu.Unlock()
+		s.writeAPIError(w, req, codeTxnNotFound, "Transaction not found")
+		return
+	}
+	if txn.Status != codeSuccess || txn.Refunded+amount > txn.Amount {
+		s.mu.Unlock()
+		s.writeAPIError(w, req, codeRefundRejected, "Refund rejected")
+		return
+	}
+	txn.Refunded += amount
+	txn.LastRefund = amount
+	s.nextID++
+	refundNo := strconv.Itoa(14000000 + s.nextID)
+	copied := *txn
+	s.mu.Unlock()
+
+	s.writeAPI(w, gateway.VnpayRefundResponseFields, map[string]string{
+		"vnp_ResponseId":        req["vnp_RequestId"],
+		"vnp_Command":           "refund",
+		"vnp_ResponseCode":      codeSuccess,
+		"vnp_Message":           "Refund Success",
+		"vnp_TmnCode":           s.config.TmnCode,
+		"vnp_TxnRef":            copied.TxnRef,
+		"vnp_Amount":            req["vnp_Amount"],
+		"vnp_BankCode":          s.config.BankCode,
+		"vnp_PayDate":           time.Now().In(zone).Format(timeLayout),
+		"vnp_TransactionNo":     refundNo,
+		"vnp_TransactionType":   req["vnp_TransactionType"],
+		"vnp_TransactionStatus": codeSuccess,
+		"vnp_OrderInfo":         req["vnp_OrderInfo"],
+	})
+}
+
+func (s *Server) writeAPIError(w http.ResponseWriter, req map[string]string, code, message string) {
+	s.writeAPI(w, nil, map[string]string{
+		"vnp_ResponseId":   req["vnp_RequestId"],
+		"vnp_Command":      req["vnp_Command"],
+		"vnp_ResponseCode": code,
+		"vnp_Message":      message,
+		"vnp_TmnCode":      s.config.TmnCode,
+		"vnp_TxnRef":       req["vnp_TxnRef"],
+	})
+}
+
+// writeAPI signs res with fields, or with the fields of the command's
+// response when fields is nil, and writes it as JSON.
+func (s *Server) writeAPI(w http.ResponseWriter, fields []string, res map[string]string) {
+	if fields == nil {
+		fields = gateway.VnpayQueryResponseFields
+		if res["vnp_Command"] == "refund" {
+			fields = gateway.VnpayRefundResponseFields
+		}
+	}
+	res["vnp_SecureHash"] = gateway.SignVnpayFields(s.config.HashSecret, res, fields)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Printf("vnpay sandbox: write API response: %v", err)
+	}
+}
+
+var payPage = template.Must(template.New("pay").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>VNPay sandbox</title>
+<style>
+body { font-family: sans-serif; max-width: 32rem; margin: 4rem auto; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5rem; }
+td { border-bottom: 1px solid #ddd; padding: .5rem; }
+button { padding: .6rem 1.4rem; margin-right: .5rem; }
+</style>
+</head>
+<body>
+<h1>VNPay sandbox</h1>
+<table>
+<tr><td>Order</td><td>{{.Txn.TxnRef}}</td></tr>
+<tr><td>Description</td><td>{{.Txn.OrderInfo}}</td></tr>
+<tr><td>Amount</td><td>{{.Txn.Amount}} VND</td></tr>
+</table>
+<form method="post" action="{{.CompletePath}}">
+<input type="hidden" name="txn_ref" value="{{.Txn.TxnRef}}">
+<button type="submit" name="outcome" value="{{.Pay}}">Pay</button>
+<button type="submit" name="outcome" value="{{.Fail}}">Fail</button>
+</form>
+</body>
+</html>
+`))
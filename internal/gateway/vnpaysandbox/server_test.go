@@ -0,0 +1,330 @@
+package vnpaysandbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hotel-management/internal/constant"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/gateway"
+	"hotel-management/internal/gateway/vnpaysandbox"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testTmnCode    = "TESTTMN1"
+	testHashSecret = "SANDBOXTESTSECRET"
+)
+
+// merchant plays the application's side of the flow: it checks IPNs with the
+// VNPay gateway, settles its pending orders once and answers with the
+// RspCode VNPay expects.
+type merchant struct {
+	gateway *gateway.VnpayGateway
+
+	mu     sync.Mutex
+	orders map[string]*order
+}
+
+type order struct {
+	amount int64
+	status string
+}
+
+func (m *merchant) handleIPN(w http.ResponseWriter, r *http.Request) {
+	err := m.settle(r.Context(), r.URL.Query())
+	rspCode, message := gateway.VnpayIPNResponse(err)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"RspCode": rspCode, "Message": message})
+}
+
+func (m *merchant) settle(ctx context.Context, params url.Values) error {
+	result, err := m.gateway.VerifyCallback(ctx, params)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[result.TxnRef]
+	if !ok {
+		return appError.ErrPaymentNotFound
+	}
+	if result.Amount != o.amount {
+		return appError.ErrPaymentAmountMismatch
+	}
+	if o.status != constant.PAYMENT_PENDING {
+		return appError.ErrPaymentAlreadyProcessed
+	}
+	o.status = result.Status
+	return nil
+}
+
+func (m *merchant) status(txnRef string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.orders[txnRef].status
+}
+
+type flow struct {
+	sandbox  *vnpaysandbox.Server
+	merchant *merchant
+	gateway  *gateway.VnpayGateway
+	client   *http.Client
+	// returnURL is where the sandbox sends the customer back to.
+	returnURL string
+}
+
+func newFlow(t *testing.T, autoRespond string) *flow {
+	t.Helper()
+	f := &flow{merchant: &merchant{orders: map[string]*order{}}}
+
+	app := http.NewServeMux()
+	app.HandleFunc("/payments/vnpay_ipn", f.merchant.handleIPN)
+	appServer := httptest.NewServer(app)
+	t.Cleanup(appServer.Close)
+	f.returnURL = appServer.URL + "/payments/vnpay_return"
+
+	f.sandbox = vnpaysandbox.NewServer(vnpaysandbox.Config{
+		TmnCode:     testTmnCode,
+		HashSecret:  testHashSecret,
+		IPNURL:      appServer.URL + "/payments/vnpay_ipn",
+		AutoRespond: autoRespond,
+	})
+	sandboxServer := httptest.NewServer(f.sandbox)
+	t.Cleanup(sandboxServer.Close)
+
+	f.gateway = gateway.NewVnpayGateway(gateway.VnpayConfig{
+		TmnCode:    testTmnCode,
+		HashSecret: testHashSecret,
+		PayURL:     sandboxServer.URL + vnpaysandbox.PayPath,
+		ReturnURL:  f.returnURL,
+		APIURL:     sandboxServer.URL + vnpaysandbox.APIPath,
+	})
+	f.merchant.gateway = f.gateway
+	// The customer's browser: redirects are checked by the test instead of
+	// being followed.
+	f.client = &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	return f
+}
+
+// createPayment opens a pending order and returns the payment page URL the
+// gateway redirects the customer to.
+func (f *flow) createPayment(t *testing.T, txnRef string, amount int64) string {
+	t.Helper()
+	f.merchant.mu.Lock()
+	f.merchant.orders[txnRef] = &order{amount: amount, status: constant.PAYMENT_PENDING}
+	f.merchant.mu.Unlock()
+
+	result, err := f.gateway.CreatePayment(context.Background(), gateway.PaymentRequest{
+		TxnRef:    txnRef,
+		BookingID: 42,
+		Amount:    amount,
+		ClientIP:  "127.0.0.1",
+	})
+	if err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	return result.RedirectURL
+}
+
+// checkReturn verifies the customer was redirected back with a correctly
+// signed result for txnRef.
+func (f *flow) checkReturn(t *testing.T, resp *http.Response, txnRef, wantStatus string) {
+	t.Helper()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("status = %d, want a redirect back to the merchant", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Location: %v", err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != f.returnURL {
+		t.Errorf("redirected to %s, want %s", got, f.returnURL)
+	}
+	result, err := f.gateway.VerifyCallback(context.Background(), location.Query())
+	if err != nil {
+		t.Fatalf("return URL: %v", err)
+	}
+	if result.TxnRef != txnRef || result.Status != wantStatus {
+		t.Errorf("return result = %+v, want %s %s", result, txnRef, wantStatus)
+	}
+}
+
+func TestPaymentFlowThroughSandbox(t *testing.T) {
+	f := newFlow(t, vnpaysandbox.OutcomePay)
+	payURL := f.createPayment(t, "42-paid", 1500000)
+
+	resp, err := f.client.Get(payURL)
+	if err != nil {
+		t.Fatalf("open payment page: %v", err)
+	}
+	resp.Body.Close()
+	f.checkReturn(t, resp, "42-paid", constant.PAYMENT_SUCCESS)
+
+	if got := f.merchant.status("42-paid"); got != constant.PAYMENT_SUCCESS {
+		t.Errorf("order status after IPN = %s, want %s", got, constant.PAYMENT_SUCCESS)
+	}
+	txn, ok := f.sandbox.Transaction("42-paid")
+	if !ok {
+		t.Fatal("sandbox has no record of the payment")
+	}
+	if txn.IPNResponse != constant.VNPAY_RSP_CONFIRMED || txn.Amount != 1500000 {
+		t.Errorf("sandbox transaction = %+v, want amount 1500000 and IPN answered %s", txn, constant.VNPAY_RSP_CONFIRMED)
+	}
+
+	// The reconciler and refunds go through the merchant API.
+	queried, err := f.gateway.QueryStatus(context.Background(), gateway.Transaction{TxnRef: "42-paid", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("QueryStatus: %v", err)
+	}
+	if queried.Status != constant.PAYMENT_SUCCESS || queried.TransactionID != txn.TransactionNo {
+		t.Errorf("querydr result = %+v, want success with transaction %s", queried, txn.TransactionNo)
+	}
+	refundReq := gateway.RefundRequest{
+		Transaction:  gateway.Transaction{TxnRef: "42-paid", TransactionID: txn.TransactionNo, Amount: 1500000, CreatedAt: time.Now()},
+		RefundAmount: 500000,
+		CreatedBy:    "admin@example.com",
+	}
+	refund, err := f.gateway.Refund(context.Background(), refundReq)
+	if err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+	if refund.Status != constant.PAYMENT_SUCCESS {
+		t.Errorf("refund status = %s, want %s", refund.Status, constant.PAYMENT_SUCCESS)
+	}
+
+	// A refund whose answer was lost is settled by looking it up.
+	queriedRefund, err := f.gateway.QueryRefund(context.Background(), refundReq)
+	if err != nil {
+		t.Fatalf("QueryRefund: %v", err)
+	}
+	if queriedRefund.Status != constant.PAYMENT_SUCCESS {
+		t.Errorf("queried refund status = %s, want %s", queriedRefund.Status, constant.PAYMENT_SUCCESS)
+	}
+	otherReq := refundReq
+	otherReq.RefundAmount = 200000
+	if queriedRefund, err = f.gateway.QueryRefund(context.Background(), otherReq); err != nil || queriedRefund.Status != constant.PAYMENT_PENDING {
+		t.Errorf("query of another refund = %+v, %v, want %s", queriedRefund, err, constant.PAYMENT_PENDING)
+	}
+
+	// More than what is left to refund is rejected, which is final.
+	tooMuch := refundReq
+	tooMuch.RefundAmount = 1200000
+	if refund, err = f.gateway.Refund(context.Background(), tooMuch); err != nil || refund.Status != constant.PAYMENT_FAILED {
+		t.Errorf("refund over the amount paid = %+v, %v, want %s", refund, err, constant.PAYMENT_FAILED)
+	}
+}
+
+func TestQueryRefundOfTransactionNeverRefunded(t *testing.T) {
+	f := newFlow(t, vnpaysandbox.OutcomePay)
+	payURL := f.createPayment(t, "42-kept", 1500000)
+	resp, err := f.client.Get(payURL)
+	if err != nil {
+		t.Fatalf("open payment page: %v", err)
+	}
+	resp.Body.Close()
+
+	result, err := f.gateway.QueryRefund(context.Background(), gateway.RefundRequest{
+		Transaction:  gateway.Transaction{TxnRef: "42-kept", Amount: 1500000, CreatedAt: time.Now()},
+		RefundAmount: 500000,
+	})
+	if err != nil {
+		t.Fatalf("QueryRefund: %v", err)
+	}
+	if result.Status != constant.PAYMENT_FAILED {
+		t.Errorf("status = %s, want %s", result.Status, constant.PAYMENT_FAILED)
+	}
+}
+
+func TestPaymentFlowCustomerCancels(t *testing.T) {
+	f := newFlow(t, "")
+	payURL := f.createPayment(t, "42-cancelled", 800000)
+
+	resp, err := f.client.Get(payURL)
+	if err != nil {
+		t.Fatalf("open payment page: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("payment page status = %d, want 200", resp.StatusCode)
+	}
+
+	completeURL, err := url.Parse(payURL)
+	if err != nil {
+		t.Fatalf("payment URL: %v", err)
+	}
+	completeURL.Path = strings.TrimSuffix(completeURL.Path, vnpaysandbox.PayPath) + vnpaysandbox.CompletePath
+	completeURL.RawQuery = ""
+	resp, err = f.client.PostForm(completeURL.String(),
+		url.Values{"txn_ref": {"42-cancelled"}, "outcome": {vnpaysandbox.OutcomeFail}})
+	if err != nil {
+		t.Fatalf("cancel payment: %v", err)
+	}
+	resp.Body.Close()
+	f.checkReturn(t, resp, "42-cancelled", constant.PAYMENT_FAILED)
+
+	if got := f.merchant.status("42-cancelled"); got != constant.PAYMENT_FAILED {
+		t.Errorf("order status after IPN = %s, want %s", got, constant.PAYMENT_FAILED)
+	}
+}
+
+func TestSandboxRejectsTamperedPaymentURL(t *testing.T) {
+	f := newFlow(t, vnpaysandbox.OutcomePay)
+	payURL := f.createPayment(t, "42-tampered", 1500000)
+
+	resp, err := f.client.Get(strings.Replace(payURL, "vnp_Amount=150000000", "vnp_Amount=100", 1))
+	if err != nil {
+		t.Fatalf("open payment page: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+	if _, ok := f.sandbox.Transaction("42-tampered"); ok {
+		t.Error("sandbox recorded a payment with an invalid signature")
+	}
+}
+
+func TestIPNIsSettledOnce(t *testing.T) {
+	f := newFlow(t, vnpaysandbox.OutcomePay)
+	payURL := f.createPayment(t, "42-replayed", 1500000)
+
+	resp, err := f.client.Get(payURL)
+	if err != nil {
+		t.Fatalf("open payment page: %v", err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Location: %v", err)
+	}
+
+	// Replaying the signed result, as VNPay does when it retries an IPN,
+	// must not settle the order again.
+	err = f.merchant.settle(context.Background(), location.Query())
+	if !errors.Is(err, appError.ErrPaymentAlreadyProcessed) {
+		t.Errorf("replayed IPN: err = %v, want ErrPaymentAlreadyProcessed", err)
+	}
+	if rspCode, _ := gateway.VnpayIPNResponse(err); rspCode != constant.VNPAY_RSP_ALREADY_CONFIRMED {
+		t.Errorf("replayed IPN RspCode = %s, want %s", rspCode, constant.VNPAY_RSP_ALREADY_CONFIRMED)
+	}
+}
+
+func TestQueryStatusOfUnknownTransaction(t *testing.T) {
+	f := newFlow(t, vnpaysandbox.OutcomePay)
+
+	// A payment page never submitted leaves VNPay without a record, which
+	// is the only error the reconciler may expire a payment on.
+	_, err := f.gateway.QueryStatus(context.Background(), gateway.Transaction{TxnRef: "42-abandoned", CreatedAt: time.Now()})
+	if !errors.Is(err, appError.ErrGatewayTransactionNotFound) {
+		t.Errorf("err = %v, want ErrGatewayTransactionNotFound", err)
+	}
+}
@@ -20,7 +20,7 @@ func NewAdminHandler(authUseCase *admin_usecase.AuthUseCase, statUseCase *admin_
 }
 
 func (h *AdminHandler) AdminDashboard(c *gin.Context) {
-	stat, err := h.statUseCase.GetDashboardStatistics(c)
+	stat, err := h.statUseCase.GetDashboardStatistics(c, c.GetUint("property_id"))
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "dashboard.html", gin.H{
 			"error": utils.T(c, "failed_to_load_statistics"),
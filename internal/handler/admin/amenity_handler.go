@@ -0,0 +1,162 @@
+package admin
+
+import (
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/usecase/admin_usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AmenityHandler struct {
+	amenityUseCase *admin_usecase.AmenityUseCase
+}
+
+func NewAmenityHandler(amenityUseCase *admin_usecase.AmenityUseCase) *AmenityHandler {
+	return &AmenityHandler{amenityUseCase: amenityUseCase}
+}
+
+func (h *AmenityHandler) AmenityManagementPage(c *gin.Context) {
+	amenities, err := h.amenityUseCase.GetAllAmenities(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.amenity_management",
+		})
+		return
+	}
+	c.HTML(http.StatusOK, "amenity.html", gin.H{
+		"Title":     "title.amenity_management",
+		"Amenities": amenities,
+		"T":         utils.TmplTranslateFromContext(c),
+	})
+}
+
+func (h *AmenityHandler) CreateAmenityPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "create_amenity.html", gin.H{
+		"Title":      "title.create_amenity",
+		"Categories": constant.AmenityCategories,
+		"Languages":  constant.TranslatedLanguages,
+		"T":          utils.TmplTranslateFromContext(c),
+	})
+}
+
+func (h *AmenityHandler) CreateAmenity(c *gin.Context) {
+	req := parseAmenityForm(c)
+	if err := h.amenityUseCase.CreateAmenity(c.Request.Context(), req); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appError.ErrAmenityCodeExists) || errors.Is(err, appError.ErrInvalidAmenityCategory) || err.Error() == "error.invalid_request" {
+			status = http.StatusBadRequest
+		}
+		c.HTML(status, "create_amenity.html", gin.H{
+			"error":      err.Error(),
+			"Title":      "title.create_amenity",
+			"Categories": constant.AmenityCategories,
+			"Languages":  constant.TranslatedLanguages,
+			"T":          utils.TmplTranslateFromContext(c),
+		})
+		return
+	}
+	c.Redirect(http.StatusSeeOther, constant.AmenityManagementPath)
+}
+
+func (h *AmenityHandler) EditAmenityPage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, "error.invalid_amenity_id"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_amenity",
+		})
+		return
+	}
+	h.renderEdit(c, id, http.StatusOK, "")
+}
+
+func (h *AmenityHandler) UpdateAmenity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, "error.invalid_amenity_id"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_amenity",
+		})
+		return
+	}
+	req := parseAmenityForm(c)
+	if err := h.amenityUseCase.UpdateAmenity(c.Request.Context(), id, req); err != nil {
+		h.renderEdit(c, id, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, constant.AmenityManagementPath)
+}
+
+func (h *AmenityHandler) DeleteAmenity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, "error.invalid_amenity_id"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.amenity_management",
+		})
+		return
+	}
+	if err := h.amenityUseCase.DeleteAmenity(c.Request.Context(), id); err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.amenity_management",
+		})
+		return
+	}
+	c.Redirect(http.StatusSeeOther, constant.AmenityManagementPath)
+}
+
+func (h *AmenityHandler) renderEdit(c *gin.Context, id int, status int, errKey string) {
+	amenity, err := h.amenityUseCase.GetAmenityByID(c.Request.Context(), id)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_amenity",
+		})
+		return
+	}
+	translations := make(map[string]string, len(amenity.Translations))
+	for _, t := range amenity.Translations {
+		translations[t.Lang] = t.Name
+	}
+	data := gin.H{
+		"Title":        "title.edit_amenity",
+		"Amenity":      amenity,
+		"Translations": translations,
+		"Categories":   constant.AmenityCategories,
+		"Languages":    constant.TranslatedLanguages,
+		"T":            utils.TmplTranslateFromContext(c),
+	}
+	if errKey != "" {
+		data["error"] = errKey
+	}
+	c.HTML(status, "edit_amenity.html", data)
+}
+
+func parseAmenityForm(c *gin.Context) *dto.AmenityRequest {
+	translations := make(map[string]string, len(constant.TranslatedLanguages))
+	for _, lang := range constant.TranslatedLanguages {
+		translations[lang] = c.PostForm("translation_" + lang)
+	}
+	return &dto.AmenityRequest{
+		Code:         strings.ToLower(strings.TrimSpace(c.PostForm("code"))),
+		Name:         strings.TrimSpace(c.PostForm("name")),
+		Icon:         strings.TrimSpace(c.PostForm("icon")),
+		Category:     strings.TrimSpace(c.PostForm("category")),
+		Translations: translations,
+	}
+}
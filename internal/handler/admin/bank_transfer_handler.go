@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *PaymentHandler) BankTransfersPage(c *gin.Context) {
+	h.renderBankTransfers(c, http.StatusOK, nil, "")
+}
+
+// ImportBankStatement confirms the transfers listed on an uploaded bank
+// statement and shows what each line was matched to.
+func (h *PaymentHandler) ImportBankStatement(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		h.renderBankTransfers(c, http.StatusBadRequest, nil, appError.ErrImportFileRequired.Error())
+		return
+	}
+	if fileHeader.Size > constant.MaxImportFileBytes {
+		h.renderBankTransfers(c, http.StatusBadRequest, nil, appError.ErrImportFileTooLarge.Error())
+		return
+	}
+	data, err := readFormFile(fileHeader, constant.MaxImportFileBytes)
+	if err != nil {
+		h.renderBankTransfers(c, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+	report, err := h.paymentUseCase.ImportBankStatement(c.Request.Context(), &dto.BankStatementImportRequest{
+		FileName:   fileHeader.Filename,
+		Data:       data,
+		PropertyID: c.GetUint("property_id"),
+	})
+	if err != nil {
+		h.renderBankTransfers(c, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+	h.renderBankTransfers(c, http.StatusOK, report, "")
+}
+
+// ConfirmBankTransfer records a transfer staff found on the hotel's account.
+func (h *PaymentHandler) ConfirmBankTransfer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.renderBankTransfers(c, http.StatusBadRequest, nil, appError.ErrPaymentNotFound.Error())
+		return
+	}
+	amount, err := strconv.ParseFloat(c.PostForm("amount"), 64)
+	if err != nil || amount <= 0 {
+		h.renderBankTransfers(c, http.StatusBadRequest, nil, appError.ErrInvalidPaymentAmount.Error())
+		return
+	}
+	err = h.paymentUseCase.ConfirmBankTransfer(c.Request.Context(), uint(id), c.GetUint("property_id"), amount, c.PostForm("reference"))
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, appError.ErrPaymentNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, appError.ErrPropertyAccessDenied):
+			status = http.StatusForbidden
+		case errors.Is(err, appError.ErrFailedToGetPayment),
+			errors.Is(err, appError.ErrFailedToUpdatePayment),
+			errors.Is(err, appError.ErrFailedToUpdateBooking),
+			errors.Is(err, appError.ErrFailedToCreateBill):
+			status = http.StatusInternalServerError
+		}
+		h.renderBankTransfers(c, status, nil, err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, constant.BankTransferPath)
+}
+
+func (h *PaymentHandler) renderBankTransfers(c *gin.Context, status int, report *dto.BankStatementReport, errKey string) {
+	payments, references, err := h.paymentUseCase.GetPendingTransfers(c.Request.Context(), c.GetUint("property_id"))
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"Title": "title.bank_transfers",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, err.Error()),
+		})
+		return
+	}
+	data := gin.H{
+		"Title":      "title.bank_transfers",
+		"Payments":   payments,
+		"References": references,
+		"Report":     report,
+		"Columns":    constant.BankStatementColumns,
+		"T":          utils.TmplTranslateFromContext(c),
+	}
+	if errKey != "" {
+		data["error"] = utils.T(c, errKey)
+	}
+	c.HTML(status, "bank_transfer.html", data)
+}
@@ -36,7 +36,7 @@ func (h *BillHandler) ListBills(c *gin.Context) {
 		}
 	}
 
-	bills, err := h.billUseCase.GetFilteredBills(c.Request.Context(), userName, bookingID, exportDate)
+	bills, err := h.billUseCase.GetFilteredBills(c.Request.Context(), c.GetUint("property_id"), userName, bookingID, exportDate)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
 			"Title": "title.bill_management",
@@ -2,7 +2,10 @@ package admin
 
 import (
 	"errors"
+	"fmt"
 	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
 	"hotel-management/internal/usecase/admin_usecase"
 	"hotel-management/internal/utils"
 	"net/http"
@@ -14,13 +17,14 @@ import (
 
 type AdminBookingHandler struct {
 	bookingUseCase *admin_usecase.BookingUseCase
+	refundUseCase  *admin_usecase.RefundUseCase
 }
 
 func (h *AdminBookingHandler) ListBookings(c *gin.Context) {
 	userName := c.Query("user_name")
 	bookingStatus := c.Query("booking_status")
 
-	bookings, err := h.bookingUseCase.SearchBookings(c.Request.Context(), userName, bookingStatus)
+	bookings, err := h.bookingUseCase.SearchBookings(c.Request.Context(), c.GetUint("property_id"), userName, bookingStatus)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
 			"Title": "admin.booking_management",
@@ -41,8 +45,8 @@ func (h *AdminBookingHandler) ListBookings(c *gin.Context) {
 	})
 }
 
-func NewAdminBookingHandler(bookingUseCase *admin_usecase.BookingUseCase) *AdminBookingHandler {
-	return &AdminBookingHandler{bookingUseCase: bookingUseCase}
+func NewAdminBookingHandler(bookingUseCase *admin_usecase.BookingUseCase, refundUseCase *admin_usecase.RefundUseCase) *AdminBookingHandler {
+	return &AdminBookingHandler{bookingUseCase: bookingUseCase, refundUseCase: refundUseCase}
 }
 
 func (h *AdminBookingHandler) GetBookingDetail(c *gin.Context) {
@@ -57,7 +61,78 @@ func (h *AdminBookingHandler) GetBookingDetail(c *gin.Context) {
 		return
 	}
 
-	booking, err := h.bookingUseCase.GetBookingDetail(c.Request.Context(), uint(id))
+	if !h.checkBookingAccess(c, uint(id), "admin.booking_detail") {
+		return
+	}
+	h.renderDetail(c, uint(id), http.StatusOK, "")
+}
+
+// CreateRefund refunds part or all of one of the booking's payments. Only
+// admins may refund.
+func (h *AdminBookingHandler) CreateRefund(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"Title": "admin.booking_detail",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, "error.invalid_booking_id"),
+		})
+		return
+	}
+	if !h.checkBookingAccess(c, uint(id), "admin.booking_detail") {
+		return
+	}
+	var req dto.CreateRefundRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.renderDetail(c, uint(id), http.StatusBadRequest, "error.invalid_request")
+		return
+	}
+	userID, _ := sessionUser(c)
+	if _, err := h.refundUseCase.StartRefund(c.Request.Context(), uint(id), &req, userID, c.ClientIP()); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, appError.ErrRefundPending) {
+			status = http.StatusAccepted
+		}
+		h.renderDetail(c, uint(id), status, err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("%s/%d", constant.BookingManagementPath, id))
+}
+
+// CreateCharge adds an extra charge, such as minibar or laundry, to a
+// booking.
+func (h *AdminBookingHandler) CreateCharge(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"Title": "admin.booking_detail",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, "error.invalid_booking_id"),
+		})
+		return
+	}
+	if !h.checkBookingAccess(c, uint(id), "admin.booking_detail") {
+		return
+	}
+	var req dto.CreateChargeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.renderDetail(c, uint(id), http.StatusBadRequest, "error.invalid_request")
+		return
+	}
+	userID, _ := sessionUser(c)
+	if err := h.bookingUseCase.AddCharge(c.Request.Context(), uint(id), &req, userID); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, appError.ErrFailedToAddCharge) {
+			status = http.StatusInternalServerError
+		}
+		h.renderDetail(c, uint(id), status, err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("%s/%d", constant.BookingManagementPath, id))
+}
+
+func (h *AdminBookingHandler) renderDetail(c *gin.Context, id uint, status int, errMessage string) {
+	booking, err := h.bookingUseCase.GetBookingDetail(c.Request.Context(), id)
 	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
 		c.HTML(http.StatusNotFound, "error.html", gin.H{
 			"Title": "admin.booking_detail",
@@ -66,10 +141,33 @@ func (h *AdminBookingHandler) GetBookingDetail(c *gin.Context) {
 		})
 		return
 	}
-	c.HTML(http.StatusOK, "booking_detail.html", gin.H{
-		"Title":   "title.booking_detail",
-		"Booking": booking,
-		"T":       utils.TmplTranslateFromContext(c),
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"Title": "admin.booking_detail",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, "error.failed_to_get_booking"),
+		})
+		return
+	}
+	payments, err := h.refundUseCase.GetBookingPayments(c.Request.Context(), id)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"Title": "admin.booking_detail",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, err.Error()),
+		})
+		return
+	}
+	_, role := sessionUser(c)
+	c.HTML(status, "booking_detail.html", gin.H{
+		"Title":       "title.booking_detail",
+		"Booking":     booking,
+		"Payments":    payments,
+		"LineItems":   h.bookingUseCase.GetLineItems(booking, payments),
+		"RefundTypes": []string{constant.REFUND_TYPE_FULL, constant.REFUND_TYPE_PARTIAL},
+		"CanRefund":   role == constant.ADMIN,
+		"error":       errMessage,
+		"T":           utils.TmplTranslateFromContext(c),
 	})
 }
 
@@ -84,6 +182,9 @@ func (h *AdminBookingHandler) EditBookingPage(c *gin.Context) {
 		})
 		return
 	}
+	if !h.checkBookingAccess(c, uint(id), "admin.edit_booking") {
+		return
+	}
 	booking, err := h.bookingUseCase.GetBookingDetail(c.Request.Context(), uint(id))
 	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
 		c.HTML(http.StatusNotFound, "error.html", gin.H{
@@ -112,6 +213,9 @@ func (h *AdminBookingHandler) EditBookingStatus(c *gin.Context) {
 		})
 		return
 	}
+	if !h.checkBookingAccess(c, uint(id), "admin.edit_booking") {
+		return
+	}
 	status := c.PostForm("status")
 	if status == "" || !constant.IsValidBookingStatus(status) {
 		c.HTML(http.StatusNotFound, "error.html", gin.H{
@@ -132,3 +236,24 @@ func (h *AdminBookingHandler) EditBookingStatus(c *gin.Context) {
 	}
 	c.Redirect(http.StatusSeeOther, constant.BookingManagementPath)
 }
+
+// checkBookingAccess renders an error page and returns false when the booking
+// is missing or belongs to a property other than the one being managed.
+func (h *AdminBookingHandler) checkBookingAccess(c *gin.Context, id uint, title string) bool {
+	err := h.bookingUseCase.CheckBookingAccess(c.Request.Context(), id, c.GetUint("property_id"))
+	if err == nil {
+		return true
+	}
+	status := http.StatusNotFound
+	if errors.Is(err, appError.ErrPropertyAccessDenied) {
+		status = http.StatusForbidden
+	} else if err.Error() == "error.failed_to_get_booking" {
+		status = http.StatusInternalServerError
+	}
+	c.HTML(status, "error.html", gin.H{
+		"Title": title,
+		"T":     utils.TmplTranslateFromContext(c),
+		"error": utils.T(c, err.Error()),
+	})
+	return false
+}
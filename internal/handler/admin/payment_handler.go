@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/usecase/admin_usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentHandler struct {
+	paymentUseCase *admin_usecase.PaymentUseCase
+}
+
+func NewPaymentHandler(paymentUseCase *admin_usecase.PaymentUseCase) *PaymentHandler {
+	return &PaymentHandler{paymentUseCase: paymentUseCase}
+}
+
+// ListPayments lists the payments of the current property, filtered by
+// method, status, booking and creation date.
+func (h *PaymentHandler) ListPayments(c *gin.Context) {
+	var filter dto.PaymentFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"Title": "title.payment_management",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, "error.invalid_request"),
+		})
+		return
+	}
+	payments, err := h.paymentUseCase.ListPayments(c.Request.Context(), c.GetUint("property_id"), filter)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"Title": "title.payment_management",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, err.Error()),
+		})
+		return
+	}
+	overpaid, err := h.paymentUseCase.ListOverpaidBookings(c.Request.Context(), c.GetUint("property_id"))
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"Title": "title.payment_management",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, err.Error()),
+		})
+		return
+	}
+	stuck, err := h.paymentUseCase.ListStuckPayments(c.Request.Context(), c.GetUint("property_id"))
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"Title": "title.payment_management",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, err.Error()),
+		})
+		return
+	}
+	c.HTML(http.StatusOK, "payment.html", gin.H{
+		"Title":    "title.payment_management",
+		"Payments": payments,
+		"Overpaid": overpaid,
+		"Stuck":    stuck,
+		"Methods":  h.paymentUseCase.PaymentMethods(),
+		"Statuses": []string{constant.PAYMENT_SUCCESS, constant.PAYMENT_PENDING, constant.PAYMENT_FAILED, constant.PAYMENT_EXPIRED},
+		"Query": gin.H{
+			"PaymentMethod": filter.PaymentMethod,
+			"PaymentStatus": filter.PaymentStatus,
+			"BookingID":     c.Query("booking_id"),
+			"From":          c.Query("from"),
+			"To":            c.Query("to"),
+		},
+		"T": utils.TmplTranslateFromContext(c),
+	})
+}
+
+// GetPaymentDetail shows a payment with its allocations, refunds and the raw
+// callbacks received for it.
+func (h *PaymentHandler) GetPaymentDetail(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"Title": "title.payment_detail",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, appError.ErrPaymentNotFound.Error()),
+		})
+		return
+	}
+	payment, callbacks, err := h.paymentUseCase.GetPaymentDetail(c.Request.Context(), uint(id), c.GetUint("property_id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, appError.ErrPaymentNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, appError.ErrPropertyAccessDenied):
+			status = http.StatusForbidden
+		}
+		c.HTML(status, "error.html", gin.H{
+			"Title": "title.payment_detail",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, err.Error()),
+		})
+		return
+	}
+	c.HTML(http.StatusOK, "payment_detail.html", gin.H{
+		"Title":     "title.payment_detail",
+		"Payment":   payment,
+		"Callbacks": callbacks,
+		"T":         utils.TmplTranslateFromContext(c),
+	})
+}
+
+// RetryReconcile hands a payment the reconciler gave up on back to it.
+func (h *PaymentHandler) RetryReconcile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"Title": "title.payment_management",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, appError.ErrPaymentNotFound.Error()),
+		})
+		return
+	}
+	if err := h.paymentUseCase.RetryReconcile(c.Request.Context(), uint(id), c.GetUint("property_id")); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, appError.ErrPaymentNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, appError.ErrPropertyAccessDenied):
+			status = http.StatusForbidden
+		case errors.Is(err, appError.ErrPaymentAlreadyProcessed):
+			status = http.StatusConflict
+		}
+		c.HTML(status, "error.html", gin.H{
+			"Title": "title.payment_management",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, err.Error()),
+		})
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/admin/payments")
+}
@@ -0,0 +1,235 @@
+package admin
+
+import (
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/usecase/admin_usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type PropertyHandler struct {
+	propertyUseCase *admin_usecase.PropertyUseCase
+}
+
+func NewPropertyHandler(propertyUseCase *admin_usecase.PropertyUseCase) *PropertyHandler {
+	return &PropertyHandler{propertyUseCase: propertyUseCase}
+}
+
+func (h *PropertyHandler) PropertyManagementPage(c *gin.Context) {
+	properties, err := h.propertyUseCase.GetAllProperties(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.properties",
+		})
+		return
+	}
+	c.HTML(http.StatusOK, "property.html", gin.H{
+		"Title":      "title.properties",
+		"Properties": properties,
+		"T":          utils.TmplTranslateFromContext(c),
+	})
+}
+
+func (h *PropertyHandler) CreatePropertyPage(c *gin.Context) {
+	h.renderCreate(c, http.StatusOK, "")
+}
+
+func (h *PropertyHandler) CreateProperty(c *gin.Context) {
+	req := parsePropertyForm(c)
+	if err := h.propertyUseCase.CreateProperty(c.Request.Context(), req); err != nil {
+		h.renderCreate(c, propertyErrorStatus(err), err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, constant.PropertyManagementPath)
+}
+
+func (h *PropertyHandler) EditPropertyPage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, appError.ErrPropertyNotFound.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_property",
+		})
+		return
+	}
+	h.renderEdit(c, uint(id), http.StatusOK, "")
+}
+
+func (h *PropertyHandler) UpdateProperty(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, appError.ErrPropertyNotFound.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_property",
+		})
+		return
+	}
+	req := parsePropertyForm(c)
+	if err := h.propertyUseCase.UpdateProperty(c.Request.Context(), uint(id), req); err != nil {
+		h.renderEdit(c, uint(id), propertyErrorStatus(err), err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, constant.PropertyManagementPath)
+}
+
+// PropertySwitcher lists the properties the signed-in user can switch to; the
+// header uses it to fill its property selector.
+func (h *PropertyHandler) PropertySwitcher(c *gin.Context) {
+	userID, role := sessionUser(c)
+	switcher, err := h.propertyUseCase.GetSwitcher(c.Request.Context(), userID, role, c.GetUint("property_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, switcher)
+}
+
+func (h *PropertyHandler) SwitchProperty(c *gin.Context) {
+	userID, role := sessionUser(c)
+	requested, err := strconv.ParseUint(c.PostForm("property_id"), 10, 64)
+	if err != nil {
+		requested = uint64(constant.AllProperties)
+	}
+
+	propertyID, err := h.propertyUseCase.ResolveCurrentProperty(c.Request.Context(), userID, role, uint(requested))
+	if err == nil && propertyID != uint(requested) {
+		err = appError.ErrPropertyAccessDenied
+	}
+	if err != nil {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.properties",
+		})
+		return
+	}
+	session := sessions.Default(c)
+	session.Set("property_id", propertyID)
+	_ = session.Save()
+
+	// Only follow same-site admin pages back so the form cannot be used as an
+	// open redirect.
+	target := constant.AdminHomePath
+	if referer := c.Request.Referer(); referer != "" {
+		if path := refererPath(referer); strings.HasPrefix(path, constant.AdminHomePath) {
+			target = path
+		}
+	}
+	c.Redirect(http.StatusSeeOther, target)
+}
+
+func (h *PropertyHandler) renderCreate(c *gin.Context, status int, errKey string) {
+	staff, err := h.propertyUseCase.GetStaffAccounts(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.create_property",
+		})
+		return
+	}
+	data := gin.H{
+		"Title": "title.create_property",
+		"Staff": staff,
+		"T":     utils.TmplTranslateFromContext(c),
+	}
+	if errKey != "" {
+		data["error"] = errKey
+	}
+	c.HTML(status, "create_property.html", data)
+}
+
+func (h *PropertyHandler) renderEdit(c *gin.Context, id uint, status int, errKey string) {
+	property, err := h.propertyUseCase.GetPropertyByID(c.Request.Context(), id)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_property",
+		})
+		return
+	}
+	staff, err := h.propertyUseCase.GetStaffAccounts(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_property",
+		})
+		return
+	}
+	assigned := make(map[uint]bool, len(property.Staff))
+	for _, user := range property.Staff {
+		assigned[user.ID] = true
+	}
+	data := gin.H{
+		"Title":    "title.edit_property",
+		"Property": property,
+		"Staff":    staff,
+		"Assigned": assigned,
+		"T":        utils.TmplTranslateFromContext(c),
+	}
+	if errKey != "" {
+		data["error"] = errKey
+	}
+	c.HTML(status, "edit_property.html", data)
+}
+
+func propertyErrorStatus(err error) int {
+	if errors.Is(err, appError.ErrFailedToSaveProperty) || errors.Is(err, appError.ErrFailedToGetProperty) {
+		return http.StatusInternalServerError
+	}
+	if errors.Is(err, appError.ErrPropertyNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+func parsePropertyForm(c *gin.Context) *dto.PropertyRequest {
+	var staffIDs []uint
+	for _, value := range c.PostFormArray("staff_ids") {
+		if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+			staffIDs = append(staffIDs, uint(id))
+		}
+	}
+	return &dto.PropertyRequest{
+		Code:     strings.ToUpper(strings.TrimSpace(c.PostForm("code"))),
+		Name:     strings.TrimSpace(c.PostForm("name")),
+		City:     strings.TrimSpace(c.PostForm("city")),
+		Address:  strings.TrimSpace(c.PostForm("address")),
+		Phone:    strings.TrimSpace(c.PostForm("phone")),
+		IsActive: c.PostForm("is_active") == "on",
+		StaffIDs: staffIDs,
+	}
+}
+
+func sessionUser(c *gin.Context) (uint, string) {
+	session := sessions.Default(c)
+	userID, _ := session.Get("user_id").(uint)
+	role, _ := session.Get("user_role").(string)
+	return userID, role
+}
+
+func refererPath(referer string) string {
+	parsed, err := url.Parse(referer)
+	if err != nil {
+		return ""
+	}
+	if parsed.RawQuery != "" {
+		return parsed.Path + "?" + parsed.RawQuery
+	}
+	return parsed.Path
+}
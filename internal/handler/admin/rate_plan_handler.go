@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/usecase/admin_usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RatePlanHandler struct {
+	ratePlanUseCase *admin_usecase.RatePlanUseCase
+}
+
+func NewRatePlanHandler(ratePlanUseCase *admin_usecase.RatePlanUseCase) *RatePlanHandler {
+	return &RatePlanHandler{ratePlanUseCase: ratePlanUseCase}
+}
+
+func (h *RatePlanHandler) RatePlanManagementPage(c *gin.Context) {
+	h.renderList(c, http.StatusOK, "")
+}
+
+func (h *RatePlanHandler) CreateRatePlan(c *gin.Context) {
+	req := parseRatePlanForm(c)
+	if err := h.ratePlanUseCase.CreateRatePlan(c.Request.Context(), req); err != nil {
+		h.renderList(c, ratePlanErrorStatus(err), err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, constant.RatePlanManagementPath)
+}
+
+func (h *RatePlanHandler) EditRatePlanPage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, appError.ErrRatePlanNotFound.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_rate_plan",
+		})
+		return
+	}
+	h.renderEdit(c, uint(id), http.StatusOK, "")
+}
+
+func (h *RatePlanHandler) UpdateRatePlan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, appError.ErrRatePlanNotFound.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_rate_plan",
+		})
+		return
+	}
+	req := parseRatePlanForm(c)
+	if err := h.ratePlanUseCase.UpdateRatePlan(c.Request.Context(), uint(id), req); err != nil {
+		h.renderEdit(c, uint(id), ratePlanErrorStatus(err), err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, constant.RatePlanManagementPath)
+}
+
+func (h *RatePlanHandler) renderList(c *gin.Context, status int, errKey string) {
+	ratePlans, err := h.ratePlanUseCase.GetRatePlans(c.Request.Context(), c.GetUint("property_id"))
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.rate_plans",
+		})
+		return
+	}
+	properties, err := h.ratePlanUseCase.GetProperties(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.rate_plans",
+		})
+		return
+	}
+	data := gin.H{
+		"Title":          "title.rate_plans",
+		"RatePlans":      ratePlans,
+		"Properties":     properties,
+		"PaymentTimings": constant.PaymentTimings,
+		"T":              utils.TmplTranslateFromContext(c),
+	}
+	if errKey != "" {
+		data["error"] = errKey
+	}
+	c.HTML(status, "rate_plan.html", data)
+}
+
+func (h *RatePlanHandler) renderEdit(c *gin.Context, id uint, status int, errKey string) {
+	ratePlan, err := h.ratePlanUseCase.GetRatePlan(c.Request.Context(), id)
+	if err != nil {
+		c.HTML(ratePlanErrorStatus(err), "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_rate_plan",
+		})
+		return
+	}
+	properties, err := h.ratePlanUseCase.GetProperties(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_rate_plan",
+		})
+		return
+	}
+	data := gin.H{
+		"Title":          "title.edit_rate_plan",
+		"RatePlan":       ratePlan,
+		"Properties":     properties,
+		"PaymentTimings": constant.PaymentTimings,
+		"T":              utils.TmplTranslateFromContext(c),
+	}
+	if errKey != "" {
+		data["error"] = errKey
+	}
+	c.HTML(status, "edit_rate_plan.html", data)
+}
+
+func ratePlanErrorStatus(err error) int {
+	if errors.Is(err, appError.ErrFailedToSaveRatePlan) || errors.Is(err, appError.ErrFailedToGetRatePlan) || errors.Is(err, appError.ErrFailedToGetProperty) {
+		return http.StatusInternalServerError
+	}
+	if errors.Is(err, appError.ErrRatePlanNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+func parseRatePlanForm(c *gin.Context) *dto.RatePlanRequest {
+	propertyID, _ := strconv.ParseUint(c.PostForm("property_id"), 10, 64)
+	depositPercent, _ := strconv.Atoi(strings.TrimSpace(c.PostForm("deposit_percent")))
+	return &dto.RatePlanRequest{
+		PropertyID:     uint(propertyID),
+		Name:           strings.TrimSpace(c.PostForm("name")),
+		Description:    strings.TrimSpace(c.PostForm("description")),
+		PaymentTiming:  strings.TrimSpace(c.PostForm("payment_timing")),
+		DepositPercent: depositPercent,
+		IsActive:       c.PostForm("is_active") == "on",
+	}
+}
@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"hotel-management/internal/usecase/admin_usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationHandler struct {
+	reconciliationUseCase *admin_usecase.ReconciliationUseCase
+}
+
+func NewReconciliationHandler(reconciliationUseCase *admin_usecase.ReconciliationUseCase) *ReconciliationHandler {
+	return &ReconciliationHandler{reconciliationUseCase: reconciliationUseCase}
+}
+
+// ListReconciliations shows what the payment reconciler did in its recent
+// runs.
+func (h *ReconciliationHandler) ListReconciliations(c *gin.Context) {
+	runs, err := h.reconciliationUseCase.ListReconciliations(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"Title": "title.payment_reconciliation",
+			"T":     utils.TmplTranslateFromContext(c),
+			"error": utils.T(c, err.Error()),
+		})
+		return
+	}
+	c.HTML(http.StatusOK, "reconciliation.html", gin.H{
+		"Title": "title.payment_reconciliation",
+		"Runs":  runs,
+		"T":     utils.TmplTranslateFromContext(c),
+	})
+}
@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/usecase/admin_usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReviewHandler struct {
+	reviewUseCase *admin_usecase.ReviewUseCase
+}
+
+func NewReviewHandler(reviewUseCase *admin_usecase.ReviewUseCase) *ReviewHandler {
+	return &ReviewHandler{reviewUseCase: reviewUseCase}
+}
+
+func (h *ReviewHandler) ListReviews(c *gin.Context) {
+	var query dto.ReviewQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, "error.invalid_request"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.review_management",
+		})
+		return
+	}
+	query.PropertyID = c.GetUint("property_id")
+	reviews, err := h.reviewUseCase.SearchReviews(c.Request.Context(), query)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.review_management",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "review.html", gin.H{
+		"Title":    "title.review_management",
+		"Reviews":  reviews,
+		"Query":    query,
+		"Statuses": constant.ReviewStatuses,
+		"Ratings":  []int{5, 4, 3, 2, 1},
+		"T":        utils.TmplTranslateFromContext(c),
+	})
+}
+
+func (h *ReviewHandler) ReviewDetailPage(c *gin.Context) {
+	id, ok := h.reviewID(c)
+	if !ok || !h.checkReviewAccess(c, id) {
+		return
+	}
+	h.renderDetail(c, id, http.StatusOK, "")
+}
+
+func (h *ReviewHandler) ModerateReview(c *gin.Context) {
+	id, ok := h.reviewID(c)
+	if !ok || !h.checkReviewAccess(c, id) {
+		return
+	}
+	var req dto.ModerateReviewRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.renderDetail(c, id, http.StatusBadRequest, "error.invalid_request")
+		return
+	}
+	userID, _ := sessionUser(c)
+	if _, err := h.reviewUseCase.ModerateReview(c.Request.Context(), id, &req, userID); err != nil {
+		h.renderDetail(c, id, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("%s/%d", constant.ReviewManagementPath, id))
+}
+
+func (h *ReviewHandler) RespondToReview(c *gin.Context) {
+	id, ok := h.reviewID(c)
+	if !ok || !h.checkReviewAccess(c, id) {
+		return
+	}
+	var req dto.ReviewReplyRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.renderDetail(c, id, http.StatusBadRequest, "error.review_response_too_long")
+		return
+	}
+	userID, _ := sessionUser(c)
+	if _, err := h.reviewUseCase.RespondToReview(c.Request.Context(), id, &req, userID); err != nil {
+		h.renderDetail(c, id, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("%s/%d", constant.ReviewManagementPath, id))
+}
+
+func (h *ReviewHandler) reviewID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, "error.invalid_review_id"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.review_detail",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *ReviewHandler) renderDetail(c *gin.Context, id uint, status int, errMessage string) {
+	review, err := h.reviewUseCase.GetReview(c.Request.Context(), id)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.review_detail",
+		})
+		return
+	}
+	c.HTML(status, "review_detail.html", gin.H{
+		"Title":   "title.review_detail",
+		"Review":  review,
+		"Actions": constant.ReviewActions,
+		"error":   errMessage,
+		"T":       utils.TmplTranslateFromContext(c),
+	})
+}
+
+// checkReviewAccess renders an error page and returns false when the review
+// is missing or is for a room of another property.
+func (h *ReviewHandler) checkReviewAccess(c *gin.Context, id uint) bool {
+	err := h.reviewUseCase.CheckReviewAccess(c.Request.Context(), id, c.GetUint("property_id"))
+	if err == nil {
+		return true
+	}
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, appError.ErrReviewNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, appError.ErrPropertyAccessDenied):
+		status = http.StatusForbidden
+	}
+	c.HTML(status, "error.html", gin.H{
+		"error": utils.T(c, err.Error()),
+		"T":     utils.TmplTranslateFromContext(c),
+		"Title": "title.review_detail",
+	})
+	return false
+}
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"errors"
+	"hotel-management/internal/constant"
 	"mime/multipart"
 	"net/http"
 	"strconv"
@@ -19,6 +20,8 @@ var (
 	ErrTooManyImages        = errors.New("error.too_many_images")
 	ErrImageTooLarge        = errors.New("error.image_too_large")
 	ErrInvalidImageType     = errors.New("error.invalid_image_type")
+	ErrRoomNumberRequired   = errors.New("error.room_number_required")
+	ErrInvalidFloor         = errors.New("error.invalid_floor")
 )
 
 type RoomFormResult struct {
@@ -31,11 +34,18 @@ type RoomFormResult struct {
 	HasAircon   bool
 	IsAvailable bool
 	Files       []*multipart.FileHeader
+	AmenityIDs  []uint
+	PropertyID  uint
+	Number      string
+	Floor       int
+	Building    string
+	// ConnectingRoomIDs are the rooms sharing an inner door with this one.
+	ConnectingRoomIDs []uint
 }
 
 const (
 	MaxUploadImages = 5
-	MaxFileSize     = 2 * 1024 * 1024 // 2MB
+	MaxFileSize     = constant.MaxImageBytes
 )
 
 var (
@@ -60,6 +70,19 @@ func ParseRoomForm(c *gin.Context) (*RoomFormResult, error) {
 		return nil, ErrInvalidRequest
 	}
 
+	number := strings.TrimSpace(c.PostForm("number"))
+	if number == "" {
+		return nil, ErrRoomNumberRequired
+	}
+	floor := 0
+	if floorStr := strings.TrimSpace(c.PostForm("floor")); floorStr != "" {
+		parsed, err := strconv.Atoi(floorStr)
+		if err != nil {
+			return nil, ErrInvalidFloor
+		}
+		floor = parsed
+	}
+
 	price, err := strconv.ParseFloat(priceStr, 64)
 	if err != nil || price < 0 {
 		return nil, ErrInvalidPrice
@@ -91,16 +114,42 @@ func ParseRoomForm(c *gin.Context) (*RoomFormResult, error) {
 		}
 	}
 
+	propertyID, _ := strconv.ParseUint(c.PostForm("property_id"), 10, 64)
+
+	var amenityIDs []uint
+	for _, idStr := range c.PostFormArray("amenity_ids") {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil || id == 0 {
+			return nil, ErrInvalidRequest
+		}
+		amenityIDs = append(amenityIDs, uint(id))
+	}
+
+	var connectingRoomIDs []uint
+	for _, idStr := range c.PostFormArray("connecting_room_ids") {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil || id == 0 {
+			return nil, ErrInvalidRequest
+		}
+		connectingRoomIDs = append(connectingRoomIDs, uint(id))
+	}
+
 	return &RoomFormResult{
-		Name:        name,
-		Type:        roomType,
-		Price:       price,
-		BedNum:      beds,
-		ViewType:    viewType,
-		Description: description,
-		HasAircon:   hasAircon,
-		IsAvailable: isAvailable,
-		Files:       files,
+		Name:              name,
+		Type:              roomType,
+		Price:             price,
+		BedNum:            beds,
+		ViewType:          viewType,
+		Description:       description,
+		HasAircon:         hasAircon,
+		IsAvailable:       isAvailable,
+		Files:             files,
+		AmenityIDs:        amenityIDs,
+		PropertyID:        uint(propertyID),
+		Number:            number,
+		Floor:             floor,
+		Building:          strings.TrimSpace(c.PostForm("building")),
+		ConnectingRoomIDs: connectingRoomIDs,
 	}, nil
 }
 
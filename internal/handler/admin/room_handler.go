@@ -1,23 +1,28 @@
 package admin
 
 import (
+	"errors"
 	"fmt"
 	"hotel-management/internal/constant"
 	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/models"
 	"hotel-management/internal/usecase/admin_usecase"
 	"hotel-management/internal/utils"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type RoomHandler struct {
-	roomUseCase *admin_usecase.RoomUseCase
+	roomUseCase     *admin_usecase.RoomUseCase
+	propertyUseCase *admin_usecase.PropertyUseCase
 }
 
-func NewRoomHandler(roomUseCase *admin_usecase.RoomUseCase) *RoomHandler {
-	return &RoomHandler{roomUseCase: roomUseCase}
+func NewRoomHandler(roomUseCase *admin_usecase.RoomUseCase, propertyUseCase *admin_usecase.PropertyUseCase) *RoomHandler {
+	return &RoomHandler{roomUseCase: roomUseCase, propertyUseCase: propertyUseCase}
 }
 func (h *RoomHandler) RoomManagementPage(c *gin.Context) {
 	var query dto.RoomQuery
@@ -42,6 +47,16 @@ func (h *RoomHandler) RoomManagementPage(c *gin.Context) {
 			"Title": "title.room_management"})
 		return
 	}
+	if _, err := strconv.Atoi(query.Floor); query.Floor != "" && err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{"error": utils.T(c, "error.invalid_floor"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.room_management"})
+		return
+	}
+	if query.GroupBy != constant.ROOM_GROUP_BY_FLOOR {
+		query.GroupBy = ""
+	}
+	query.PropertyID = c.GetUint("property_id")
 	rooms, err := h.roomUseCase.SearchRooms(c.Request.Context(), query)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": utils.T(c, "error.failed_to_get_room"),
@@ -50,18 +65,36 @@ func (h *RoomHandler) RoomManagementPage(c *gin.Context) {
 		return
 	}
 
+	// The list is always rendered as groups; without grouping there is a
+	// single group holding every room.
+	floorGroups := []dto.RoomFloorGroup{{Rooms: rooms}}
+	if query.GroupBy == constant.ROOM_GROUP_BY_FLOOR {
+		floorGroups = admin_usecase.GroupRoomsByFloor(rooms)
+	}
 	c.HTML(http.StatusOK, "room.html", gin.H{
-		"Title": "title.room_management",
-		"Rooms": rooms,
-		"Query": query,
-		"T":     utils.TmplTranslateFromContext(c),
+		"Title":       "title.room_management",
+		"Rooms":       rooms,
+		"FloorGroups": floorGroups,
+		"Query":       query,
+		"T":           utils.TmplTranslateFromContext(c),
 	})
 }
 
 func (h *RoomHandler) CreateRoomPage(c *gin.Context) {
+	amenities, err := h.roomUseCase.GetAllAmenities(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": utils.T(c, "error.failed_to_get_amenity"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.create_room"})
+		return
+	}
 	c.HTML(http.StatusOK, "create_room.html", gin.H{
-		"Title": "title.create_room",
-		"T":     utils.TmplTranslateFromContext(c),
+		"Title":             "title.create_room",
+		"Amenities":         amenities,
+		"Properties":        h.formProperties(c),
+		"CurrentPropertyID": c.GetUint("property_id"),
+		"ConnectableRooms":  h.connectableRooms(c, c.GetUint("property_id"), 0),
+		"T":                 utils.TmplTranslateFromContext(c),
 	})
 }
 
@@ -75,16 +108,31 @@ func (h *RoomHandler) CreateRoom(c *gin.Context) {
 		})
 		return
 	}
+	userID, role := sessionUser(c)
+	if err := h.propertyUseCase.CheckPropertyAccess(c.Request.Context(), userID, role, formResult.PropertyID); err != nil {
+		c.HTML(http.StatusForbidden, "create_room.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"Title": "title.create_room",
+			"T":     utils.TmplTranslateFromContext(c),
+		})
+		return
+	}
 	createRoomRequest := &dto.CreateRoomRequest{
-		Name:          formResult.Name,
-		Type:          formResult.Type,
-		PricePerNight: formResult.Price,
-		BedNum:        formResult.BedNum,
-		HasAircon:     formResult.HasAircon,
-		ViewType:      formResult.ViewType,
-		Description:   formResult.Description,
-		IsAvailable:   formResult.IsAvailable,
-		ImageFiles:    formResult.Files,
+		PropertyID:        formResult.PropertyID,
+		Name:              formResult.Name,
+		Type:              formResult.Type,
+		PricePerNight:     formResult.Price,
+		BedNum:            formResult.BedNum,
+		HasAircon:         formResult.HasAircon,
+		ViewType:          formResult.ViewType,
+		Description:       formResult.Description,
+		IsAvailable:       formResult.IsAvailable,
+		ImageFiles:        formResult.Files,
+		AmenityIDs:        formResult.AmenityIDs,
+		Number:            formResult.Number,
+		Floor:             formResult.Floor,
+		Building:          formResult.Building,
+		ConnectingRoomIDs: formResult.ConnectingRoomIDs,
 	}
 
 	err = h.roomUseCase.CreateRoom(c, createRoomRequest)
@@ -110,6 +158,9 @@ func (h *RoomHandler) RoomDetailPage(c *gin.Context) {
 		})
 		return
 	}
+	if !h.checkRoomAccess(c, id, "title.room_detail") {
+		return
+	}
 	roomDetail, err := h.roomUseCase.GetRoomDetail(c.Request.Context(), id)
 	if err != nil {
 		c.HTML(http.StatusBadRequest, "error.html", gin.H{"error": utils.T(c, err.Error()),
@@ -135,6 +186,9 @@ func (h *RoomHandler) EditRoomPage(c *gin.Context) {
 		})
 		return
 	}
+	if !h.checkRoomAccess(c, id, "title.edit_room") {
+		return
+	}
 	room, err := h.roomUseCase.GetRoomByID(c.Request.Context(), id)
 	if err != nil {
 		c.HTML(http.StatusNotFound, "error.html", gin.H{"error": utils.T(c, "error.room_not_found"),
@@ -144,10 +198,31 @@ func (h *RoomHandler) EditRoomPage(c *gin.Context) {
 		return
 	}
 
+	amenities, err := h.roomUseCase.GetAllAmenities(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": utils.T(c, "error.failed_to_get_amenity"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.edit_room",
+		})
+		return
+	}
+
+	var roomPropertyID uint
+	if room.PropertyID != nil {
+		roomPropertyID = *room.PropertyID
+	}
+	connected := make(map[uint]bool, len(room.ConnectingRooms))
+	for _, connectedRoom := range room.ConnectingRooms {
+		connected[connectedRoom.ID] = true
+	}
 	c.HTML(http.StatusOK, "edit_room.html", gin.H{
-		"Title": "title.edit_room",
-		"Room":  room,
-		"T":     utils.TmplTranslateFromContext(c),
+		"Title":            "title.edit_room",
+		"Room":             room,
+		"Amenities":        amenities,
+		"Properties":       h.formProperties(c),
+		"ConnectableRooms": h.connectableRooms(c, roomPropertyID, room.ID),
+		"ConnectedRoomIDs": connected,
+		"T":                utils.TmplTranslateFromContext(c),
 	})
 }
 
@@ -163,6 +238,10 @@ func (h *RoomHandler) UpdateRoom(c *gin.Context) {
 		return
 	}
 
+	if !h.checkRoomAccess(c, roomID, "title.edit_room") {
+		return
+	}
+
 	formResult, err := ParseRoomForm(c)
 	if err != nil {
 		c.HTML(http.StatusBadRequest, "edit_room.html", gin.H{
@@ -172,6 +251,15 @@ func (h *RoomHandler) UpdateRoom(c *gin.Context) {
 		})
 		return
 	}
+	userID, role := sessionUser(c)
+	if err := h.propertyUseCase.CheckPropertyAccess(c.Request.Context(), userID, role, formResult.PropertyID); err != nil {
+		c.HTML(http.StatusForbidden, "edit_room.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"Title": "title.edit_room",
+			"T":     utils.TmplTranslateFromContext(c),
+		})
+		return
+	}
 	// Parse deleted image id list
 	deletedIDs := c.PostFormArray("delete_image_ids")
 
@@ -183,17 +271,42 @@ func (h *RoomHandler) UpdateRoom(c *gin.Context) {
 		}
 	}
 
+	// Parse order, alt text and primary flag of the existing images
+	var imageMeta []dto.RoomImageMeta
+	for _, idStr := range c.PostFormArray("image_ids") {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			continue
+		}
+		sortOrder, _ := strconv.Atoi(c.PostForm(fmt.Sprintf("sort_order_%d", id)))
+		imageMeta = append(imageMeta, dto.RoomImageMeta{
+			ID:        id,
+			AltText:   strings.TrimSpace(c.PostForm(fmt.Sprintf("alt_text_%d", id))),
+			SortOrder: sortOrder,
+		})
+	}
+	primaryImageID, _ := strconv.Atoi(c.PostForm("primary_image_id"))
+
 	updateReq := &dto.EditRoomRequest{
-		ID:            roomID,
-		Name:          formResult.Name,
-		Type:          formResult.Type,
-		PricePerNight: formResult.Price,
-		BedNum:        formResult.BedNum,
-		HasAircon:     formResult.HasAircon,
-		ViewType:      formResult.ViewType,
-		Description:   formResult.Description,
-		IsAvailable:   formResult.IsAvailable,
-		ImageDeletes:  deletedImageIDs,
+		ID:                roomID,
+		PropertyID:        formResult.PropertyID,
+		Name:              formResult.Name,
+		Type:              formResult.Type,
+		PricePerNight:     formResult.Price,
+		BedNum:            formResult.BedNum,
+		HasAircon:         formResult.HasAircon,
+		ViewType:          formResult.ViewType,
+		Description:       formResult.Description,
+		IsAvailable:       formResult.IsAvailable,
+		ImageFiles:        formResult.Files,
+		ImageDeletes:      deletedImageIDs,
+		AmenityIDs:        formResult.AmenityIDs,
+		ImageMeta:         imageMeta,
+		PrimaryImageID:    primaryImageID,
+		Number:            formResult.Number,
+		Floor:             formResult.Floor,
+		Building:          formResult.Building,
+		ConnectingRoomIDs: formResult.ConnectingRoomIDs,
 	}
 	fmt.Println("updateReq:", updateReq)
 
@@ -219,13 +332,106 @@ func (h *RoomHandler) DeleteRoom(c *gin.Context) {
 			"Title": "title.delete_room"})
 		return
 	}
-	err = h.roomUseCase.DeleteRoom(c.Request.Context(), id)
+	if !h.checkRoomAccess(c, id, "title.delete_room") {
+		return
+	}
+	conflicts, err := h.roomUseCase.DeleteRoom(c.Request.Context(), id)
+	if errors.Is(err, appError.ErrRoomHasUpcomingBookings) {
+		c.HTML(http.StatusConflict, "room_archive_conflict.html", gin.H{
+			"Title":     "title.archive_room",
+			"RoomID":    id,
+			"Conflicts": conflicts,
+			"error":     utils.T(c, err.Error()),
+			"T":         utils.TmplTranslateFromContext(c),
+		})
+		return
+	}
 	if err != nil {
-		c.HTML(http.StatusNotFound, "error.html", gin.H{"error": utils.T(c, err.Error()),
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": utils.T(c, err.Error()),
 			"T":     utils.TmplTranslateFromContext(c),
 			"Title": "title.delete_room"})
-		return	
+		return
 	}
 
 	c.Redirect(http.StatusSeeOther, constant.RoomManagementPath)
 }
+
+func (h *RoomHandler) RoomTrashPage(c *gin.Context) {
+	rooms, err := h.roomUseCase.GetArchivedRooms(c.Request.Context(), c.GetUint("property_id"))
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": utils.T(c, "error.failed_to_get_room"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.room_trash"})
+		return
+	}
+	c.HTML(http.StatusOK, "room_trash.html", gin.H{
+		"Title": "title.room_trash",
+		"Rooms": rooms,
+		"T":     utils.TmplTranslateFromContext(c),
+	})
+}
+
+func (h *RoomHandler) RestoreRoom(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{"error": utils.T(c, "error.invalid_room_id"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.room_trash"})
+		return
+	}
+	if !h.checkRoomAccess(c, id, "title.room_trash") {
+		return
+	}
+	if err := h.roomUseCase.RestoreRoom(c.Request.Context(), id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appError.ErrArchivedRoomNotFound) {
+			status = http.StatusNotFound
+		}
+		c.HTML(status, "error.html", gin.H{"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.room_trash"})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, constant.RoomTrashPath)
+}
+
+// checkRoomAccess renders an error page and returns false when the room is
+// missing or belongs to a property other than the one being managed.
+func (h *RoomHandler) checkRoomAccess(c *gin.Context, id int, title string) bool {
+	err := h.roomUseCase.CheckRoomAccess(c.Request.Context(), id, c.GetUint("property_id"))
+	if err == nil {
+		return true
+	}
+	status := http.StatusNotFound
+	if errors.Is(err, appError.ErrPropertyAccessDenied) {
+		status = http.StatusForbidden
+	} else if err.Error() == "error.failed_to_get_room" {
+		status = http.StatusInternalServerError
+	}
+	c.HTML(status, "error.html", gin.H{"error": utils.T(c, err.Error()),
+		"T":     utils.TmplTranslateFromContext(c),
+		"Title": title})
+	return false
+}
+
+// formProperties lists the properties a room can be assigned to in the room
+// forms.
+func (h *RoomHandler) formProperties(c *gin.Context) []models.Property {
+	userID, role := sessionUser(c)
+	properties, err := h.propertyUseCase.GetAccessibleProperties(c.Request.Context(), userID, role)
+	if err != nil {
+		return nil
+	}
+	return properties
+}
+
+// connectableRooms lists the rooms of a property that can be picked as
+// connecting rooms in the room forms.
+func (h *RoomHandler) connectableRooms(c *gin.Context, propertyID uint, roomID uint) []models.Room {
+	rooms, err := h.roomUseCase.GetConnectableRooms(c.Request.Context(), propertyID, roomID)
+	if err != nil {
+		return nil
+	}
+	return rooms
+}
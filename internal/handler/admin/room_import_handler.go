@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"archive/zip"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/utils"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *RoomHandler) RoomImportPage(c *gin.Context) {
+	h.renderImport(c, http.StatusOK, nil, "")
+}
+
+func (h *RoomHandler) ImportRooms(c *gin.Context) {
+	req, closeFiles, err := parseRoomImportForm(c)
+	if err != nil {
+		h.renderImport(c, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+	defer closeFiles()
+
+	report, err := h.roomUseCase.ImportRooms(c.Request.Context(), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, appError.ErrFailedToImportRooms) || errors.Is(err, appError.ErrFailedToGetAmenity) {
+			status = http.StatusInternalServerError
+		}
+		h.renderImport(c, status, nil, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if report.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	h.renderImport(c, status, report, "")
+}
+
+func (h *RoomHandler) ExportRooms(c *gin.Context) {
+	format := c.DefaultQuery("format", constant.ROOM_FILE_FORMAT_CSV)
+	file, err := h.roomUseCase.ExportRooms(c.Request.Context(), c.GetUint("property_id"), format)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appError.ErrUnsupportedExportFormat) {
+			status = http.StatusBadRequest
+		}
+		c.HTML(status, "error.html", gin.H{"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.import_rooms"})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+file.FileName+`"`)
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+func (h *RoomHandler) renderImport(c *gin.Context, status int, report *dto.RoomImportReport, errKey string) {
+	data := gin.H{
+		"Title":            "title.import_rooms",
+		"Report":           report,
+		"Columns":          constant.RoomFileColumns,
+		"MaxImportRows":    constant.MaxImportRows,
+		"ImageSeparator":   constant.ImportListSeparator,
+		"PropertySelected": c.GetUint("property_id") != constant.AllProperties,
+		"T":                utils.TmplTranslateFromContext(c),
+	}
+	if errKey != "" {
+		data["error"] = utils.T(c, errKey)
+	}
+	c.HTML(status, "import_rooms.html", data)
+}
+
+// parseRoomImportForm reads the spreadsheet and the optional zip bundle of
+// images. The returned function closes the bundle once the import is done.
+func parseRoomImportForm(c *gin.Context) (*dto.RoomImportRequest, func(), error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, nil, appError.ErrImportFileRequired
+	}
+	if fileHeader.Size > constant.MaxImportFileBytes {
+		return nil, nil, appError.ErrImportFileTooLarge
+	}
+	data, err := readFormFile(fileHeader, constant.MaxImportFileBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	req := &dto.RoomImportRequest{
+		FileName: fileHeader.Filename,
+		Data:     data,
+		DryRun:   c.PostForm("dry_run") == "on",
+		// Rooms are imported into the property currently being managed.
+		PropertyID: c.GetUint("property_id"),
+	}
+
+	closeFiles := func() {}
+	bundleHeader, err := c.FormFile("bundle")
+	if err != nil {
+		return req, closeFiles, nil
+	}
+	if bundleHeader.Size > constant.MaxImportBundleBytes {
+		return nil, nil, appError.ErrImportFileTooLarge
+	}
+	bundle, err := bundleHeader.Open()
+	if err != nil {
+		return nil, nil, appError.ErrInvalidImportBundle
+	}
+	reader, err := zip.NewReader(bundle, bundleHeader.Size)
+	if err != nil {
+		bundle.Close()
+		return nil, nil, appError.ErrInvalidImportBundle
+	}
+	req.Bundle = reader
+	return req, func() { bundle.Close() }, nil
+}
+
+func readFormFile(fileHeader *multipart.FileHeader, limit int64) ([]byte, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, appError.ErrImportFileRequired
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, appError.ErrImportFileRequired
+	}
+	if int64(len(data)) > limit {
+		return nil, appError.ErrImportFileTooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/usecase/admin_usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkOrderHandler struct {
+	workOrderUseCase *admin_usecase.WorkOrderUseCase
+}
+
+func NewWorkOrderHandler(workOrderUseCase *admin_usecase.WorkOrderUseCase) *WorkOrderHandler {
+	return &WorkOrderHandler{workOrderUseCase: workOrderUseCase}
+}
+
+func (h *WorkOrderHandler) ListWorkOrders(c *gin.Context) {
+	var query dto.WorkOrderQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, "error.invalid_request_data"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.work_order_management",
+		})
+		return
+	}
+	query.PropertyID = c.GetUint("property_id")
+	workOrders, err := h.workOrderUseCase.SearchWorkOrders(c.Request.Context(), query)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.work_order_management",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "work_order.html", gin.H{
+		"Title":      "title.work_order_management",
+		"WorkOrders": workOrders,
+		"Query":      query,
+		"Statuses":   constant.WorkOrderStatuses,
+		"Priorities": constant.WorkOrderPriorities,
+		"Categories": constant.WorkOrderCategories,
+		"T":          utils.TmplTranslateFromContext(c),
+	})
+}
+
+func (h *WorkOrderHandler) WorkOrderDetailPage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, "error.invalid_work_order_id"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.work_order_detail",
+		})
+		return
+	}
+	if !h.checkWorkOrderAccess(c, uint(id)) {
+		return
+	}
+	h.renderDetail(c, uint(id), http.StatusOK, "")
+}
+
+func (h *WorkOrderHandler) UpdateWorkOrderStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, "error.invalid_work_order_id"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.work_order_detail",
+		})
+		return
+	}
+	if !h.checkWorkOrderAccess(c, uint(id)) {
+		return
+	}
+	var req dto.UpdateWorkOrderStatusRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.renderDetail(c, uint(id), http.StatusBadRequest, "error.invalid_request")
+		return
+	}
+	if _, err := h.workOrderUseCase.UpdateStatus(c.Request.Context(), uint(id), c.GetUint("property_id"), &req); err != nil {
+		h.renderDetail(c, uint(id), http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("%s/%d", constant.WorkOrderPath, id))
+}
+
+func (h *WorkOrderHandler) AssignWorkOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": utils.T(c, "error.invalid_work_order_id"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.work_order_detail",
+		})
+		return
+	}
+	if !h.checkWorkOrderAccess(c, uint(id)) {
+		return
+	}
+	var req dto.AssignWorkOrderRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.renderDetail(c, uint(id), http.StatusBadRequest, "error.invalid_assignee")
+		return
+	}
+	if _, err := h.workOrderUseCase.AssignWorkOrder(c.Request.Context(), uint(id), c.GetUint("property_id"), req.AssigneeID); err != nil {
+		h.renderDetail(c, uint(id), http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("%s/%d", constant.WorkOrderPath, id))
+}
+
+func (h *WorkOrderHandler) renderDetail(c *gin.Context, id uint, status int, errMessage string) {
+	workOrder, err := h.workOrderUseCase.GetWorkOrder(c.Request.Context(), id, c.GetUint("property_id"))
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": utils.T(c, err.Error()),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.work_order_detail",
+		})
+		return
+	}
+	assignees, err := h.workOrderUseCase.GetAssignees(c.Request.Context(), workOrder)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": utils.T(c, "error.failed_to_get_staff_list"),
+			"T":     utils.TmplTranslateFromContext(c),
+			"Title": "title.work_order_detail",
+		})
+		return
+	}
+	c.HTML(status, "work_order_detail.html", gin.H{
+		"Title":     "title.work_order_detail",
+		"WorkOrder": workOrder,
+		"Assignees": assignees,
+		"Statuses":  constant.WorkOrderStatuses,
+		"error":     errMessage,
+		"T":         utils.TmplTranslateFromContext(c),
+	})
+}
+
+// checkWorkOrderAccess renders an error page and returns false when the work
+// order is missing or is for a room of another property.
+func (h *WorkOrderHandler) checkWorkOrderAccess(c *gin.Context, id uint) bool {
+	err := h.workOrderUseCase.CheckWorkOrderAccess(c.Request.Context(), id, c.GetUint("property_id"))
+	if err == nil {
+		return true
+	}
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, appError.ErrWorkOrderNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, appError.ErrPropertyAccessDenied):
+		status = http.StatusForbidden
+	}
+	c.HTML(status, "error.html", gin.H{
+		"error": utils.T(c, err.Error()),
+		"T":     utils.TmplTranslateFromContext(c),
+		"Title": "title.work_order_detail",
+	})
+	return false
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"hotel-management/internal/usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AmenityHandler struct {
+	amenityUseCase *usecase.AmenityUseCase
+}
+
+func NewAmenityHandler(amenityUseCase *usecase.AmenityUseCase) *AmenityHandler {
+	return &AmenityHandler{amenityUseCase: amenityUseCase}
+}
+
+// ListAmenities godoc
+// @Summary      List amenities
+// @Description  Return the amenity catalog with names in the requested language, for use as room search filters
+// @Tags         Rooms
+// @Produce      json
+// @Param        lang query string false "Language code (en, vi)"
+// @Success      200 {object} map[string][]dto.AmenityResponse "Amenities"
+// @Failure      500 {object} map[string]string "Failed to get amenities"
+// @Router       /amenities [get]
+func (h *AmenityHandler) ListAmenities(c *gin.Context) {
+	amenities, err := h.amenityUseCase.ListAmenities(c.Request.Context(), c.GetString("lang"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, "error.failed_to_get_amenity")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"amenities": amenities})
+}
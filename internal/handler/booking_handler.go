@@ -2,6 +2,7 @@ package handler
 
 import (
 	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
 	"hotel-management/internal/usecase"
 	"hotel-management/internal/utils"
 	"net/http"
@@ -26,10 +27,14 @@ func NewBookingHandler(bookingUseCase *usecase.BookingUseCase) *BookingHandler {
 // @Accept json
 // @Produce json
 // @Param data body dto.CreateBookingRequest true "Booking request payload"
-// @Success 201 {object} map[string]string "Booking created successfully."
+// @Param Idempotency-Key header string false "Makes retries return the first response instead of booking again; remembered for 24 hours"
+// @Success 201 {object} map[string]interface{} "Booking created successfully, with the booking under \"booking\" (dto.CreateBookingResponse)."
 // @Failure 400 {object} map[string]string "Invalid date range. Check-in date must be before check-out date."
 // @Failure 401 {object} map[string]string "Unauthorized access."
-// @Failure 400 {object} map[string]string "Room is not available."
+// @Failure 400 {object} map[string]string "Room is not available, or the rate plan is not for the rooms' property."
+// @Failure 404 {object} map[string]string "Room or rate plan not found."
+// @Failure 409 {object} map[string]string "A request with the same Idempotency-Key is still running."
+// @Failure 422 {object} map[string]string "The Idempotency-Key was used for a different request."
 // @Failure 500 {object} map[string]string "Failed to create booking, get room price, or commit transaction."
 // @Router /bookings [post]
 func (h *BookingHandler) CreateBooking(c *gin.Context) {
@@ -57,13 +62,17 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 		return
 	}
 
-	err := h.bookingUseCase.CreateBooking(c.Request.Context(), &createBookingRequest, userID)
+	booking, err := h.bookingUseCase.CreateBooking(c.Request.Context(), &createBookingRequest, userID)
 	if err != nil {
 		switch err.Error() {
 		case "error.room_not_found":
 			c.JSON(http.StatusNotFound, gin.H{"error": utils.T(c, "error.room_not_found")})
 		case "error.room_is_not_available":
 			c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.room_is_not_available")})
+		case appError.ErrRoomsInDifferentProperties.Error(), appError.ErrRatePlanNotForProperty.Error():
+			c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, err.Error())})
+		case appError.ErrRatePlanNotFound.Error():
+			c.JSON(http.StatusNotFound, gin.H{"error": utils.T(c, err.Error())})
 		case "error.failed_to_get_room_price", "error.failed_to_create_booking", "error.failed_to_commit_transaction":
 			c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
 		default:
@@ -71,7 +80,7 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 		}
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"message": utils.T(c, "success.booking_created")})
+	c.JSON(http.StatusCreated, gin.H{"message": utils.T(c, "success.booking_created"), "booking": booking})
 }
 
 // GetBookingHistory godoc
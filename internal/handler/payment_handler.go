@@ -2,9 +2,13 @@ package handler
 
 import (
 	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
 	paymentError "hotel-management/internal/error"
+	"hotel-management/internal/gateway"
 	"hotel-management/internal/usecase"
 	"hotel-management/internal/utils"
+	"log"
 	"net/http"
 	"strconv"
 
@@ -21,16 +25,25 @@ func NewPaymentHandler(paymentUseCase *usecase.PaymentUseCase) *PaymentHandler {
 
 // GetVnPayUrl godoc
 // @Summary      Create VnPay payment URL
-// @Description  Generate a payment URL via VnPay for a specific booking.
+// @Description  Generate a payment URL via VnPay for a specific booking. Pay-now and deposit bookings can be paid while their hold lasts; other bookings once checked out.
 // @Tags         payments
-// @Param        id   path      int  true  "Booking ID"
+// @Security     BearerAuth
+// @Param        id               path      int     true   "Booking ID"
+// @Param        Idempotency-Key  header    string  false  "Makes retries return the first payment URL instead of opening another payment; remembered for 24 hours"
 // @Success      200  {object}  map[string]string  "VnPay payment URL generated successfully"
-// @Failure      400  {object}  map[string]string  "Invalid amount or invalid IP address"
+// @Failure      400  {object}  map[string]string  "Invalid IP address, booking not payable yet or hold expired"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
 // @Failure      404  {object}  map[string]string  "Booking not found"
-// @Failure      409  {object}  map[string]string  "Booking has already been paid"
+// @Failure      409  {object}  map[string]string  "The rest of the balance is being paid, or a request with the same Idempotency-Key is still running"
+// @Failure      422  {object}  map[string]string  "The Idempotency-Key was used for a different request"
 // @Failure      500  {object}  map[string]string  "Failed to create payment or save payment info"
 // @Router       /payments/{id}/vnpay [get]
 func (h *PaymentHandler) GetVnPayUrl(c *gin.Context) {
+	userID, exists := c.MustGet("userID").(uint)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.unauthorized")})
+		return
+	}
 	bookingIDStr := c.Param("id")
 	clientIP := c.ClientIP()
 
@@ -43,11 +56,14 @@ func (h *PaymentHandler) GetVnPayUrl(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_client_ip")})
 		return
 	}
-	paymentURL, err := h.paymentUseCase.GetVnPayUrl(c.Request.Context(), uint(bookingID), clientIP)
+	paymentURL, err := h.paymentUseCase.GetVnPayUrl(c.Request.Context(), uint(bookingID), userID, clientIP)
 	if err != nil {
 		switch {
-		case errors.Is(err, paymentError.ErrBookingNotFound), errors.Is(err, paymentError.ErrBookingHasPaid):
+		case errors.Is(err, paymentError.ErrBookingNotFound), errors.Is(err, paymentError.ErrBookingHasPaid),
+			errors.Is(err, paymentError.ErrBookingNotCheckedOut), errors.Is(err, paymentError.ErrBookingHoldExpired):
 			c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, err.Error())})
+		case errors.Is(err, paymentError.ErrPaymentInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": utils.T(c, err.Error())})
 		case errors.Is(err, paymentError.ErrFailedToGetBooking):
 			c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
 		default:
@@ -60,44 +76,205 @@ func (h *PaymentHandler) GetVnPayUrl(c *gin.Context) {
 	})
 }
 
-// HandleVnpayCallback godoc
-// @Summary      Process VnPay callback
-// @Description  Handle the response returned from VnPay after a payment attempt.
+// CreatePayment godoc
+// @Summary      Start paying a booking
+// @Description  Create a payment for a checked-out booking with the chosen method: vnpay returns
+// @Description  the payment page URL, while cash and card_terminal payments are confirmed by
+// @Description  staff at the front desk. bank_transfer returns a VietQR payload with the amount
+// @Description  and the transfer reference; the payment is settled once staff confirm the
+// @Description  transfer or import a bank statement listing it. Without line_items or amount the payment covers the
+// @Description  balance; otherwise it covers the given rooms and charges, or the amount, for
+// @Description  payer_name. Each payer gets a separate bill once the balance reaches zero.
 // @Tags         payments
-// @Param        vnp_TxnRef          query  string  true  "Transaction Reference"
-// @Param        vnp_ResponseCode    query  string  true  "VnPay Response Code"
-// @Param        vnp_TransactionNo   query  string  true  "VnPay Transaction Number"
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path  int                       true  "Booking ID"
+// @Param        payment  body  dto.CreatePaymentRequest  true  "Payment method and split"
+// @Success      201  {object}  dto.CreatePaymentResponse
+// @Failure      400  {object}  map[string]string  "Invalid request, unknown method, booking not checked out, already paid, manual method for a held booking, invalid line item or amount over the balance"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      404  {object}  map[string]string  "Booking not found"
+// @Failure      409  {object}  map[string]string  "The rest of the balance is being paid"
+// @Failure      500  {object}  map[string]string  "Failed to create payment"
+// @Router       /payments/{id} [post]
+func (h *PaymentHandler) CreatePayment(c *gin.Context) {
+	userID, exists := c.MustGet("userID").(uint)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.unauthorized")})
+		return
+	}
+	bookingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || bookingID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_booking_id")})
+		return
+	}
+	var req dto.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+		return
+	}
+	payment, err := h.paymentUseCase.CreatePayment(c.Request.Context(), uint(bookingID), userID, &req, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, paymentError.ErrBookingNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": utils.T(c, err.Error())})
+		case errors.Is(err, paymentError.ErrUnknownPaymentMethod),
+			errors.Is(err, paymentError.ErrBookingNotCheckedOut),
+			errors.Is(err, paymentError.ErrBookingHoldExpired),
+			errors.Is(err, paymentError.ErrBookingHasPaid),
+			errors.Is(err, paymentError.ErrSplitPaymentNotAllowed),
+			errors.Is(err, paymentError.ErrHoldNeedsOnlinePayment),
+			errors.Is(err, paymentError.ErrInvalidLineItem),
+			errors.Is(err, paymentError.ErrLineItemAlreadyPaid),
+			errors.Is(err, paymentError.ErrInvalidPaymentAmount),
+			errors.Is(err, paymentError.ErrPaymentExceedsBalance):
+			c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, err.Error())})
+		case errors.Is(err, paymentError.ErrPaymentInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": utils.T(c, err.Error())})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, payment)
+}
+
+// ConfirmPayment godoc
+// @Summary      Confirm a front desk payment or bank transfer
+// @Description  Staff record that a cash, card terminal or bank transfer payment was received.
+// @Description  Card terminal payments need the terminal's approval code as reference, and bank
+// @Description  transfers the bank's transaction ID.
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id           path   int                        true   "Payment ID"
+// @Param        payment      body   dto.ConfirmPaymentRequest  true   "Amount received and reference"
+// @Param        property_id  query  int                        false  "Assigned property to work in; defaults to the first one"
 // @Success      200  {object}  map[string]string  "Payment processed successfully"
-// @Failure      400  {object}  map[string]string  "Invalid callback parameters"
+// @Failure      400  {object}  map[string]string  "Invalid request, amount mismatch, missing reference, not a front desk payment or already processed"
+// @Failure      403  {object}  map[string]string  "Property not assigned to the caller"
 // @Failure      404  {object}  map[string]string  "Payment not found"
-// @Failure      409  {object}  map[string]string  "Payment has already been processed or booking already paid"
-// @Failure      500  {object}  map[string]string  "Failed to update payment, process payment, or create bill"
-// @Router       /payments/vnpay_return [get]
-func (h *PaymentHandler) HandleVnpayCallback(c *gin.Context) {
-	vnpTxnRef := c.Query("vnp_TxnRef")
-	vnpResponseCode := c.Query("vnp_ResponseCode")
-	vnpTransactionNo := c.Query("vnp_TransactionNo")
-
-	if vnpTxnRef == "" || vnpResponseCode == "" || vnpTransactionNo == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "error.invalid_vnpay_callback_parameters"})
+// @Failure      500  {object}  map[string]string  "Failed to process payment"
+// @Router       /staff/payments/{id}/confirm [post]
+func (h *PaymentHandler) ConfirmPayment(c *gin.Context) {
+	paymentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || paymentID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
 		return
 	}
-
-	err := h.paymentUseCase.HandleVnpayCallback(c.Request.Context(), vnpTxnRef, vnpResponseCode, vnpTransactionNo)
-	if err != nil {
+	var req dto.ConfirmPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+		return
+	}
+	if err := h.paymentUseCase.ConfirmManualPayment(c.Request.Context(), uint(paymentID), c.GetUint("property_id"), &req); err != nil {
 		switch {
 		case errors.Is(err, paymentError.ErrPaymentNotFound), errors.Is(err, paymentError.ErrBookingNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": utils.T(c, err.Error())})
-		case errors.Is(err, paymentError.ErrFailedToGetPayment), errors.Is(err, paymentError.ErrFailedToGetBooking):
+		case errors.Is(err, paymentError.ErrPaymentAmountMismatch),
+			errors.Is(err, paymentError.ErrPaymentReferenceRequired),
+			errors.Is(err, paymentError.ErrPaymentNotManual),
+			errors.Is(err, paymentError.ErrPaymentAlreadyProcessed):
+			c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, err.Error())})
+		case errors.Is(err, paymentError.ErrPropertyAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": utils.T(c, err.Error())})
+		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
-		case errors.Is(err, paymentError.ErrPaymentAlreadyProcessed):
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": utils.T(c, "success.payment_processed")})
+}
+
+// HandleVnpayReturn godoc
+// @Summary      Show the result of a VnPay payment
+// @Description  VnPay redirects the customer here after a payment attempt. The signature is
+// @Description  checked and the payment status recorded from the IPN is returned; nothing is
+// @Description  updated, so the status may still be pending until the IPN arrives.
+// @Tags         payments
+// @Param        vnp_TxnRef          query  string  true  "Transaction Reference"
+// @Param        vnp_ResponseCode    query  string  true  "VnPay Response Code"
+// @Param        vnp_SecureHash      query  string  true  "HMAC-SHA512 signature"
+// @Success      200  {object}  dto.VnpayReturnResponse
+// @Failure      400  {object}  map[string]string  "Invalid signature"
+// @Failure      404  {object}  map[string]string  "Payment not found"
+// @Failure      500  {object}  map[string]string  "Failed to get payment"
+// @Router       /payments/vnpay_return [get]
+func (h *PaymentHandler) HandleVnpayReturn(c *gin.Context) {
+	result, err := h.paymentUseCase.GetVnpayReturn(c.Request.Context(), c.Request.URL.Query())
+	if err != nil {
+		switch {
+		case errors.Is(err, paymentError.ErrInvalidVnpaySignature):
 			c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, err.Error())})
-		case errors.Is(err, paymentError.ErrFailedToUpdatePayment), errors.Is(err, paymentError.ErrFailedToUpdateBooking), errors.Is(err, paymentError.ErrFailedToCreateBill):
+		case errors.Is(err, paymentError.ErrPaymentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": utils.T(c, err.Error())})
+		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// HandleVnpayIPN godoc
+// @Summary      Receive a VnPay IPN
+// @Description  Server-to-server notification from VnPay; the only request that marks a VnPay
+// @Description  payment paid or failed. The response always has status 200 and follows VnPay's
+// @Description  contract: RspCode 00 confirmed, 01 order not found, 02 already confirmed,
+// @Description  04 invalid amount, 97 invalid signature, 99 unknown error.
+// @Tags         payments
+// @Produce      json
+// @Param        vnp_TxnRef          query  string  true  "Transaction Reference"
+// @Param        vnp_Amount          query  string  true  "Amount in hundredths of a dong"
+// @Param        vnp_ResponseCode    query  string  true  "VnPay Response Code"
+// @Param        vnp_TransactionNo   query  string  true  "VnPay Transaction Number"
+// @Param        vnp_SecureHash      query  string  true  "HMAC-SHA512 signature"
+// @Success      200  {object}  map[string]string  "RspCode and Message"
+// @Router       /payments/vnpay_ipn [get]
+func (h *PaymentHandler) HandleVnpayIPN(c *gin.Context) {
+	err := h.paymentUseCase.HandleCallback(c.Request.Context(), constant.PAYMENT_METHOD_VNPAY, c.Request.URL.Query())
+	rspCode, message := gateway.VnpayIPNResponse(err)
+	if rspCode == constant.VNPAY_RSP_UNKNOWN_ERROR {
+		log.Printf("vnpay ipn for %s failed: %v", c.Query("vnp_TxnRef"), err)
+	}
+	c.JSON(http.StatusOK, gin.H{"RspCode": rspCode, "Message": message})
+}
+
+// ListBookingPayments godoc
+// @Summary      List the payments of a booking
+// @Description  Return the payments made on one of the customer's bookings, newest first, with what was refunded on each.
+// @Tags         payments
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Booking ID"
+// @Success      200  {object}  map[string][]dto.PaymentResponse "Payments"
+// @Failure      400  {object}  map[string]string  "Invalid booking ID"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      404  {object}  map[string]string  "Booking not found"
+// @Failure      500  {object}  map[string]string  "Failed to get payments"
+// @Router       /bookings/{id}/payments [get]
+func (h *PaymentHandler) ListBookingPayments(c *gin.Context) {
+	userID, exists := c.MustGet("userID").(uint)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.unauthorized")})
+		return
+	}
+	bookingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || bookingID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_booking_id")})
+		return
+	}
+	payments, err := h.paymentUseCase.ListBookingPayments(c.Request.Context(), uint(bookingID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, paymentError.ErrBookingNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": utils.T(c, err.Error())})
 		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, "error.failed_to_process_payment")})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
 		}
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": utils.T(c, "success.payment_processed")})
+	c.JSON(http.StatusOK, gin.H{"payments": payments})
 }
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"encoding/json"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	"hotel-management/internal/gateway"
+	"hotel-management/internal/gateway/vnpaysandbox"
+	"hotel-management/internal/middleware"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/testutil"
+	"hotel-management/internal/usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	testTmnCode    = "TESTTMN1"
+	testHashSecret = "SANDBOXTESTSECRET"
+	testTotalPrice = 2_000_000
+)
+
+// vnpayFlow runs the payment routes with their real middleware, use case and
+// repositories on a test database, with the VNPay gateway pointed at the
+// sandbox.
+type vnpayFlow struct {
+	db      *gorm.DB
+	app     *httptest.Server
+	sandbox *vnpaysandbox.Server
+	// client is the customer's browser: redirects are checked by the test
+	// instead of being followed.
+	client  *http.Client
+	booking *models.Booking
+	token   string
+}
+
+func newVnpayFlow(t *testing.T, autoRespond string) *vnpayFlow {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	utils.InitI18n()
+	t.Setenv("SECRET_KEY", "test-secret")
+	t.Setenv("JWT_ISSUER", "test")
+	utils.InitJWT()
+
+	f := &vnpayFlow{db: testutil.NewDB(t)}
+	r := gin.New()
+	r.Use(middleware.I18nMiddleware())
+	f.app = httptest.NewServer(r)
+	t.Cleanup(f.app.Close)
+
+	f.sandbox = vnpaysandbox.NewServer(vnpaysandbox.Config{
+		TmnCode:     testTmnCode,
+		HashSecret:  testHashSecret,
+		IPNURL:      f.app.URL + "/payments/vnpay_ipn",
+		AutoRespond: autoRespond,
+	})
+	sandboxServer := httptest.NewServer(f.sandbox)
+	t.Cleanup(sandboxServer.Close)
+
+	vnpay := gateway.NewVnpayGateway(gateway.VnpayConfig{
+		TmnCode:    testTmnCode,
+		HashSecret: testHashSecret,
+		PayURL:     sandboxServer.URL + vnpaysandbox.PayPath,
+		ReturnURL:  f.app.URL + "/payments/vnpay_return",
+		APIURL:     sandboxServer.URL + vnpaysandbox.APIPath,
+	})
+	userRepository := repository.NewUserRepository(f.db)
+	paymentUseCase := usecase.NewPaymentUseCase(
+		repository.NewPaymentRepository(f.db),
+		repository.NewBookingRepository(f.db),
+		repository.NewBillRepository(f.db),
+		gateway.NewRegistry(vnpay),
+	)
+	paymentHandler := NewPaymentHandler(paymentUseCase)
+	idempotent := middleware.Idempotency(repository.NewIdempotencyRepository(f.db))
+	paymentGroup := r.Group("/payments")
+	{
+		paymentGroup.GET("/:id/vnpay", middleware.RequireAuth(userRepository), idempotent, paymentHandler.GetVnPayUrl)
+		paymentGroup.GET("/vnpay_return", paymentHandler.HandleVnpayReturn)
+		paymentGroup.GET("/vnpay_ipn", paymentHandler.HandleVnpayIPN)
+	}
+
+	f.client = &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	f.createBooking(t)
+	return f
+}
+
+// createBooking stores a customer and a booking holding its rooms until it
+// is paid in full.
+func (f *vnpayFlow) createBooking(t *testing.T) {
+	t.Helper()
+	user := models.User{Name: "Guest", Email: "guest@example.com", Role: constant.CUSTOMER, IsActive: true}
+	if err := f.db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	token, err := utils.GenerateAccessToken(&user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	holdExpiresAt := time.Now().Add(time.Hour)
+	booking := models.Booking{
+		UserID:        user.ID,
+		BookingStatus: constant.PENDING_PAYMENT,
+		TotalPrice:    testTotalPrice,
+		StartDate:     time.Now().AddDate(0, 0, 7),
+		EndDate:       time.Now().AddDate(0, 0, 9),
+		PaymentTiming: constant.PAYMENT_TIMING_PAY_NOW,
+		HoldExpiresAt: &holdExpiresAt,
+	}
+	if err := f.db.Create(&booking).Error; err != nil {
+		t.Fatal(err)
+	}
+	f.booking = &booking
+	f.token = token
+}
+
+// paymentURL asks the application for the VNPay payment page of the booking.
+func (f *vnpayFlow) paymentURL(t *testing.T) string {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, f.app.URL+"/payments/"+strconv.Itoa(int(f.booking.ID))+"/vnpay", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+f.token)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		t.Fatalf("GET /payments/:id/vnpay: %v", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		PaymentURL string `json:"payment_url"`
+		Error      string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode payment URL response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /payments/:id/vnpay status = %d (%s), want 200", resp.StatusCode, body.Error)
+	}
+	return body.PaymentURL
+}
+
+// returnTo follows the sandbox's redirect back to the application and
+// returns what the return page reported, with the signed result VNPay sent.
+func (f *vnpayFlow) returnTo(t *testing.T, resp *http.Response) (*dto.VnpayReturnResponse, url.Values) {
+	t.Helper()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("status = %d, want a redirect back to the application", resp.StatusCode)
+	}
+	location := resp.Header.Get("Location")
+	if !strings.HasPrefix(location, f.app.URL+"/payments/vnpay_return?") {
+		t.Fatalf("redirected to %s, want the return page", location)
+	}
+	returned, err := f.client.Get(location)
+	if err != nil {
+		t.Fatalf("GET return page: %v", err)
+	}
+	defer returned.Body.Close()
+	if returned.StatusCode != http.StatusOK {
+		t.Fatalf("return page status = %d, want 200", returned.StatusCode)
+	}
+	var result dto.VnpayReturnResponse
+	if err := json.NewDecoder(returned.Body).Decode(&result); err != nil {
+		t.Fatalf("decode return page: %v", err)
+	}
+	params, err := url.ParseQuery(strings.SplitN(location, "?", 2)[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &result, params
+}
+
+func (f *vnpayFlow) state(t *testing.T) (models.Payment, models.Booking, []models.Bill) {
+	t.Helper()
+	var payments []models.Payment
+	if err := f.db.Where("booking_id = ?", f.booking.ID).Find(&payments).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 1 {
+		t.Fatalf("booking has %d payments, want 1", len(payments))
+	}
+	var booking models.Booking
+	if err := f.db.First(&booking, f.booking.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	var bills []models.Bill
+	if err := f.db.Where("booking_id = ?", f.booking.ID).Find(&bills).Error; err != nil {
+		t.Fatal(err)
+	}
+	return payments[0], booking, bills
+}
+
+func TestVnpayPaymentSettlesBooking(t *testing.T) {
+	f := newVnpayFlow(t, vnpaysandbox.OutcomePay)
+
+	resp, err := f.client.Get(f.paymentURL(t))
+	if err != nil {
+		t.Fatalf("open payment page: %v", err)
+	}
+	resp.Body.Close()
+	result, _ := f.returnTo(t, resp)
+	if result.BookingID != f.booking.ID || result.PaymentStatus != constant.PAYMENT_SUCCESS {
+		t.Errorf("return page = %+v, want booking %d paid", result, f.booking.ID)
+	}
+
+	payment, booking, bills := f.state(t)
+	txn, ok := f.sandbox.Transaction(payment.TxnRef)
+	if !ok {
+		t.Fatal("sandbox has no record of the payment")
+	}
+	if txn.IPNResponse != constant.VNPAY_RSP_CONFIRMED {
+		t.Errorf("IPN answered %s, want %s", txn.IPNResponse, constant.VNPAY_RSP_CONFIRMED)
+	}
+	if payment.PaymentStatus != constant.PAYMENT_SUCCESS || payment.Amount != testTotalPrice || payment.TransactionID != txn.TransactionNo {
+		t.Errorf("payment = %s %v %q, want %s %v %q",
+			payment.PaymentStatus, payment.Amount, payment.TransactionID, constant.PAYMENT_SUCCESS, testTotalPrice, txn.TransactionNo)
+	}
+	if booking.BookingStatus != constant.BOOKED || !booking.IsPaid || booking.PaidAmount != testTotalPrice || booking.HoldExpiresAt != nil {
+		t.Errorf("booking = %s paid=%v %v hold=%v, want %s paid in full without a hold",
+			booking.BookingStatus, booking.IsPaid, booking.PaidAmount, booking.HoldExpiresAt, constant.BOOKED)
+	}
+	if len(bills) != 1 || bills[0].TotalAmount != testTotalPrice {
+		t.Errorf("bills = %+v, want one bill of %v", bills, testTotalPrice)
+	}
+}
+
+func TestVnpayPaymentCancelledByCustomer(t *testing.T) {
+	f := newVnpayFlow(t, "")
+
+	payURL := f.paymentURL(t)
+	resp, err := f.client.Get(payURL)
+	if err != nil {
+		t.Fatalf("open payment page: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("payment page status = %d, want 200", resp.StatusCode)
+	}
+	payment, _, _ := f.state(t)
+	completeURL := strings.SplitN(payURL, vnpaysandbox.PayPath, 2)[0] + vnpaysandbox.CompletePath
+	resp, err = f.client.PostForm(completeURL, url.Values{"txn_ref": {payment.TxnRef}, "outcome": {vnpaysandbox.OutcomeFail}})
+	if err != nil {
+		t.Fatalf("cancel payment: %v", err)
+	}
+	resp.Body.Close()
+	result, _ := f.returnTo(t, resp)
+	if result.PaymentStatus != constant.PAYMENT_FAILED {
+		t.Errorf("return page status = %s, want %s", result.PaymentStatus, constant.PAYMENT_FAILED)
+	}
+
+	payment, booking, bills := f.state(t)
+	if payment.PaymentStatus != constant.PAYMENT_FAILED {
+		t.Errorf("payment status = %s, want %s", payment.PaymentStatus, constant.PAYMENT_FAILED)
+	}
+	// Nothing else is paying for the held rooms, so they are released.
+	if booking.BookingStatus != constant.CANCELLED || booking.IsPaid || booking.PaidAmount != 0 {
+		t.Errorf("booking = %s paid=%v %v, want %s and unpaid", booking.BookingStatus, booking.IsPaid, booking.PaidAmount, constant.CANCELLED)
+	}
+	if len(bills) != 0 {
+		t.Errorf("%d bills issued for a cancelled payment, want 0", len(bills))
+	}
+}
+
+func TestVnpayDuplicateIPNIsSettledOnce(t *testing.T) {
+	f := newVnpayFlow(t, vnpaysandbox.OutcomePay)
+
+	resp, err := f.client.Get(f.paymentURL(t))
+	if err != nil {
+		t.Fatalf("open payment page: %v", err)
+	}
+	resp.Body.Close()
+	_, params := f.returnTo(t, resp)
+
+	// VNPay retries an IPN it got no answer to with the same signed result.
+	ipn, err := f.client.Get(f.app.URL + "/payments/vnpay_ipn?" + params.Encode())
+	if err != nil {
+		t.Fatalf("replay IPN: %v", err)
+	}
+	defer ipn.Body.Close()
+	var answer struct{ RspCode string }
+	if err := json.NewDecoder(ipn.Body).Decode(&answer); err != nil {
+		t.Fatalf("decode IPN answer: %v", err)
+	}
+	if ipn.StatusCode != http.StatusOK || answer.RspCode != constant.VNPAY_RSP_ALREADY_CONFIRMED {
+		t.Errorf("replayed IPN = %d %s, want 200 %s", ipn.StatusCode, answer.RspCode, constant.VNPAY_RSP_ALREADY_CONFIRMED)
+	}
+
+	payment, booking, bills := f.state(t)
+	if payment.PaymentStatus != constant.PAYMENT_SUCCESS {
+		t.Errorf("payment status = %s, want %s", payment.PaymentStatus, constant.PAYMENT_SUCCESS)
+	}
+	if booking.PaidAmount != testTotalPrice {
+		t.Errorf("booking paid amount = %v, want %v", booking.PaidAmount, testTotalPrice)
+	}
+	if len(bills) != 1 {
+		t.Errorf("%d bills issued, want 1", len(bills))
+	}
+	var callbacks int64
+	f.db.Model(&models.PaymentCallback{}).Where("txn_ref = ?", payment.TxnRef).Count(&callbacks)
+	if callbacks != 2 {
+		t.Errorf("%d callbacks kept, want both IPNs", callbacks)
+	}
+}
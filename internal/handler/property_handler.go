@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"hotel-management/internal/dto"
+	"hotel-management/internal/usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PropertyHandler struct {
+	propertyUseCase *usecase.PropertyUseCase
+}
+
+func NewPropertyHandler(propertyUseCase *usecase.PropertyUseCase) *PropertyHandler {
+	return &PropertyHandler{propertyUseCase: propertyUseCase}
+}
+
+// ListProperties godoc
+// @Summary      List properties
+// @Description  Return the hotels guests can book, optionally in one city, for use as a room search filter
+// @Tags         Rooms
+// @Produce      json
+// @Param        city query string false "City"
+// @Success      200 {object} map[string][]dto.PropertyResponse "Properties"
+// @Failure      400 {object} map[string]string "Invalid query"
+// @Failure      500 {object} map[string]string "Failed to get properties"
+// @Router       /properties [get]
+func (h *PropertyHandler) ListProperties(c *gin.Context) {
+	var query dto.PropertyQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+		return
+	}
+	properties, err := h.propertyUseCase.ListProperties(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, "error.failed_to_get_property")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"properties": properties})
+}
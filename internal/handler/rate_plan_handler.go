@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"hotel-management/internal/dto"
+	"hotel-management/internal/usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RatePlanHandler struct {
+	ratePlanUseCase *usecase.RatePlanUseCase
+}
+
+func NewRatePlanHandler(ratePlanUseCase *usecase.RatePlanUseCase) *RatePlanHandler {
+	return &RatePlanHandler{ratePlanUseCase: ratePlanUseCase}
+}
+
+// ListRatePlans godoc
+// @Summary      List rate plans
+// @Description  Return the active rate plans of a property with their payment timing (pay_at_hotel, pay_now or deposit)
+// @Tags         Bookings
+// @Produce      json
+// @Param        property_id query int true "Property ID"
+// @Success      200 {object} map[string][]dto.RatePlanResponse "Rate plans"
+// @Failure      400 {object} map[string]string "Invalid query"
+// @Failure      500 {object} map[string]string "Failed to get rate plans"
+// @Router       /rate-plans [get]
+func (h *RatePlanHandler) ListRatePlans(c *gin.Context) {
+	var query dto.RatePlanQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+		return
+	}
+	ratePlans, err := h.ratePlanUseCase.ListRatePlans(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, "error.failed_to_get_rate_plan")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rate_plans": ratePlans})
+}
@@ -7,6 +7,7 @@ import (
 	"hotel-management/internal/usecase"
 	"hotel-management/internal/utils"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,24 +24,30 @@ func NewReviewHandler(reviewUseCase *usecase.ReviewUseCase) *ReviewHandler {
 
 // CreateReview godoc
 // @Summary Create a review for a completed booking
-// @Description Customers can create a review after checking out from a room
+// @Description Customers can create a review after checking out from a room, with
+// @Description sub-scores per criterion. Up to 5 photos can be attached by sending the
+// @Description review as multipart/form-data with "photos" files.
 // @Tags Review
-// @Accept json
+// @Accept json,mpfd
 // @Produce json
 // @Security BearerAuth
 // @Param review body dto.CreateReviewRequest true "Review content"
+// @Param photos formData file false "Photos, at most 5"
 // @Success 201 {object} map[string]string "Review created successfully"
-// @Failure 400 {object} map[string]string "Invalid request / already reviewed / not checked out"
+// @Failure 400 {object} map[string]string "Invalid request / already reviewed / not checked out / room not in booking / invalid photo"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 404 {object} map[string]string "Booking not found"
 // @Failure 500 {object} map[string]string "Failed to create review"
 // @Router /reviews [post]
 func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	var createReviewRequest dto.CreateReviewRequest
-	if err := c.ShouldBindJSON(&createReviewRequest); err != nil {
+	if err := c.ShouldBind(&createReviewRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
 		return
 	}
+	if form, err := c.MultipartForm(); err == nil {
+		createReviewRequest.Photos = form.File["photos"]
+	}
 	userIDStr, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.unauthorized")})
@@ -57,7 +64,13 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 		case errors.Is(err, reviewError.ErrBookingNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": utils.T(c, err.Error())})
 		case errors.Is(err, reviewError.ErrBookingNotCheckedOut),
-			errors.Is(err, reviewError.ErrReviewAlreadyExists):
+			errors.Is(err, reviewError.ErrReviewAlreadyExists),
+			errors.Is(err, reviewError.ErrRoomNotInBooking),
+			errors.Is(err, reviewError.ErrTooManyReviewPhotos),
+			errors.Is(err, reviewError.ErrImageTooLarge),
+			errors.Is(err, reviewError.ErrInvalidImage),
+			errors.Is(err, reviewError.ErrInvalidImageType),
+			errors.Is(err, reviewError.ErrImageDimensionsTooLarge):
 			c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, err.Error())})
 		case errors.Is(err, reviewError.ErrFailedToCreateReview),
 			errors.Is(err, reviewError.ErrReviewCheckFailed),
@@ -70,3 +83,115 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	}
 	c.JSON(http.StatusCreated, gin.H{"message": utils.T(c, "success.review_created")})
 }
+
+// UpdateReview godoc
+// @Summary Edit a review
+// @Description The author can change the rating, sub-scores and comment of a review
+// @Description within the edit window (REVIEW_EDIT_WINDOW_DAYS, 14 days by default).
+// @Description The edited review goes back to moderation.
+// @Tags Review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Param review body dto.UpdateReviewRequest true "New review content"
+// @Success 200 {object} dto.PublicReviewResponse
+// @Failure 400 {object} map[string]string "Invalid request / edit window expired"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the author"
+// @Failure 404 {object} map[string]string "Review not found"
+// @Failure 500 {object} map[string]string "Failed to update review"
+// @Router /reviews/{id} [put]
+func (h *ReviewHandler) UpdateReview(c *gin.Context) {
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || reviewID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_review_id")})
+		return
+	}
+	var req dto.UpdateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+		return
+	}
+	userID, ok := c.MustGet("userID").(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.unauthorized")})
+		return
+	}
+	review, err := h.reviewUseCase.UpdateReview(c.Request.Context(), uint(reviewID), &req, userID)
+	if err != nil {
+		respondReviewChangeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+// DeleteReview godoc
+// @Summary Delete a review
+// @Description The author can delete a review within the edit window. Moderators
+// @Description still see its last content in the review history.
+// @Tags Review
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Success 200 {object} map[string]string "Review deleted successfully"
+// @Failure 400 {object} map[string]string "Invalid review ID / edit window expired"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the author"
+// @Failure 404 {object} map[string]string "Review not found"
+// @Failure 500 {object} map[string]string "Failed to delete review"
+// @Router /reviews/{id} [delete]
+func (h *ReviewHandler) DeleteReview(c *gin.Context) {
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || reviewID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_review_id")})
+		return
+	}
+	userID, ok := c.MustGet("userID").(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.unauthorized")})
+		return
+	}
+	if err := h.reviewUseCase.DeleteReview(c.Request.Context(), uint(reviewID), userID); err != nil {
+		respondReviewChangeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": utils.T(c, "success.review_deleted")})
+}
+
+func respondReviewChangeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, reviewError.ErrReviewNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": utils.T(c, err.Error())})
+	case errors.Is(err, reviewError.ErrReviewNotOwned):
+		c.JSON(http.StatusForbidden, gin.H{"error": utils.T(c, err.Error())})
+	case errors.Is(err, reviewError.ErrReviewEditWindowExpired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, err.Error())})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
+	}
+}
+
+// ListPendingReviews godoc
+// @Summary List rooms waiting for a review
+// @Description Rooms of the customer's checked-out bookings that have not been reviewed yet
+// @Tags Review
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.PendingReviewResponse
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Failed to get pending reviews"
+// @Router /reviews/pending [get]
+func (h *ReviewHandler) ListPendingReviews(c *gin.Context) {
+	userID, ok := c.MustGet("userID").(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.unauthorized")})
+		return
+	}
+	pending, err := h.reviewUseCase.ListPendingReviews(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, pending)
+}
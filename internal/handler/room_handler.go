@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
 	"hotel-management/internal/usecase"
 	"hotel-management/internal/utils"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,12 +23,15 @@ func NewRoomHandler(roomUseCase *usecase.RoomUseCase) *RoomHandler {
 
 // FindAvailableRoom godoc
 // @Summary      Search available rooms
-// @Description  Find all available rooms that match the search criteria and are not booked during the requested time range
+// @Description  Find all available rooms that match the search criteria and are not booked during the requested time range.
+// @Description  Supports free-text search (q), sorting (price_asc, price_desc, rating_desc, capacity_asc, capacity_desc),
+// @Description  property_id or city filters, page/limit pagination and returns facet counts for the matching rooms.
+// @Description  With family=true the result also suggests pairs of available connecting rooms (connecting_rooms).
 // @Tags         Rooms
 // @Accept       json
 // @Produce      json
 // @Param        request body dto.SearchRoomRequest true "Search filters for room availability"
-// @Success      200 {object} map[string][]dto.SearchRoomResponse "Find available room successful!"
+// @Success      200 {object} dto.SearchRoomResult "Find available room successful!"
 // @Failure      400 {object} map[string]string "Invalid request data"
 // @Failure      500 {object} map[string]string "Failed to find available room."
 // @Router       /rooms/search [post]
@@ -47,13 +53,136 @@ func (h *RoomHandler) FindAvailableRoom(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.min_price_must_be_less_than_max_price")})
 		return
 	}
-	rooms, err := h.roomUseCase.SearchRoom(c.Request.Context(), &searchRoomRequest)
+	result, err := h.roomUseCase.SearchRoom(c.Request.Context(), &searchRoomRequest, c.GetString("lang"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, "error.failed_to_find_available_room")})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"message": utils.T(c, "success.find_available_room_successful"),
-		"rooms":   rooms,
-	})
+	response := gin.H{
+		"message":    utils.T(c, "success.find_available_room_successful"),
+		"rooms":      result.Rooms,
+		"pagination": result.Pagination,
+		"facets":     result.Facets,
+	}
+	if searchRoomRequest.Family {
+		response["connecting_rooms"] = result.ConnectingRooms
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListRooms godoc
+// @Summary      Browse rooms
+// @Description  Public, paginated catalogue of rooms with images, amenities and average rating. No login required.
+// @Tags         Rooms
+// @Produce      json
+// @Param        type  query string false "Filter by room type"
+// @Param        property_id query int false "Filter by property"
+// @Param        city  query string false "Filter by property city"
+// @Param        page  query int    false "Page number (default 1)"
+// @Param        limit query int    false "Page size (default 20, max 100)"
+// @Param        lang  query string false "Language code (en, vi)"
+// @Success      200 {object} dto.PublicRoomListResponse
+// @Success      304 "Not modified"
+// @Failure      400 {object} map[string]string "Invalid request data"
+// @Failure      500 {object} map[string]string "Failed to get rooms"
+// @Router       /rooms [get]
+func (h *RoomHandler) ListRooms(c *gin.Context) {
+	var query dto.PublicRoomQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+		return
+	}
+	rooms, err := h.roomUseCase.ListRooms(c.Request.Context(), query, c.GetString("lang"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, rooms)
+}
+
+// ListRoomTypes godoc
+// @Summary      List room types
+// @Description  Public list of room types with room count, price range and a cover image. No login required.
+// @Tags         Rooms
+// @Produce      json
+// @Success      200 {object} map[string][]dto.RoomTypeResponse
+// @Success      304 "Not modified"
+// @Failure      500 {object} map[string]string "Failed to get rooms"
+// @Router       /rooms/types [get]
+func (h *RoomHandler) ListRoomTypes(c *gin.Context) {
+	types, err := h.roomUseCase.ListRoomTypes(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"room_types": types})
+}
+
+// GetRoomDetail godoc
+// @Summary      Room detail
+// @Description  Public room detail with images, amenities, average rating, rating histogram and the most recent reviews. No login required.
+// @Tags         Rooms
+// @Produce      json
+// @Param        id   path  int    true  "Room ID"
+// @Param        lang query string false "Language code (en, vi)"
+// @Success      200 {object} dto.PublicRoomDetailResponse
+// @Success      304 "Not modified"
+// @Failure      400 {object} map[string]string "Invalid room ID"
+// @Failure      404 {object} map[string]string "Room not found"
+// @Failure      500 {object} map[string]string "Failed to get room"
+// @Router       /rooms/{id} [get]
+func (h *RoomHandler) GetRoomDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_room_id")})
+		return
+	}
+	room, err := h.roomUseCase.GetRoomDetail(c.Request.Context(), uint(id), c.GetString("lang"))
+	if err != nil {
+		if errors.Is(err, appError.ErrRoomNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": utils.T(c, err.Error())})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+// ListRoomReviews godoc
+// @Summary      Room reviews
+// @Description  Public, paginated reviews of a room with its average rating, review count and rating histogram. No login required.
+// @Tags         Rooms
+// @Produce      json
+// @Param        id    path  int    true  "Room ID"
+// @Param        sort  query string false "newest (default) or highest"
+// @Param        page  query int    false "Page number (default 1)"
+// @Param        limit query int    false "Page size (default 20, max 100)"
+// @Success      200 {object} dto.RoomReviewListResponse
+// @Success      304 "Not modified"
+// @Failure      400 {object} map[string]string "Invalid request data"
+// @Failure      404 {object} map[string]string "Room not found"
+// @Failure      500 {object} map[string]string "Failed to get reviews"
+// @Router       /rooms/{id}/reviews [get]
+func (h *RoomHandler) ListRoomReviews(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_room_id")})
+		return
+	}
+	var query dto.RoomReviewQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+		return
+	}
+	reviews, err := h.roomUseCase.ListRoomReviews(c.Request.Context(), uint(id), query)
+	if err != nil {
+		if errors.Is(err, appError.ErrRoomNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": utils.T(c, err.Error())})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, reviews)
 }
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/models"
+	"hotel-management/internal/utils"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const MaxWorkOrderPhotos = 5
+
+// WorkOrderService is the part of the work order use case the staff API
+// needs. The use case itself lives with the admin pages that also drive it.
+type WorkOrderService interface {
+	CreateWorkOrder(ctx *gin.Context, req *dto.CreateWorkOrderRequest, reporterID uint, propertyID uint) (*models.WorkOrder, error)
+	SearchWorkOrders(ctx context.Context, query dto.WorkOrderQuery) ([]models.WorkOrder, error)
+	GetWorkOrder(ctx context.Context, id uint, propertyID uint) (*models.WorkOrder, error)
+	UpdateStatus(ctx context.Context, id uint, propertyID uint, req *dto.UpdateWorkOrderStatusRequest) (*models.WorkOrder, error)
+	AssignWorkOrder(ctx context.Context, id uint, propertyID uint, assigneeID uint) (*models.WorkOrder, error)
+}
+
+type WorkOrderHandler struct {
+	workOrderUseCase WorkOrderService
+}
+
+func NewWorkOrderHandler(workOrderUseCase WorkOrderService) *WorkOrderHandler {
+	return &WorkOrderHandler{workOrderUseCase: workOrderUseCase}
+}
+
+// CreateWorkOrder godoc
+// @Summary      Report a maintenance issue
+// @Description  Staff open a work order for a room, optionally with up to 5 photos. High and urgent tickets block the room by default.
+// @Tags         WorkOrders
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        room_id      formData  int     true   "Room ID"
+// @Param        title        formData  string  true   "Short summary"
+// @Param        description  formData  string  false  "Details"
+// @Param        category     formData  string  true   "aircon, plumbing, electrical, furniture, cleaning or other"
+// @Param        priority     formData  string  true   "low, medium, high or urgent"
+// @Param        assignee_id  formData  int     false  "Staff user ID"
+// @Param        blocks_room  formData  bool    false  "Override whether the room is blocked until the ticket is closed"
+// @Param        photos       formData  file    false  "Photos"
+// @Param        property_id  query     int     false  "Assigned property to work in; defaults to the first one"
+// @Success      201  {object}  models.WorkOrder
+// @Failure      400  {object}  map[string]string  "Invalid request data"
+// @Failure      403  {object}  map[string]string  "Property not assigned to the caller"
+// @Failure      404  {object}  map[string]string  "Room not found"
+// @Failure      500  {object}  map[string]string  "Failed to create work order"
+// @Router       /staff/work-orders [post]
+func (h *WorkOrderHandler) CreateWorkOrder(c *gin.Context) {
+	var req dto.CreateWorkOrderRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+		return
+	}
+	if form, err := c.MultipartForm(); err == nil {
+		req.Photos = form.File["photos"]
+	}
+	if err := validateWorkOrderPhotos(req.Photos); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, err.Error())})
+		return
+	}
+
+	workOrder, err := h.workOrderUseCase.CreateWorkOrder(c, &req, c.MustGet("userID").(uint), c.GetUint("property_id"))
+	if err != nil {
+		respondWorkOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, workOrder)
+}
+
+// ListWorkOrders godoc
+// @Summary      List work orders
+// @Tags         WorkOrders
+// @Produce      json
+// @Security     BearerAuth
+// @Param        room_id      query  int     false  "Room ID"
+// @Param        status       query  string  false  "Status"
+// @Param        priority     query  string  false  "Priority"
+// @Param        category     query  string  false  "Category"
+// @Param        assignee_id  query  int     false  "Assignee ID"
+// @Param        property_id  query  int     false  "Assigned property to work in; defaults to the first one"
+// @Success      200  {array}   models.WorkOrder
+// @Failure      400  {object}  map[string]string  "Invalid filter"
+// @Failure      403  {object}  map[string]string  "Property not assigned to the caller"
+// @Failure      500  {object}  map[string]string  "Failed to get work orders"
+// @Router       /staff/work-orders [get]
+func (h *WorkOrderHandler) ListWorkOrders(c *gin.Context) {
+	var query dto.WorkOrderQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+		return
+	}
+	query.PropertyID = c.GetUint("property_id")
+	workOrders, err := h.workOrderUseCase.SearchWorkOrders(c.Request.Context(), query)
+	if err != nil {
+		respondWorkOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, workOrders)
+}
+
+// GetWorkOrder godoc
+// @Summary      Get a work order
+// @Tags         WorkOrders
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id           path   int  true   "Work order ID"
+// @Param        property_id  query  int  false  "Assigned property to work in; defaults to the first one"
+// @Success      200  {object}  models.WorkOrder
+// @Failure      403  {object}  map[string]string  "Property not assigned to the caller"
+// @Failure      404  {object}  map[string]string  "Work order not found"
+// @Router       /staff/work-orders/{id} [get]
+func (h *WorkOrderHandler) GetWorkOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_work_order_id")})
+		return
+	}
+	workOrder, err := h.workOrderUseCase.GetWorkOrder(c.Request.Context(), uint(id), c.GetUint("property_id"))
+	if err != nil {
+		respondWorkOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, workOrder)
+}
+
+// UpdateWorkOrderStatus godoc
+// @Summary      Move a work order through its lifecycle
+// @Description  Allowed moves: open→assigned/in_progress, assigned→in_progress, in_progress→resolved, resolved→closed/in_progress, and any unfinished status→cancelled. Resolving requires a resolution note.
+// @Tags         WorkOrders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id           path   int                               true   "Work order ID"
+// @Param        request      body   dto.UpdateWorkOrderStatusRequest  true   "New status"
+// @Param        property_id  query  int                               false  "Assigned property to work in; defaults to the first one"
+// @Success      200  {object}  models.WorkOrder
+// @Failure      400  {object}  map[string]string  "Invalid status or transition"
+// @Failure      403  {object}  map[string]string  "Property not assigned to the caller"
+// @Failure      404  {object}  map[string]string  "Work order not found"
+// @Router       /staff/work-orders/{id}/status [put]
+func (h *WorkOrderHandler) UpdateWorkOrderStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_work_order_id")})
+		return
+	}
+	var req dto.UpdateWorkOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+		return
+	}
+	workOrder, err := h.workOrderUseCase.UpdateStatus(c.Request.Context(), uint(id), c.GetUint("property_id"), &req)
+	if err != nil {
+		respondWorkOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, workOrder)
+}
+
+// AssignWorkOrder godoc
+// @Summary      Assign a work order to a staff member
+// @Tags         WorkOrders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id           path   int                         true   "Work order ID"
+// @Param        request      body   dto.AssignWorkOrderRequest  true   "Assignee"
+// @Param        property_id  query  int                         false  "Assigned property to work in; defaults to the first one"
+// @Success      200  {object}  models.WorkOrder
+// @Failure      400  {object}  map[string]string  "Invalid assignee"
+// @Failure      403  {object}  map[string]string  "Property not assigned to the caller"
+// @Failure      404  {object}  map[string]string  "Work order not found"
+// @Router       /staff/work-orders/{id}/assign [put]
+func (h *WorkOrderHandler) AssignWorkOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_work_order_id")})
+		return
+	}
+	var req dto.AssignWorkOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+		return
+	}
+	workOrder, err := h.workOrderUseCase.AssignWorkOrder(c.Request.Context(), uint(id), c.GetUint("property_id"), req.AssigneeID)
+	if err != nil {
+		respondWorkOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, workOrder)
+}
+
+func validateWorkOrderPhotos(files []*multipart.FileHeader) error {
+	if len(files) > MaxWorkOrderPhotos {
+		return errors.New("error.too_many_images")
+	}
+	for _, file := range files {
+		if err := utils.ValidateUploadedImage(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func respondWorkOrderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, appError.ErrWorkOrderNotFound), errors.Is(err, appError.ErrRoomNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": utils.T(c, err.Error())})
+	case errors.Is(err, appError.ErrInvalidWorkOrderStatus),
+		errors.Is(err, appError.ErrInvalidWorkOrderStatusTransition),
+		errors.Is(err, appError.ErrInvalidWorkOrderPriority),
+		errors.Is(err, appError.ErrInvalidWorkOrderCategory),
+		errors.Is(err, appError.ErrResolutionNoteRequired),
+		errors.Is(err, appError.ErrInvalidAssignee):
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, err.Error())})
+	case errors.Is(err, appError.ErrPropertyAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": utils.T(c, err.Error())})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, err.Error())})
+	}
+}
@@ -0,0 +1,45 @@
+package job
+
+import (
+	"context"
+	"hotel-management/internal/usecase"
+	"log"
+	"time"
+)
+
+// holdReleaseInterval is short so that a room held for an unpaid booking goes
+// back on sale soon after its hold expires.
+const holdReleaseInterval = time.Minute
+
+// StartBookingHoldReleaser cancels pay-now and deposit bookings whose payment
+// hold has run out, every minute until ctx is done.
+func StartBookingHoldReleaser(ctx context.Context, bookings *usecase.BookingUseCase) {
+	go func() {
+		ticker := time.NewTicker(holdReleaseInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runBookingHoldReleaser(ctx, bookings)
+			}
+		}
+	}()
+}
+
+func runBookingHoldReleaser(ctx context.Context, bookings *usecase.BookingUseCase) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("booking hold releaser panicked: %v", r)
+		}
+	}()
+	released, err := bookings.ReleaseExpiredHolds(ctx)
+	if err != nil {
+		log.Printf("booking hold releaser failed: %v", err)
+		return
+	}
+	if released > 0 {
+		log.Printf("booking hold releaser cancelled %d unpaid bookings", released)
+	}
+}
@@ -0,0 +1,43 @@
+package job
+
+import (
+	"context"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/repository"
+	"log"
+	"time"
+)
+
+// StartIdempotencyKeyCleaner deletes expired idempotency keys every hour
+// until ctx is done. Expired keys are already ignored when reused; this only
+// keeps the table small.
+func StartIdempotencyKeyCleaner(ctx context.Context, keys repository.IdempotencyRepository) {
+	go func() {
+		ticker := time.NewTicker(constant.IdempotencyCleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runIdempotencyKeyCleaner(ctx, keys)
+			}
+		}
+	}()
+}
+
+func runIdempotencyKeyCleaner(ctx context.Context, keys repository.IdempotencyRepository) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("idempotency key cleaner panicked: %v", r)
+		}
+	}()
+	deleted, err := keys.DeleteExpiredIdempotencyKeys(ctx, time.Now())
+	if err != nil {
+		log.Printf("idempotency key cleaner failed: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("idempotency key cleaner deleted %d expired keys", deleted)
+	}
+}
@@ -0,0 +1,66 @@
+// Package job runs background work next to the HTTP server.
+package job
+
+import (
+	"context"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/usecase"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartPaymentReconciler reconciles stale pending payments every
+// PAYMENT_RECONCILE_INTERVAL_MINUTES until ctx is done. An interval of 0
+// turns the job off.
+func StartPaymentReconciler(ctx context.Context, reconciler *usecase.PaymentReconcileUseCase) {
+	interval := reconcileInterval()
+	if interval == 0 {
+		log.Println("payment reconciler disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runPaymentReconciler(ctx, reconciler)
+			}
+		}
+	}()
+}
+
+func runPaymentReconciler(ctx context.Context, reconciler *usecase.PaymentReconcileUseCase) {
+	// A panic in one run must not take the server down with it.
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("payment reconciler panicked: %v", r)
+		}
+	}()
+	run, err := reconciler.Reconcile(ctx)
+	if err != nil {
+		log.Printf("payment reconciler failed: %v", err)
+		return
+	}
+	if run.Checked > 0 {
+		log.Printf("payment reconciler checked %d payments: %d settled, %d failed, %d expired, %d errors",
+			run.Checked, run.Settled, run.Failed, run.Expired, run.Errors)
+	}
+}
+
+func reconcileInterval() time.Duration {
+	minutes := constant.DefaultReconcileIntervalMinutes
+	if value := strings.TrimSpace(os.Getenv("PAYMENT_RECONCILE_INTERVAL_MINUTES")); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			minutes = parsed
+		} else {
+			log.Printf("invalid PAYMENT_RECONCILE_INTERVAL_MINUTES %q, using %d", value, minutes)
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
@@ -0,0 +1,48 @@
+package job
+
+import (
+	"context"
+	"hotel-management/internal/usecase/admin_usecase"
+	"log"
+	"time"
+)
+
+// StartRefundReconciler settles refunds whose outcome the gateway did not
+// give when they were made, on the payment reconciler's interval until ctx
+// is done.
+func StartRefundReconciler(ctx context.Context, refunds *admin_usecase.RefundUseCase) {
+	interval := reconcileInterval()
+	if interval == 0 {
+		log.Println("refund reconciler disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runRefundReconciler(ctx, refunds)
+			}
+		}
+	}()
+}
+
+func runRefundReconciler(ctx context.Context, refunds *admin_usecase.RefundUseCase) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("refund reconciler panicked: %v", r)
+		}
+	}()
+	run, err := refunds.SettlePendingRefunds(ctx)
+	if err != nil {
+		log.Printf("refund reconciler failed: %v", err)
+		return
+	}
+	if run.Checked > 0 {
+		log.Printf("refund reconciler checked %d refunds: %d settled, %d failed, %d still pending, %d errors",
+			run.Checked, run.Settled, run.Failed, run.Pending, run.Errors)
+	}
+}
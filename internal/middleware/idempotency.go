@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/utils"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordingWriter passes the response through while keeping a copy of the
+// body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes requests sent with an Idempotency-Key header safe to
+// retry. The first response is stored for the user and key, and a retry
+// gets it back without running the handler again. Reusing a key for a
+// different request is rejected with 422, and a retry arriving while the
+// first request is still running gets 409. Server errors are not stored, so
+// those requests can be retried. It must run after RequireAuth.
+func Idempotency(keys repository.IdempotencyRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(constant.IdempotencyKeyHeader))
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > constant.MaxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_idempotency_key")})
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// The response is stored even when the client hung up meanwhile,
+		// since that client is the one about to retry.
+		ctx := context.WithoutCancel(c.Request.Context())
+		record := &models.IdempotencyKey{
+			UserID:      c.GetUint("userID"),
+			Key:         key,
+			RequestHash: requestHash(c.Request.Method, c.Request.URL.RequestURI(), body),
+			ExpiresAt:   time.Now().Add(constant.IdempotencyKeyTTL),
+		}
+		if !claimIdempotencyKey(c, keys, record) {
+			return
+		}
+
+		original := c.Writer
+		writer := &recordingWriter{ResponseWriter: original}
+		c.Writer = writer
+		completed := false
+		defer func() {
+			c.Writer = original
+			// A handler that panicked or failed on the server side leaves the
+			// key free for a retry.
+			if !completed {
+				if err := keys.DeleteIdempotencyKey(ctx, record.ID); err != nil {
+					log.Printf("release idempotency key %d: %v", record.ID, err)
+				}
+			}
+		}()
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		record.StatusCode = writer.Status()
+		record.ContentType = writer.Header().Get("Content-Type")
+		record.ResponseBody = writer.body.String()
+		if err := keys.SaveIdempotencyResponse(ctx, record); err != nil {
+			log.Printf("save idempotent response %d: %v", record.ID, err)
+			return
+		}
+		completed = true
+	}
+}
+
+// claimIdempotencyKey stores the key for the request about to run. When the
+// user already used the key, it answers the request itself and returns
+// false.
+func claimIdempotencyKey(c *gin.Context, keys repository.IdempotencyRepository, record *models.IdempotencyKey) bool {
+	ctx := c.Request.Context()
+	err := keys.CreateIdempotencyKey(ctx, record)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		var existing *models.IdempotencyKey
+		existing, err = keys.GetIdempotencyKey(ctx, record.UserID, record.Key)
+		if err == nil && existing.ExpiresAt.Before(time.Now()) {
+			// An expired key is forgotten and claimed again.
+			if err = keys.DeleteIdempotencyKey(ctx, existing.ID); err == nil {
+				err = keys.CreateIdempotencyKey(ctx, record)
+			}
+			existing = nil
+		}
+		if err == nil && existing != nil {
+			replayIdempotentResponse(c, existing, record.RequestHash)
+			return false
+		}
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": utils.T(c, "error.idempotency_request_in_progress")})
+		return false
+	}
+	if err != nil {
+		log.Printf("claim idempotency key: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": utils.T(c, "error.failed_to_save_idempotency_key")})
+		return false
+	}
+	return true
+}
+
+func replayIdempotentResponse(c *gin.Context, existing *models.IdempotencyKey, hash string) {
+	switch {
+	case existing.RequestHash != hash:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": utils.T(c, "error.idempotency_key_reused")})
+	case existing.StatusCode == 0:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": utils.T(c, "error.idempotency_request_in_progress")})
+	default:
+		c.Header(constant.IdempotentReplayedHeader, "true")
+		c.Data(existing.StatusCode, existing.ContentType, []byte(existing.ResponseBody))
+		c.Abort()
+	}
+}
+
+func requestHash(method, uri string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + uri + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"hotel-management/internal/constant"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/testutil"
+	"hotel-management/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const testUserID = 7
+
+// idempotentRoutes serves idempotent routes whose handlers count how many
+// times they ran.
+type idempotentRoutes struct {
+	db     *gorm.DB
+	router *gin.Engine
+	calls  atomic.Int32
+	// failures is how many requests /flaky answers with a server error
+	// before it succeeds.
+	failures atomic.Int32
+	// entered and release hold requests to /slow until the test lets them
+	// finish.
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newIdempotentRoutes(t *testing.T) *idempotentRoutes {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	utils.InitI18n()
+
+	routes := &idempotentRoutes{
+		db:      testutil.NewDB(t),
+		router:  gin.New(),
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	// Stands in for RequireAuth, which the middleware runs after.
+	authenticated := func(c *gin.Context) {
+		c.Set("userID", uint(testUserID))
+		c.Next()
+	}
+	idempotent := Idempotency(repository.NewIdempotencyRepository(routes.db))
+	routes.router.POST("/bookings", authenticated, idempotent, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"call": routes.calls.Add(1)})
+	})
+	routes.router.POST("/flaky", authenticated, idempotent, func(c *gin.Context) {
+		call := routes.calls.Add(1)
+		if routes.failures.Add(-1) >= 0 {
+			c.JSON(http.StatusBadGateway, gin.H{"call": call})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": call})
+	})
+	routes.router.POST("/slow", authenticated, idempotent, func(c *gin.Context) {
+		call := routes.calls.Add(1)
+		routes.entered <- struct{}{}
+		<-routes.release
+		c.JSON(http.StatusCreated, gin.H{"call": call})
+	})
+	return routes
+}
+
+func (r *idempotentRoutes) post(path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(constant.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.router.ServeHTTP(w, req)
+	return w
+}
+
+func wantCall(t *testing.T, w *httptest.ResponseRecorder, status int, call int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d (%s), want %d", w.Code, w.Body.String(), status)
+	}
+	if want := `{"call":` + strconv.Itoa(call) + `}`; w.Body.String() != want {
+		t.Errorf("body = %s, want %s", w.Body.String(), want)
+	}
+}
+
+func TestIdempotencyReplaysSameRequest(t *testing.T) {
+	routes := newIdempotentRoutes(t)
+
+	first := routes.post("/bookings", "key-1", `{"room_id":1}`)
+	wantCall(t, first, http.StatusCreated, 1)
+	if first.Header().Get(constant.IdempotentReplayedHeader) != "" {
+		t.Error("first response is marked as replayed")
+	}
+
+	retry := routes.post("/bookings", "key-1", `{"room_id":1}`)
+	wantCall(t, retry, http.StatusCreated, 1)
+	if retry.Header().Get(constant.IdempotentReplayedHeader) != "true" {
+		t.Error("retry is not marked as replayed")
+	}
+	if got := retry.Header().Get("Content-Type"); got != first.Header().Get("Content-Type") {
+		t.Errorf("replayed Content-Type = %q, want %q", got, first.Header().Get("Content-Type"))
+	}
+	if got := routes.calls.Load(); got != 1 {
+		t.Errorf("handler ran %d times, want 1", got)
+	}
+
+	// Requests without a key, or with another key, run every time.
+	wantCall(t, routes.post("/bookings", "", `{"room_id":1}`), http.StatusCreated, 2)
+	wantCall(t, routes.post("/bookings", "key-2", `{"room_id":1}`), http.StatusCreated, 3)
+}
+
+func TestIdempotencyRejectsKeyReusedForAnotherRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{name: "different body", path: "/bookings", body: `{"room_id":2}`},
+		{name: "different URL", path: "/bookings?room_id=1", body: `{"room_id":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes := newIdempotentRoutes(t)
+			wantCall(t, routes.post("/bookings", "key-1", `{"room_id":1}`), http.StatusCreated, 1)
+
+			w := routes.post(tt.path, "key-1", tt.body)
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+			}
+			if got := routes.calls.Load(); got != 1 {
+				t.Errorf("handler ran %d times, want 1", got)
+			}
+		})
+	}
+}
+
+func TestIdempotencyRejectsRetryWhileInFlight(t *testing.T) {
+	routes := newIdempotentRoutes(t)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- routes.post("/slow", "key-1", `{}`) }()
+	<-routes.entered
+
+	retry := routes.post("/slow", "key-1", `{}`)
+	if retry.Code != http.StatusConflict {
+		t.Errorf("retry while in flight: status = %d, want %d", retry.Code, http.StatusConflict)
+	}
+
+	close(routes.release)
+	wantCall(t, <-done, http.StatusCreated, 1)
+	// Once the first request finished, the retry gets its response.
+	wantCall(t, routes.post("/slow", "key-1", `{}`), http.StatusCreated, 1)
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	routes := newIdempotentRoutes(t)
+	routes.failures.Store(1)
+
+	wantCall(t, routes.post("/flaky", "key-1", `{}`), http.StatusBadGateway, 1)
+	var stored int64
+	routes.db.Model(&models.IdempotencyKey{}).Count(&stored)
+	if stored != 0 {
+		t.Errorf("%d keys kept after a server error, want 0", stored)
+	}
+
+	// The retry runs the handler again, and its answer is the one kept.
+	wantCall(t, routes.post("/flaky", "key-1", `{}`), http.StatusCreated, 2)
+	wantCall(t, routes.post("/flaky", "key-1", `{}`), http.StatusCreated, 2)
+}
+
+func TestIdempotencyReclaimsExpiredKey(t *testing.T) {
+	routes := newIdempotentRoutes(t)
+	wantCall(t, routes.post("/bookings", "key-1", `{"room_id":1}`), http.StatusCreated, 1)
+
+	routes.db.Model(&models.IdempotencyKey{}).Where("idempotency_key = ?", "key-1").
+		Update("expires_at", time.Now().Add(-time.Minute))
+
+	// An expired key runs the request again, even for another body.
+	reclaimed := routes.post("/bookings", "key-1", `{"room_id":2}`)
+	wantCall(t, reclaimed, http.StatusCreated, 2)
+	if reclaimed.Header().Get(constant.IdempotentReplayedHeader) != "" {
+		t.Error("response to a reclaimed key is marked as replayed")
+	}
+	var key models.IdempotencyKey
+	if err := routes.db.Where("idempotency_key = ?", "key-1").First(&key).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !key.ExpiresAt.After(time.Now()) || key.UserID != testUserID {
+		t.Errorf("reclaimed key = %+v, want it kept for user %d until a new expiry", key, testUserID)
+	}
+	wantCall(t, routes.post("/bookings", "key-1", `{"room_id":2}`), http.StatusCreated, 2)
+}
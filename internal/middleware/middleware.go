@@ -4,6 +4,7 @@ import (
 	"hotel-management/internal/constant"
 	"net/http"
 
+	"hotel-management/internal/models"
 	"hotel-management/internal/repository"
 	"hotel-management/internal/utils"
 	"strings"
@@ -56,51 +57,86 @@ func RequireRoles(allowedRoles ...string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// bearerUser resolves the account behind the request's bearer token. On
+// failure it has already written the 401 and aborted the request.
+func bearerUser(c *gin.Context, userRepo repository.UserRepository) (*models.User, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.missing_token")})
+		c.Abort()
+		return nil, false
+	}
+	tokenParts := strings.Split(authHeader, " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.invalid_token")})
+		c.Abort()
+		return nil, false
+	}
+
+	claims, err := utils.ValidateToken(tokenParts[1])
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.invalid_token")})
+		c.Abort()
+		return nil, false
+	}
+
+	user, err := userRepo.GetUserByEmail(c.Request.Context(), claims.Email)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.get_user_failed")})
+		c.Abort()
+		return nil, false
+	}
+	return user, true
+}
+
+func setAuthenticatedUser(c *gin.Context, user *models.User) {
+	c.Set("userEmail", user.Email)
+	c.Set("userID", user.ID)
+	c.Set("userName", user.Name)
+	c.Set("userRole", user.Role)
+}
+
 func RequireAuth(userRepo repository.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "error.missing_token"})
-			c.Abort()
-			return
-		}
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "error.invalid_token"})
-			c.Abort()
+		user, ok := bearerUser(c, userRepo)
+		if !ok {
 			return
 		}
-		tokenStr := tokenParts[1]
 
-		claims, err := utils.ValidateToken(tokenStr)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.invalid_token")})
+		if user.Role != "customer" {
+			c.JSON(http.StatusForbidden, gin.H{"error": utils.T(c, "error.access_restricted_to_customers_only")})
 			c.Abort()
 			return
 		}
 
-		user, err := userRepo.GetUserByEmail(c.Request.Context(), claims.Email)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": utils.T(c, "error.get_user_failed")})
+		if !user.IsActive {
+			c.JSON(http.StatusForbidden, gin.H{"error": utils.T(c, "error.account_is_not_activated")})
 			c.Abort()
 			return
 		}
 
-		if user.Role != "customer" {
-			c.JSON(http.StatusForbidden, gin.H{"error": utils.T(c, "error.access_restricted_to_customers_only")})
-			c.Abort()
+		setAuthenticatedUser(c, user)
+		c.Next()
+	}
+}
+
+// RequireStaffAuth authenticates API requests made with a bearer token and
+// only lets staff and admin accounts through.
+func RequireStaffAuth(userRepo repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := bearerUser(c, userRepo)
+		if !ok {
 			return
 		}
 
-		if !user.IsActive {
-			c.JSON(http.StatusForbidden, gin.H{"error": utils.T(c, "error.account_is_not_activated")})
+		if user.Role != constant.STAFF && user.Role != constant.ADMIN {
+			c.JSON(http.StatusForbidden, gin.H{"error": utils.T(c, "error.access_restricted_to_staff_only")})
 			c.Abort()
 			return
 		}
 
-		c.Set("userEmail", user.Email)
-		c.Set("userID", user.ID)
-		c.Set("userName", user.Name)
+		setAuthenticatedUser(c, user)
 		c.Next()
 	}
 }
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"errors"
+	"hotel-management/internal/constant"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/usecase/admin_usecase"
+	"hotel-management/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// PropertyScope resolves the property the signed-in admin or staff member is
+// working on and stores it under "property_id". Staff are limited to the
+// properties assigned to them; admins may also pick all properties (0).
+func PropertyScope(propertyUseCase *admin_usecase.PropertyUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := sessions.Default(c)
+		userID, _ := session.Get("user_id").(uint)
+		role, _ := session.Get("user_role").(string)
+		requested, ok := session.Get("property_id").(uint)
+		if !ok {
+			requested = constant.AllProperties
+		}
+
+		propertyID, err := propertyUseCase.ResolveCurrentProperty(c.Request.Context(), userID, role, requested)
+		if err != nil {
+			c.HTML(http.StatusForbidden, "error.html", gin.H{"error": utils.T(c, err.Error()),
+				"T":     utils.TmplTranslateFromContext(c),
+				"Title": "title.error"})
+			c.Abort()
+			return
+		}
+		if !ok || propertyID != requested {
+			session.Set("property_id", propertyID)
+			_ = session.Save()
+		}
+		c.Set("property_id", propertyID)
+		c.Next()
+	}
+}
+
+// StaffPropertyScope is PropertyScope for the bearer token staff API. It runs
+// after RequireStaffAuth and stores the property the caller works in under
+// "property_id": the one asked for with the property_id query parameter when
+// it is assigned to them, otherwise their first assigned property. Only
+// admins work across all properties.
+func StaffPropertyScope(propertyUseCase *admin_usecase.PropertyUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested := uint64(constant.AllProperties)
+		if raw := c.Query("property_id"); raw != "" {
+			var err error
+			requested, err = strconv.ParseUint(raw, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": utils.T(c, "error.invalid_request")})
+				c.Abort()
+				return
+			}
+		}
+
+		propertyID, err := propertyUseCase.ResolveCurrentProperty(c.Request.Context(), c.GetUint("userID"), c.GetString("userRole"), uint(requested))
+		if err == nil && requested != uint64(constant.AllProperties) && propertyID != uint(requested) {
+			err = appError.ErrPropertyAccessDenied
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, appError.ErrPropertyAccessDenied) || errors.Is(err, appError.ErrNoPropertyAssigned) {
+				status = http.StatusForbidden
+			}
+			c.JSON(status, gin.H{"error": utils.T(c, err.Error())})
+			c.Abort()
+			return
+		}
+		c.Set("property_id", propertyID)
+		c.Next()
+	}
+}
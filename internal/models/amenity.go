@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+type Amenity struct {
+	gorm.Model
+	Code     string `gorm:"type:varchar(50);uniqueIndex;not null" json:"code" binding:"required"`
+	Name     string `gorm:"type:varchar(100);not null" json:"name" binding:"required"`
+	Icon     string `gorm:"type:varchar(100)" json:"icon"`
+	Category string `gorm:"type:varchar(50);not null" json:"category" binding:"required"`
+
+	Translations []AmenityTranslation `gorm:"foreignKey:AmenityID" json:"translations,omitempty"`
+	Rooms        []Room               `gorm:"many2many:room_amenities;" json:"rooms,omitempty"`
+}
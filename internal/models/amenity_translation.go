@@ -0,0 +1,10 @@
+package models
+
+import "gorm.io/gorm"
+
+type AmenityTranslation struct {
+	gorm.Model
+	AmenityID uint   `gorm:"not null;uniqueIndex:idx_amenity_lang" json:"amenity_id"`
+	Lang      string `gorm:"type:varchar(10);not null;uniqueIndex:idx_amenity_lang" json:"lang"`
+	Name      string `gorm:"type:varchar(100);not null" json:"name"`
+}
@@ -11,6 +11,16 @@ type Bill struct {
 	BookingID   uint      `gorm:"not null" json:"booking_id"`
 	TotalAmount float64   `gorm:"not null" json:"total_amount"`
 	ExportAt    time.Time `gorm:"type:timestamp;not null" json:"export_at" binding:"required"`
+	// RefundedAmount is the total of the successful refunds on the booking.
+	RefundedAmount float64 `gorm:"not null;default:0" json:"refunded_amount"`
+	// PayerName is who the bill is made out to when a booking is split
+	// between several payers.
+	PayerName string `gorm:"type:varchar(100);not null;default:''" json:"payer_name"`
 
 	Booking Booking `gorm:"foreignKey:BookingID" json:"booking,omitempty"`
 }
+
+// NetAmount is what the hotel keeps after refunds.
+func (b Bill) NetAmount() float64 {
+	return b.TotalAmount - b.RefundedAmount
+}
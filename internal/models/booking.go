@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,13 +10,55 @@ import (
 type Booking struct {
 	gorm.Model
 	UserID        uint      `gorm:"not null" json:"user_id"`
-	BookingStatus string    `gorm:"default:'booked'" json:"booking_status" binding:"required,oneof=booked cancelled checked_in checked_out no_show"`
+	BookingStatus string    `gorm:"default:'booked'" json:"booking_status" binding:"required,oneof=booked cancelled checked_in checked_out no_show pending_payment"`
 	TotalPrice    float64   `gorm:"not null" json:"total_price"`
 	IsPaid        bool      `gorm:"not null" json:"is_paid"`
 	StartDate     time.Time `gorm:"type:datetime;not null" json:"start_date"`
 	EndDate       time.Time `gorm:"type:datetime;not null" json:"end_date"`
+	PropertyID    *uint     `gorm:"index" json:"property_id"`
+	RatePlanID    *uint     `gorm:"index" json:"rate_plan_id"`
+	// PaymentTiming is copied from the rate plan, so later changes to the
+	// plan do not affect the booking.
+	PaymentTiming string  `gorm:"type:varchar(20);not null;default:'pay_at_hotel'" json:"payment_timing"`
+	DepositAmount float64 `gorm:"not null;default:0" json:"deposit_amount"`
+	// HoldExpiresAt is when a pending_payment booking gives up its rooms.
+	HoldExpiresAt *time.Time `gorm:"type:datetime" json:"hold_expires_at"`
+	// PaidAmount is the total of the booking's successful payments, before
+	// refunds. Refunds give money back without reopening the balance.
+	PaidAmount float64 `gorm:"not null;default:0" json:"paid_amount"`
+	// RefundedAmount is the total of the successful refunds on the
+	// booking's payments.
+	RefundedAmount float64 `gorm:"not null;default:0" json:"refunded_amount"`
 
-	BookingRooms []BookingRoom `gorm:"foreignKey:BookingID" json:"booking_rooms,omitempty"`
-	Reviews      []Review      `gorm:"foreignKey:BookingID" json:"reviews,omitempty"`
-	User         User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	BookingRooms []BookingRoom   `gorm:"foreignKey:BookingID" json:"booking_rooms,omitempty"`
+	Charges      []BookingCharge `gorm:"foreignKey:BookingID" json:"charges,omitempty"`
+	Reviews      []Review        `gorm:"foreignKey:BookingID" json:"reviews,omitempty"`
+	User         User            `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	RatePlan     *RatePlan       `gorm:"foreignKey:RatePlanID" json:"rate_plan,omitempty"`
+}
+
+// Balance is what is left to pay on the booking.
+func (b Booking) Balance() float64 {
+	return b.TotalPrice - b.PaidAmount
+}
+
+// NetPaid is what the hotel keeps of the booking's payments.
+func (b Booking) NetPaid() float64 {
+	return b.PaidAmount - b.RefundedAmount
+}
+
+// Overpayment is what was paid beyond the booking's total and not refunded
+// yet, such as a payment that succeeded after the guest had paid again.
+func (b Booking) Overpayment() float64 {
+	return math.Max(0, b.NetPaid()-b.TotalPrice)
+}
+
+// Nights is how many nights the booking's rooms are charged for.
+func (b Booking) Nights() int {
+	return int(math.Ceil(b.EndDate.Sub(b.StartDate).Hours() / 24))
+}
+
+// RoomAmount is what one booked room costs over the whole stay.
+func (b Booking) RoomAmount(room BookingRoom) float64 {
+	return room.Price * float64(b.Nights())
 }
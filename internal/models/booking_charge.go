@@ -0,0 +1,13 @@
+package models
+
+import "gorm.io/gorm"
+
+// BookingCharge is an extra billed on a booking besides its rooms, such as
+// minibar or laundry. Charges are added to the booking's total price.
+type BookingCharge struct {
+	gorm.Model
+	BookingID   uint    `gorm:"not null;index" json:"booking_id"`
+	Description string  `gorm:"type:varchar(255);not null" json:"description"`
+	Amount      float64 `gorm:"not null" json:"amount"`
+	CreatedBy   uint    `gorm:"not null" json:"created_by"`
+}
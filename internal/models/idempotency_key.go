@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// IdempotencyKey is the response to a request sent with an Idempotency-Key
+// header, kept so that a retry with the same key gets it back instead of
+// repeating the request. Keys are per user. StatusCode stays 0 while the
+// first request is still running.
+type IdempotencyKey struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	UserID uint   `gorm:"not null;uniqueIndex:idx_idempotency_user_key" json:"user_id"`
+	Key    string `gorm:"column:idempotency_key;type:varchar(255);not null;uniqueIndex:idx_idempotency_user_key" json:"key"`
+	// RequestHash covers the method, the URL and the body, so that a key
+	// reused for another request is caught.
+	RequestHash  string    `gorm:"type:char(64);not null" json:"request_hash"`
+	StatusCode   int       `gorm:"not null;default:0" json:"status_code"`
+	ContentType  string    `gorm:"type:varchar(100);not null;default:''" json:"content_type"`
+	ResponseBody string    `gorm:"type:mediumtext" json:"response_body"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`
+}
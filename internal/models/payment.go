@@ -8,12 +8,31 @@ import (
 
 type Payment struct {
 	gorm.Model
-	BookingID     uint      `gorm:"not null" json:"booking_id"`
-	TransactionID string    `gorm:"type:varchar(100);not null;" json:"transaction_id"`
-	PaymentMethod string    `gorm:"type:varchar(50);not null" json:"payment_method" binding:"required"`
-	PaymentStatus string    `gorm:"type:varchar(50);not null" json:"payment_status" binding:"required,oneof=success pending failed"`
+	BookingID     uint   `gorm:"not null" json:"booking_id"`
+	TransactionID string `gorm:"type:varchar(100);not null;" json:"transaction_id"`
+	PaymentMethod string `gorm:"type:varchar(50);not null" json:"payment_method" binding:"required"`
+	// Amount is what the customer was asked to pay, checked against the
+	// amount the gateway reports.
+	Amount        float64   `gorm:"not null;default:0" json:"amount"`
+	PaymentStatus string    `gorm:"type:varchar(50);not null" json:"payment_status" binding:"required,oneof=success pending failed expired"`
 	PaidAt        time.Time `gorm:"type:timestamp;not null" json:"paid_at" binding:"required"`
 	TxnRef        string    `gorm:"type:varchar(100);not null" json:"txn_ref"`
+	// PayerName tells apart the people or companies sharing a booking's
+	// bill. Each payer gets a bill of their own.
+	PayerName string `gorm:"type:varchar(100);not null;default:''" json:"payer_name"`
+	// BillID is the bill the payment was invoiced on, once the booking has
+	// been paid in full.
+	BillID *uint `gorm:"index" json:"bill_id"`
+	// ReconcileCheckedAt is when the reconciler last looked the payment up.
+	// Payments it has not looked at for longest go first, so payments whose
+	// lookup keeps failing do not hold back the others.
+	ReconcileCheckedAt *time.Time `gorm:"type:timestamp;index" json:"reconcile_checked_at"`
+	// ReconcileErrors counts the lookups in a row that failed. Once it
+	// reaches constant.MaxReconcileErrors the reconciler gives up and the
+	// payment is listed as stuck on the admin payments page.
+	ReconcileErrors int `gorm:"not null;default:0" json:"reconcile_errors"`
 
-	Booking Booking `gorm:"foreignKey:BookingID" json:"booking,omitempty"`
+	Booking     Booking             `gorm:"foreignKey:BookingID" json:"booking,omitempty"`
+	Refunds     []Refund            `gorm:"foreignKey:PaymentID" json:"refunds,omitempty"`
+	Allocations []PaymentAllocation `gorm:"foreignKey:PaymentID" json:"allocations,omitempty"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+// PaymentAllocation is the part of a payment that settles one line item of a
+// booking, either a booked room or a charge. Payments made for an amount
+// rather than for line items have no allocations.
+type PaymentAllocation struct {
+	gorm.Model
+	PaymentID       uint    `gorm:"not null;index" json:"payment_id"`
+	BookingRoomID   *uint   `gorm:"index" json:"booking_room_id,omitempty"`
+	BookingChargeID *uint   `gorm:"index" json:"booking_charge_id,omitempty"`
+	Amount          float64 `gorm:"not null" json:"amount"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "gorm.io/gorm"
+
+// PaymentCallback is a notification received about a payment, kept as it
+// arrived so that disputes can be investigated. Callbacks are linked to their
+// payment by transaction reference.
+type PaymentCallback struct {
+	gorm.Model
+	TxnRef        string `gorm:"type:varchar(100);not null;index" json:"txn_ref"`
+	PaymentMethod string `gorm:"type:varchar(50);not null" json:"payment_method"`
+	// Payload is the query string of the callback as received.
+	Payload string `gorm:"type:text;not null" json:"payload"`
+	// Outcome is "ok" or the error key handling the callback returned.
+	Outcome string `gorm:"type:varchar(100);not null" json:"outcome"`
+}
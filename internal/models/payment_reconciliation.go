@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentReconciliation is one run of the payment reconciler, which checks
+// payments left pending with their gateway.
+type PaymentReconciliation struct {
+	gorm.Model
+	StartedAt  time.Time `gorm:"type:timestamp;not null" json:"started_at"`
+	FinishedAt time.Time `gorm:"type:timestamp;not null" json:"finished_at"`
+	Checked    int       `gorm:"not null;default:0" json:"checked"`
+	Settled    int       `gorm:"not null;default:0" json:"settled"`
+	Failed     int       `gorm:"not null;default:0" json:"failed"`
+	Expired    int       `gorm:"not null;default:0" json:"expired"`
+	Errors     int       `gorm:"not null;default:0" json:"errors"`
+
+	Entries []PaymentReconciliationEntry `gorm:"foreignKey:ReconciliationID" json:"entries,omitempty"`
+}
+
+// PaymentReconciliationEntry is what a run did with one payment.
+type PaymentReconciliationEntry struct {
+	gorm.Model
+	ReconciliationID uint   `gorm:"not null;index" json:"reconciliation_id"`
+	PaymentID        uint   `gorm:"not null;index" json:"payment_id"`
+	TxnRef           string `gorm:"type:varchar(100);not null" json:"txn_ref"`
+	PaymentMethod    string `gorm:"type:varchar(50);not null" json:"payment_method"`
+	Outcome          string `gorm:"type:varchar(50);not null" json:"outcome"`
+	Detail           string `gorm:"type:varchar(255)" json:"detail"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "gorm.io/gorm"
+
+// Property is one hotel of the chain. Rooms, and through them bookings, belong
+// to a property; staff accounts are assigned to the properties they work at.
+type Property struct {
+	gorm.Model
+	Code     string `gorm:"type:varchar(30);uniqueIndex;not null" json:"code"`
+	Name     string `gorm:"type:varchar(150);not null" json:"name"`
+	City     string `gorm:"type:varchar(100);index;not null" json:"city"`
+	Address  string `gorm:"type:varchar(255)" json:"address"`
+	Phone    string `gorm:"type:varchar(20)" json:"phone"`
+	IsActive bool   `gorm:"default:true" json:"is_active"`
+
+	Rooms []Room `gorm:"foreignKey:PropertyID" json:"rooms,omitempty"`
+	Staff []User `gorm:"many2many:property_staff;" json:"staff,omitempty"`
+}
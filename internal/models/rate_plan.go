@@ -0,0 +1,19 @@
+package models
+
+import "gorm.io/gorm"
+
+// RatePlan is a way of selling a property's rooms. For now it only decides
+// when guests pay.
+type RatePlan struct {
+	gorm.Model
+	PropertyID    uint   `gorm:"not null;index" json:"property_id"`
+	Name          string `gorm:"type:varchar(100);not null" json:"name"`
+	Description   string `gorm:"type:varchar(500)" json:"description"`
+	PaymentTiming string `gorm:"type:varchar(20);not null;default:'pay_at_hotel'" json:"payment_timing"`
+	// DepositPercent is the share of the price paid when booking, for
+	// deposit plans only.
+	DepositPercent int  `gorm:"not null;default:0" json:"deposit_percent"`
+	IsActive       bool `gorm:"not null;default:true" json:"is_active"`
+
+	Property Property `gorm:"foreignKey:PropertyID" json:"-"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Refund is money given back on a payment, in full or in part. It stays
+// pending while the provider processes it.
+type Refund struct {
+	gorm.Model
+	PaymentID uint    `gorm:"not null;index" json:"payment_id"`
+	Amount    float64 `gorm:"not null" json:"amount"`
+	Reason    string  `gorm:"type:varchar(500);not null" json:"reason"`
+	Status    string  `gorm:"type:varchar(50);not null" json:"status"`
+	// ProviderRef is the provider's ID for the refund, empty for refunds
+	// handed back at the desk.
+	ProviderRef string     `gorm:"type:varchar(100)" json:"provider_ref"`
+	CreatedBy   uint       `gorm:"not null" json:"created_by"`
+	ProcessedAt *time.Time `gorm:"type:timestamp" json:"processed_at"`
+
+	Payment Payment `gorm:"foreignKey:PaymentID" json:"-"`
+}
@@ -1,16 +1,63 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Review struct {
 	gorm.Model
-	UserID    uint   `gorm:"not null" json:"user_id"`
-	BookingID uint   `gorm:"not null" json:"booking_id"`
-	RoomID    uint   `gorm:"not null" json:"room_id"`
+	UserID uint `gorm:"not null" json:"user_id"`
+	// A booking gets one review per room it covers.
+	BookingID uint   `gorm:"not null;uniqueIndex:idx_reviews_booking_room" json:"booking_id"`
+	RoomID    uint   `gorm:"not null;uniqueIndex:idx_reviews_booking_room;index" json:"room_id"`
 	Rating    int    `gorm:"not null" json:"rating" binding:"required,min=1,max=5"`
 	Comment   string `gorm:"type:text" json:"comment"`
+	// Sub-scores from 1 to 5. Reviews written before they existed have
+	// zeros, which the aggregates skip.
+	CleanlinessRating int `gorm:"not null;default:0" json:"cleanliness_rating"`
+	ServiceRating     int `gorm:"not null;default:0" json:"service_rating"`
+	LocationRating    int `gorm:"not null;default:0" json:"location_rating"`
+	ValueRating       int `gorm:"not null;default:0" json:"value_rating"`
+	ComfortRating     int `gorm:"not null;default:0" json:"comfort_rating"`
+	// Status is the moderation state; only hidden reviews are kept out of
+	// public listings and rating aggregates.
+	Status           string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ModerationReason string     `gorm:"type:varchar(500)" json:"moderation_reason"`
+	ModeratedByID    *uint      `json:"moderated_by_id"`
+	ModeratedAt      *time.Time `json:"moderated_at"`
+	// ManagementResponse is the public reply from the hotel.
+	ManagementResponse string     `gorm:"type:text" json:"management_response"`
+	RespondedByID      *uint      `json:"responded_by_id"`
+	RespondedAt        *time.Time `json:"responded_at"`
+
+	User        User             `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Booking     Booking          `gorm:"foreignKey:BookingID" json:"booking,omitempty"`
+	Room        Room             `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	ModeratedBy *User            `gorm:"foreignKey:ModeratedByID" json:"moderated_by,omitempty"`
+	RespondedBy *User            `gorm:"foreignKey:RespondedByID" json:"responded_by,omitempty"`
+	Photos      []ReviewPhoto    `gorm:"foreignKey:ReviewID" json:"photos"`
+	Revisions   []ReviewRevision `gorm:"foreignKey:ReviewID" json:"revisions,omitempty"`
+}
+
+// NewRevision snapshots the review's current content.
+func (r Review) NewRevision(action string) ReviewRevision {
+	return ReviewRevision{
+		ReviewID:          r.ID,
+		Action:            action,
+		Rating:            r.Rating,
+		Comment:           r.Comment,
+		CleanlinessRating: r.CleanlinessRating,
+		ServiceRating:     r.ServiceRating,
+		LocationRating:    r.LocationRating,
+		ValueRating:       r.ValueRating,
+		ComfortRating:     r.ComfortRating,
+	}
+}
 
-	User    User    `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Booking Booking `gorm:"foreignKey:BookingID" json:"booking,omitempty"`
-	Room    Room    `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+// HasCriteriaRatings reports whether the review has sub-scores; reviews
+// written before they were introduced do not.
+func (r Review) HasCriteriaRatings() bool {
+	return r.CleanlinessRating > 0
 }
@@ -0,0 +1,10 @@
+package models
+
+import "gorm.io/gorm"
+
+type ReviewPhoto struct {
+	gorm.Model
+	ReviewID     uint   `gorm:"not null;index" json:"review_id"`
+	ImageURL     string `gorm:"type:varchar(255);not null" json:"image_url"`
+	ThumbnailURL string `gorm:"type:varchar(255)" json:"thumbnail_url"`
+}
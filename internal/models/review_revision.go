@@ -0,0 +1,18 @@
+package models
+
+import "gorm.io/gorm"
+
+// ReviewRevision keeps what a review said before its author edited or
+// deleted it, so moderators can see the history.
+type ReviewRevision struct {
+	gorm.Model
+	ReviewID          uint   `gorm:"not null;index" json:"review_id"`
+	Action            string `gorm:"type:varchar(20);not null" json:"action"`
+	Rating            int    `gorm:"not null" json:"rating"`
+	Comment           string `gorm:"type:text" json:"comment"`
+	CleanlinessRating int    `gorm:"not null;default:0" json:"cleanliness_rating"`
+	ServiceRating     int    `gorm:"not null;default:0" json:"service_rating"`
+	LocationRating    int    `gorm:"not null;default:0" json:"location_rating"`
+	ValueRating       int    `gorm:"not null;default:0" json:"value_rating"`
+	ComfortRating     int    `gorm:"not null;default:0" json:"comfort_rating"`
+}
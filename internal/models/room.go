@@ -12,8 +12,24 @@ type Room struct {
 	ViewType      string  `gorm:"type:varchar(100);not null" json:"view_type" binding:"required"`
 	Description   string  `gorm:"type:text" json:"description"`
 	IsAvailable   bool    `gorm:"default:true" json:"is_available"`
+	PropertyID    *uint   `gorm:"index;uniqueIndex:idx_rooms_property_number,priority:1" json:"property_id"`
+	// Number is unique within a property, e.g. "1204". Archived rooms keep
+	// theirs so they can be restored.
+	Number   string `gorm:"type:varchar(20);not null;default:'';uniqueIndex:idx_rooms_property_number,priority:2" json:"number"`
+	Floor    int    `gorm:"not null;default:0" json:"floor"`
+	Building string `gorm:"type:varchar(50);not null;default:''" json:"building"`
 
+	Property     *Property     `gorm:"foreignKey:PropertyID" json:"property,omitempty"`
 	Images       []RoomImage   `gorm:"foreignKey:RoomID" json:"images"`
 	Reviews      []Review      `gorm:"foreignKey:RoomID" json:"reviews"`
 	BookingRooms []BookingRoom `gorm:"foreignKey:RoomID" json:"booking_rooms,omitempty"`
+	Amenities    []Amenity     `gorm:"many2many:room_amenities;" json:"amenities"`
+	// ConnectingRooms share an inner door with this room. Connections are
+	// stored in both directions.
+	ConnectingRooms []Room `gorm:"many2many:room_connections;joinForeignKey:RoomID;joinReferences:ConnectedRoomID" json:"connecting_rooms,omitempty"`
+}
+
+type RoomConnection struct {
+	RoomID          uint `gorm:"primaryKey"`
+	ConnectedRoomID uint `gorm:"primaryKey"`
 }
@@ -4,7 +4,13 @@ import "gorm.io/gorm"
 
 type RoomImage struct {
 	gorm.Model
-	RoomID   uint   `gorm:"not null" json:"room_id"`
-	ImageURL string `gorm:"type:varchar(255);not null" json:"image_url" binding:"required,url"`
-	Room Room `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	RoomID       uint   `gorm:"not null" json:"room_id"`
+	ImageURL     string `gorm:"type:varchar(255);not null" json:"image_url" binding:"required,url"`
+	ThumbnailURL string `gorm:"type:varchar(255)" json:"thumbnail_url"`
+	MediumURL    string `gorm:"type:varchar(255)" json:"medium_url"`
+	LargeURL     string `gorm:"type:varchar(255)" json:"large_url"`
+	SortOrder    int    `gorm:"not null;default:0" json:"sort_order"`
+	IsPrimary    bool   `gorm:"not null;default:false" json:"is_primary"`
+	AltText      string `gorm:"type:varchar(255)" json:"alt_text"`
+	Room         Room   `gorm:"foreignKey:RoomID" json:"room,omitempty"`
 }
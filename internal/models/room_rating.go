@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// RoomRating is the stored review aggregate of one room. It is recomputed
+// whenever a review of the room is written, so listings and search never
+// aggregate the reviews table.
+type RoomRating struct {
+	RoomID       uint    `gorm:"primaryKey;autoIncrement:false" json:"room_id"`
+	ReviewCount  int64   `gorm:"not null;default:0" json:"review_count"`
+	AvgRating    float64 `gorm:"not null;default:0;index" json:"avg_rating"`
+	Rating1Count int64   `gorm:"not null;default:0" json:"rating_1_count"`
+	Rating2Count int64   `gorm:"not null;default:0" json:"rating_2_count"`
+	Rating3Count int64   `gorm:"not null;default:0" json:"rating_3_count"`
+	Rating4Count int64   `gorm:"not null;default:0" json:"rating_4_count"`
+	Rating5Count int64   `gorm:"not null;default:0" json:"rating_5_count"`
+	// Per-criterion averages over the reviews that have sub-scores.
+	AvgCleanliness float64   `gorm:"not null;default:0" json:"avg_cleanliness"`
+	AvgService     float64   `gorm:"not null;default:0" json:"avg_service"`
+	AvgLocation    float64   `gorm:"not null;default:0" json:"avg_location"`
+	AvgValue       float64   `gorm:"not null;default:0" json:"avg_value"`
+	AvgComfort     float64   `gorm:"not null;default:0" json:"avg_comfort"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Counts returns the number of reviews per star rating, index 0 being one
+// star.
+func (r RoomRating) Counts() [5]int64 {
+	return [5]int64{r.Rating1Count, r.Rating2Count, r.Rating3Count, r.Rating4Count, r.Rating5Count}
+}
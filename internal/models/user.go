@@ -15,4 +15,6 @@ type User struct {
 	Bookings []Booking `gorm:"foreignKey:UserID" json:"bookings,omitempty"`
 	Reviews  []Review  `gorm:"foreignKey:UserID" json:"reviews,omitempty"`
 	Shifts   []Shift   `gorm:"foreignKey:StaffID" json:"shifts,omitempty"`
+	// Properties are the hotels a staff account may manage.
+	Properties []Property `gorm:"many2many:property_staff;" json:"properties,omitempty"`
 }
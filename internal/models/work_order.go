@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WorkOrder struct {
+	gorm.Model
+	RoomID         uint       `gorm:"not null;index" json:"room_id"`
+	ReporterID     uint       `gorm:"not null" json:"reporter_id"`
+	AssigneeID     *uint      `json:"assignee_id"`
+	Title          string     `gorm:"type:varchar(150);not null" json:"title" binding:"required"`
+	Description    string     `gorm:"type:text" json:"description"`
+	Category       string     `gorm:"type:varchar(30);not null" json:"category" binding:"required"`
+	Priority       string     `gorm:"type:varchar(20);not null;default:'medium'" json:"priority" binding:"required,oneof=low medium high urgent"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'open';index" json:"status" binding:"required,oneof=open assigned in_progress resolved closed cancelled"`
+	BlocksRoom     bool       `gorm:"not null;default:false" json:"blocks_room"`
+	ResolutionNote string     `gorm:"type:text" json:"resolution_note"`
+	ResolvedAt     *time.Time `gorm:"type:datetime" json:"resolved_at"`
+	ClosedAt       *time.Time `gorm:"type:datetime" json:"closed_at"`
+
+	Room     Room             `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	Reporter User             `gorm:"foreignKey:ReporterID" json:"reporter,omitempty"`
+	Assignee *User            `gorm:"foreignKey:AssigneeID" json:"assignee,omitempty"`
+	Photos   []WorkOrderPhoto `gorm:"foreignKey:WorkOrderID" json:"photos"`
+}
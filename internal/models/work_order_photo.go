@@ -0,0 +1,9 @@
+package models
+
+import "gorm.io/gorm"
+
+type WorkOrderPhoto struct {
+	gorm.Model
+	WorkOrderID uint   `gorm:"not null;index" json:"work_order_id"`
+	ImageURL    string `gorm:"type:varchar(255);not null" json:"image_url"`
+}
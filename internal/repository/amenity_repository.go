@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"hotel-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type AmenityRepository interface {
+	GetAllAmenities(ctx context.Context) ([]models.Amenity, error)
+	FindAmenityByID(ctx context.Context, id int) (*models.Amenity, error)
+	FindAmenityByCode(ctx context.Context, code string) (*models.Amenity, error)
+	FindAmenitiesByIDs(ctx context.Context, ids []uint) ([]models.Amenity, error)
+	CreateAmenityTx(ctx context.Context, tx *gorm.DB, amenity *models.Amenity) error
+	UpdateAmenityTx(ctx context.Context, tx *gorm.DB, amenity *models.Amenity) error
+	ReplaceAmenityTranslationsTx(ctx context.Context, tx *gorm.DB, amenityID uint, translations []models.AmenityTranslation) error
+	DeleteAmenityTx(ctx context.Context, tx *gorm.DB, id int) error
+	GetDB() *gorm.DB
+}
+
+type amenityRepository struct {
+	db *gorm.DB
+}
+
+func NewAmenityRepository(db *gorm.DB) AmenityRepository {
+	return &amenityRepository{db: db}
+}
+
+func (r *amenityRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *amenityRepository) GetAllAmenities(ctx context.Context) ([]models.Amenity, error) {
+	var amenities []models.Amenity
+	err := r.db.WithContext(ctx).Preload("Translations").Order("category, name").Find(&amenities).Error
+	if err != nil {
+		return nil, err
+	}
+	return amenities, nil
+}
+
+func (r *amenityRepository) FindAmenityByID(ctx context.Context, id int) (*models.Amenity, error) {
+	var amenity models.Amenity
+	err := r.db.WithContext(ctx).Preload("Translations").First(&amenity, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &amenity, nil
+}
+
+func (r *amenityRepository) FindAmenityByCode(ctx context.Context, code string) (*models.Amenity, error) {
+	var amenity models.Amenity
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&amenity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &amenity, nil
+}
+
+func (r *amenityRepository) FindAmenitiesByIDs(ctx context.Context, ids []uint) ([]models.Amenity, error) {
+	var amenities []models.Amenity
+	if len(ids) == 0 {
+		return amenities, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&amenities).Error
+	if err != nil {
+		return nil, err
+	}
+	return amenities, nil
+}
+
+func (r *amenityRepository) CreateAmenityTx(ctx context.Context, tx *gorm.DB, amenity *models.Amenity) error {
+	return tx.WithContext(ctx).Omit("Translations", "Rooms").Create(amenity).Error
+}
+
+func (r *amenityRepository) UpdateAmenityTx(ctx context.Context, tx *gorm.DB, amenity *models.Amenity) error {
+	return tx.WithContext(ctx).Model(amenity).Select("Code", "Name", "Icon", "Category").Updates(amenity).Error
+}
+
+func (r *amenityRepository) ReplaceAmenityTranslationsTx(ctx context.Context, tx *gorm.DB, amenityID uint, translations []models.AmenityTranslation) error {
+	err := tx.WithContext(ctx).Unscoped().Where("amenity_id = ?", amenityID).Delete(&models.AmenityTranslation{}).Error
+	if err != nil {
+		return err
+	}
+	if len(translations) == 0 {
+		return nil
+	}
+	for i := range translations {
+		translations[i].AmenityID = amenityID
+	}
+	return tx.WithContext(ctx).Create(&translations).Error
+}
+
+func (r *amenityRepository) DeleteAmenityTx(ctx context.Context, tx *gorm.DB, id int) error {
+	amenity := &models.Amenity{}
+	amenity.ID = uint(id)
+	if err := tx.WithContext(ctx).Model(amenity).Association("Rooms").Clear(); err != nil {
+		return err
+	}
+	if err := tx.WithContext(ctx).Where("amenity_id = ?", id).Delete(&models.AmenityTranslation{}).Error; err != nil {
+		return err
+	}
+	return tx.WithContext(ctx).Delete(amenity).Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"hotel-management/internal/constant"
 	"hotel-management/internal/models"
 
 	"gorm.io/gorm"
@@ -9,7 +10,8 @@ import (
 
 type BillRepository interface {
 	CreateBillTx(ctx context.Context, tx *gorm.DB, bill *models.Bill) error
-	SearchBills(ctx context.Context, userName string, bookingID int, exportDate string) ([]models.Bill, error)
+	SearchBills(ctx context.Context, propertyID uint, userName string, bookingID int, exportDate string) ([]models.Bill, error)
+	AddRefundTx(ctx context.Context, tx *gorm.DB, billID uint, amount float64) error
 }
 type billRepository struct {
 	db *gorm.DB
@@ -23,11 +25,22 @@ func (r *billRepository) CreateBillTx(ctx context.Context, tx *gorm.DB, bill *mo
 	return tx.WithContext(ctx).Create(&bill).Error
 }
 
-func (r *billRepository) SearchBills(ctx context.Context, userName string, bookingID int, exportDate string) ([]models.Bill, error) {
+// AddRefundTx records a successful refund on the bill of the refunded
+// payment.
+func (r *billRepository) AddRefundTx(ctx context.Context, tx *gorm.DB, billID uint, amount float64) error {
+	return tx.WithContext(ctx).Model(&models.Bill{}).
+		Where("id = ?", billID).
+		Update("refunded_amount", gorm.Expr("refunded_amount + ?", amount)).Error
+}
+
+func (r *billRepository) SearchBills(ctx context.Context, propertyID uint, userName string, bookingID int, exportDate string) ([]models.Bill, error) {
 	var bills []models.Bill
 	query := r.db.WithContext(ctx).Model(&models.Bill{}).
 		Joins("JOIN bookings ON bills.booking_id = bookings.id").
 		Joins("JOIN users ON bookings.user_id = users.id")
+	if propertyID != constant.AllProperties {
+		query = query.Where("bookings.property_id = ?", propertyID)
+	}
 	if userName != "" {
 		query = query.Where("users.name LIKE ?", "%"+userName+"%")
 	}
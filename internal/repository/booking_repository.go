@@ -7,24 +7,33 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BookingRepository interface {
-	DeleteBookingRoomByRoomIDTx(ctx context.Context, tx *gorm.DB, id int) error
 	CreateBookingTx(ctx context.Context, tx *gorm.DB, booking *models.Booking) error
 	CreateBookingRoomTx(ctx context.Context, tx *gorm.DB, bookingRoom *models.BookingRoom) error
 	IsAvailableRoom(ctx context.Context, tx *gorm.DB, roomID int, startDate time.Time, endDate time.Time) (bool, error)
 	GetPriceByRoomID(ctx context.Context, tx *gorm.DB, roomID int) (float64, error)
+	GetRoomPropertyIDTx(ctx context.Context, tx *gorm.DB, roomID int) (*uint, error)
 	GetBookingByUserID(ctx context.Context, userID uint) ([]models.Booking, error)
 	GetBookingByBookingIDAndUserID(ctx context.Context, bookingID uint, userID uint) (*models.Booking, error)
 	UpdateBooking(ctx context.Context, booking *models.Booking) error
 	GetDB() *gorm.DB
 	GetBookingByID(ctx context.Context, bookingID uint) (*models.Booking, error)
 	GetBookingByIDTx(ctx context.Context, tx *gorm.DB, bookingID uint) (*models.Booking, error)
+	GetBookingByIDForUpdateTx(ctx context.Context, tx *gorm.DB, bookingID uint) (*models.Booking, error)
+	LoadLineItemsTx(ctx context.Context, tx *gorm.DB, booking *models.Booking) error
+	AddChargeTx(ctx context.Context, tx *gorm.DB, booking *models.Booking, charge *models.BookingCharge) error
 	UpdateBookingTx(ctx context.Context, tx *gorm.DB, booking *models.Booking) error
 	GetAllBookingsWithUser(ctx context.Context) ([]models.Booking, error)
-	SearchBookings(ctx context.Context, userName, bookingStatus string) ([]models.Booking, error)
+	SearchBookings(ctx context.Context, propertyID uint, userName, bookingStatus string) ([]models.Booking, error)
 	GetActiveBookingsByRoomID(ctx context.Context, roomID int) ([]models.Booking, error)
+	GetUpcomingBookingsByRoomIDTx(ctx context.Context, tx *gorm.DB, roomID int) ([]models.Booking, error)
+	GetExpiredHolds(ctx context.Context, now time.Time, limit int) ([]models.Booking, error)
+	HasPendingPaymentsTx(ctx context.Context, tx *gorm.DB, bookingID uint) (bool, error)
+	AddRefundTx(ctx context.Context, tx *gorm.DB, bookingID uint, amount float64) error
+	GetOverpaidBookings(ctx context.Context, propertyID uint) ([]models.Booking, error)
 }
 
 type bookingRepository struct {
@@ -38,12 +47,11 @@ func NewBookingRepository(db *gorm.DB) BookingRepository {
 func (r *bookingRepository) GetDB() *gorm.DB {
 	return r.db
 }
-func (*bookingRepository) DeleteBookingRoomByRoomIDTx(ctx context.Context, tx *gorm.DB, id int) error {
-	err := tx.WithContext(ctx).Where("room_id = ?", id).Delete(&models.BookingRoom{}).Error
-	if err != nil {
-		return err
-	}
-	return nil
+
+// withArchivedRooms loads rooms even when they have been archived, so booking
+// history keeps showing the room that was booked.
+func withArchivedRooms(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
 }
 
 func (r *bookingRepository) CreateBookingTx(ctx context.Context, tx *gorm.DB, booking *models.Booking) error {
@@ -68,7 +76,17 @@ func (r *bookingRepository) IsAvailableRoom(ctx context.Context, tx *gorm.DB, ro
 		Where("room_id = ?", roomID).
 		Joins("JOIN bookings ON bookings.id = booking_rooms.booking_id").
 		Where("(? < bookings.end_date) AND (? > bookings.start_date)", startDate, endDate).
-		Where("bookings.booking_status IN ?", []string{"booked", "checked_in"}).
+		Where("bookings.booking_status IN ?", constant.OccupyingBookingStatuses).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	err = blockedRoomsSubQuery(tx.WithContext(ctx)).
+		Where("work_orders.room_id = ?", roomID).
 		Count(&count).Error
 	if err != nil {
 		return false, err
@@ -86,9 +104,18 @@ func (r *bookingRepository) GetPriceByRoomID(ctx context.Context, tx *gorm.DB, r
 	return room.PricePerNight, nil
 }
 
+func (r *bookingRepository) GetRoomPropertyIDTx(ctx context.Context, tx *gorm.DB, roomID int) (*uint, error) {
+	var room models.Room
+	err := tx.WithContext(ctx).Select("id", "property_id").Where("id = ?", roomID).First(&room).Error
+	if err != nil {
+		return nil, err
+	}
+	return room.PropertyID, nil
+}
+
 func (r *bookingRepository) GetBookingByUserID(ctx context.Context, userID uint) ([]models.Booking, error) {
 	var bookings []models.Booking
-	err := r.db.WithContext(ctx).Preload("BookingRooms.Room").Where("user_id = ?", userID).Find(&bookings).Error
+	err := r.db.WithContext(ctx).Preload("BookingRooms.Room", withArchivedRooms).Preload("Charges").Where("user_id = ?", userID).Find(&bookings).Error
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +132,7 @@ func (r *bookingRepository) GetBookingByBookingIDAndUserID(ctx context.Context,
 }
 func (r *bookingRepository) GetBookingByID(ctx context.Context, bookingID uint) (*models.Booking, error) {
 	var booking models.Booking
-	if err := r.db.WithContext(ctx).Preload("User").Preload("BookingRooms.Room").First(&booking, bookingID).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("User").Preload("BookingRooms.Room", withArchivedRooms).Preload("Charges").First(&booking, bookingID).Error; err != nil {
 		return nil, err
 	}
 	return &booking, nil
@@ -118,12 +145,38 @@ func (r *bookingRepository) GetBookingByIDTx(ctx context.Context, tx *gorm.DB, b
 	return &booking, nil
 }
 
-func (r *bookingRepository) UpdateBooking(ctx context.Context, booking *models.Booking) error {
-	err := r.db.WithContext(ctx).Updates(&booking).Error
-	if err != nil {
+// GetBookingByIDForUpdateTx locks the booking until the transaction ends, so
+// that payments of the same booking are allocated and settled one at a time.
+func (r *bookingRepository) GetBookingByIDForUpdateTx(ctx context.Context, tx *gorm.DB, bookingID uint) (*models.Booking, error) {
+	var booking models.Booking
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, bookingID).Error; err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// LoadLineItemsTx loads the rooms and charges of a booking.
+func (r *bookingRepository) LoadLineItemsTx(ctx context.Context, tx *gorm.DB, booking *models.Booking) error {
+	if err := tx.WithContext(ctx).Where("booking_id = ?", booking.ID).Find(&booking.BookingRooms).Error; err != nil {
 		return err
 	}
-	return nil
+	return tx.WithContext(ctx).Where("booking_id = ?", booking.ID).Find(&booking.Charges).Error
+}
+
+// AddChargeTx saves a charge and adds it to the booking's total, which
+// leaves the booking unpaid until the charge is paid too.
+func (r *bookingRepository) AddChargeTx(ctx context.Context, tx *gorm.DB, booking *models.Booking, charge *models.BookingCharge) error {
+	if err := tx.WithContext(ctx).Create(charge).Error; err != nil {
+		return err
+	}
+	booking.TotalPrice += charge.Amount
+	booking.IsPaid = booking.Balance() <= 0
+	return tx.WithContext(ctx).Model(booking).
+		UpdateColumns(map[string]interface{}{"total_price": booking.TotalPrice, "is_paid": booking.IsPaid}).Error
+}
+
+func (r *bookingRepository) UpdateBooking(ctx context.Context, booking *models.Booking) error {
+	return updateBooking(r.db.WithContext(ctx), booking)
 }
 
 func (r *bookingRepository) GetAllBookingsWithUser(ctx context.Context) ([]models.Booking, error) {
@@ -132,16 +185,29 @@ func (r *bookingRepository) GetAllBookingsWithUser(ctx context.Context) ([]model
 	return bookings, err
 }
 func (r *bookingRepository) UpdateBookingTx(ctx context.Context, tx *gorm.DB, booking *models.Booking) error {
-	err := tx.WithContext(ctx).Updates(&booking).Error
-	if err != nil {
+	return updateBooking(tx.WithContext(ctx), booking)
+}
+
+// updateBooking saves the non-zero fields of a booking. Updates skips nil
+// pointers, so a hold released by setting HoldExpiresAt to nil is cleared
+// explicitly.
+func updateBooking(db *gorm.DB, booking *models.Booking) error {
+	if err := db.Updates(booking).Error; err != nil {
 		return err
 	}
-	return nil
+	if booking.HoldExpiresAt != nil {
+		return nil
+	}
+	return db.Model(booking).UpdateColumn("hold_expires_at", nil).Error
 }
 
-func (r *bookingRepository) SearchBookings(ctx context.Context, userName, bookingStatus string) ([]models.Booking, error) {
+func (r *bookingRepository) SearchBookings(ctx context.Context, propertyID uint, userName, bookingStatus string) ([]models.Booking, error) {
 	var bookings []models.Booking
-	query := r.db.WithContext(ctx).Model(&models.Booking{}).Preload("User").Preload("BookingRooms.Room")
+	query := r.db.WithContext(ctx).Model(&models.Booking{}).Preload("User").Preload("BookingRooms.Room", withArchivedRooms)
+
+	if propertyID != constant.AllProperties {
+		query = query.Where("bookings.property_id = ?", propertyID)
+	}
 
 	if userName != "" {
 		query = query.Joins("JOIN users ON users.id = bookings.user_id").Where("users.name LIKE ?", "%"+userName+"%")
@@ -167,4 +233,66 @@ func (r *bookingRepository) GetActiveBookingsByRoomID(ctx context.Context, roomI
 		Find(&bookings).Error
 	return bookings, err
 }
-	
\ No newline at end of file
+	
+
+// GetUpcomingBookingsByRoomIDTx returns the bookings holding a room that have
+// not ended yet.
+func (r *bookingRepository) GetUpcomingBookingsByRoomIDTx(ctx context.Context, tx *gorm.DB, roomID int) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := tx.WithContext(ctx).
+		Preload("User").
+		Joins("JOIN booking_rooms ON bookings.id = booking_rooms.booking_id").
+		Where("booking_rooms.room_id = ? AND bookings.end_date >= NOW()", roomID).
+		Where("bookings.booking_status IN ?", constant.OccupyingBookingStatuses).
+		Order("bookings.start_date").
+		Find(&bookings).Error
+	return bookings, err
+}
+
+// GetExpiredHolds lists pending_payment bookings whose hold has run out and
+// that have no payment still in progress. A payment in progress is left to
+// the payment reconciler, which settles or expires it first.
+func (r *bookingRepository) GetExpiredHolds(ctx context.Context, now time.Time, limit int) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.db.WithContext(ctx).
+		Where("booking_status = ? AND hold_expires_at < ?", constant.PENDING_PAYMENT, now).
+		Where("NOT EXISTS (?)", r.db.Model(&models.Payment{}).
+			Select("1").
+			Where("payments.booking_id = bookings.id AND payments.payment_status = ?", constant.PAYMENT_PENDING)).
+		Order("hold_expires_at").
+		Limit(limit).
+		Find(&bookings).Error
+	return bookings, err
+}
+
+// HasPendingPaymentsTx reports whether a payment of the booking is still in
+// progress.
+func (r *bookingRepository) HasPendingPaymentsTx(ctx context.Context, tx *gorm.DB, bookingID uint) (bool, error) {
+	var count int64
+	err := tx.WithContext(ctx).Model(&models.Payment{}).
+		Where("booking_id = ? AND payment_status = ?", bookingID, constant.PAYMENT_PENDING).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// AddRefundTx records a successful refund on the booking of the refunded
+// payment.
+func (r *bookingRepository) AddRefundTx(ctx context.Context, tx *gorm.DB, bookingID uint, amount float64) error {
+	return tx.WithContext(ctx).Model(&models.Booking{}).
+		Where("id = ?", bookingID).
+		Update("refunded_amount", gorm.Expr("refunded_amount + ?", amount)).Error
+}
+
+// GetOverpaidBookings lists the bookings of a property (every property when
+// propertyID is 0) whose payments, less refunds, exceed their total.
+func (r *bookingRepository) GetOverpaidBookings(ctx context.Context, propertyID uint) ([]models.Booking, error) {
+	var bookings []models.Booking
+	query := r.db.WithContext(ctx).
+		Preload("User").
+		Where("paid_amount - refunded_amount > total_price")
+	if propertyID != constant.AllProperties {
+		query = query.Where("property_id = ?", propertyID)
+	}
+	err := query.Order("id").Find(&bookings).Error
+	return bookings, err
+}
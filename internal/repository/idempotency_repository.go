@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"hotel-management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IdempotencyRepository interface {
+	CreateIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error
+	GetIdempotencyKey(ctx context.Context, userID uint, key string) (*models.IdempotencyKey, error)
+	SaveIdempotencyResponse(ctx context.Context, key *models.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, id uint) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// CreateIdempotencyKey claims a key for a request. It fails with
+// gorm.ErrDuplicatedKey when the user already used the key.
+func (r *idempotencyRepository) CreateIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *idempotencyRepository) GetIdempotencyKey(ctx context.Context, userID uint, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := r.db.WithContext(ctx).Where("user_id = ? AND idempotency_key = ?", userID, key).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// SaveIdempotencyResponse stores the response of the request that claimed
+// the key.
+func (r *idempotencyRepository) SaveIdempotencyResponse(ctx context.Context, key *models.IdempotencyKey) error {
+	return r.db.WithContext(ctx).Model(&models.IdempotencyKey{}).Where("id = ?", key.ID).UpdateColumns(map[string]interface{}{
+		"status_code":   key.StatusCode,
+		"content_type":  key.ContentType,
+		"response_body": key.ResponseBody,
+	}).Error
+}
+
+func (r *idempotencyRepository) DeleteIdempotencyKey(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.IdempotencyKey{}, id).Error
+}
+
+// DeleteExpiredIdempotencyKeys removes the keys that expired before now and
+// returns how many there were.
+func (r *idempotencyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
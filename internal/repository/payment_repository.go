@@ -2,14 +2,35 @@ package repository
 
 import (
 	"context"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
 	"hotel-management/internal/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentRepository interface {
-	CreatePayment(ctx context.Context, payment *models.Payment) error
+	CreatePaymentTx(ctx context.Context, tx *gorm.DB, payment *models.Payment) error
+	GetPaymentByID(ctx context.Context, id uint) (*models.Payment, error)
+	GetPaymentByIDForUpdateTx(ctx context.Context, tx *gorm.DB, id uint) (*models.Payment, error)
+	GetPaymentsByBookingID(ctx context.Context, bookingID uint) ([]models.Payment, error)
+	SearchPayments(ctx context.Context, propertyID uint, filter dto.PaymentFilter) ([]models.Payment, error)
+	GetPaymentDetail(ctx context.Context, id uint) (*models.Payment, error)
+	CreatePaymentCallback(ctx context.Context, callback *models.PaymentCallback) error
+	GetPaymentCallbacks(ctx context.Context, txnRef string) ([]models.PaymentCallback, error)
+	GetPaymentByTxnRefPrefix(ctx context.Context, method string, prefix string) (*models.Payment, error)
+	GetPendingPaymentsBefore(ctx context.Context, before time.Time, methods []string, limit int) ([]models.Payment, error)
+	MarkReconcileChecked(ctx context.Context, paymentID uint, checkedAt time.Time, failed bool) error
+	GetStuckPayments(ctx context.Context, propertyID uint) ([]models.Payment, error)
+	ResetReconcileErrors(ctx context.Context, paymentID uint) error
+	SumOpenPaymentsTx(ctx context.Context, tx *gorm.DB, bookingID uint, pendingSince time.Time) (float64, error)
+	GetOpenAllocationsTx(ctx context.Context, tx *gorm.DB, bookingID uint, pendingSince time.Time) ([]models.PaymentAllocation, error)
+	GetUnbilledPaymentsTx(ctx context.Context, tx *gorm.DB, bookingID uint) ([]models.Payment, error)
+	SetPaymentsBillTx(ctx context.Context, tx *gorm.DB, paymentIDs []uint, billID uint) error
 	GetPaymentByTxnRefTx(ctx context.Context, tx *gorm.DB, txnRef string) (*models.Payment, error)
+	GetPaymentByTxnRefForUpdateTx(ctx context.Context, tx *gorm.DB, txnRef string) (*models.Payment, error)
 	UpdatePaymentTx(ctx context.Context, tx *gorm.DB, payment *models.Payment) error
 	GetDB() *gorm.DB
 }
@@ -24,8 +45,213 @@ func (r *paymentRepository) GetDB() *gorm.DB {
 	return r.db
 }
 
-func (r *paymentRepository) CreatePayment(ctx context.Context, payment *models.Payment) error {
-	return r.db.WithContext(ctx).Create(payment).Error
+// CreatePaymentTx saves a payment together with its allocations.
+func (r *paymentRepository) CreatePaymentTx(ctx context.Context, tx *gorm.DB, payment *models.Payment) error {
+	return tx.WithContext(ctx).Create(payment).Error
+}
+
+func (r *paymentRepository) GetPaymentByID(ctx context.Context, id uint) (*models.Payment, error) {
+	var payment models.Payment
+	if err := r.db.WithContext(ctx).First(&payment, id).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaymentByIDForUpdateTx locks the payment until the transaction ends, so
+// refunds of the same payment are taken one at a time.
+func (r *paymentRepository) GetPaymentByIDForUpdateTx(ctx context.Context, tx *gorm.DB, id uint) (*models.Payment, error) {
+	var payment models.Payment
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, id).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaymentsByBookingID lists the payments of a booking with their refunds
+// and allocations, newest first.
+func (r *paymentRepository) GetPaymentsByBookingID(ctx context.Context, bookingID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.WithContext(ctx).
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		Preload("Allocations").
+		Where("booking_id = ?", bookingID).
+		Order("created_at DESC").
+		Find(&payments).Error
+	return payments, err
+}
+
+// SearchPayments lists the payments matching the admin filters, newest
+// first. A propertyID of 0 searches every property.
+func (r *paymentRepository) SearchPayments(ctx context.Context, propertyID uint, filter dto.PaymentFilter) ([]models.Payment, error) {
+	var payments []models.Payment
+	query := r.db.WithContext(ctx).Model(&models.Payment{}).
+		Joins("JOIN bookings ON bookings.id = payments.booking_id").
+		Preload("Booking.User").
+		Preload("Refunds")
+	if propertyID != constant.AllProperties {
+		query = query.Where("bookings.property_id = ?", propertyID)
+	}
+	if filter.PaymentMethod != "" {
+		query = query.Where("payments.payment_method = ?", filter.PaymentMethod)
+	}
+	if filter.PaymentStatus != "" {
+		query = query.Where("payments.payment_status = ?", filter.PaymentStatus)
+	}
+	if filter.BookingID != 0 {
+		query = query.Where("payments.booking_id = ?", filter.BookingID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("payments.created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("payments.created_at < ?", filter.To.AddDate(0, 0, 1))
+	}
+	err := query.Order("payments.created_at DESC").Limit(constant.PaymentSearchLimit).Find(&payments).Error
+	return payments, err
+}
+
+// GetPaymentDetail loads a payment with its booking, allocations and
+// refunds.
+func (r *paymentRepository) GetPaymentDetail(ctx context.Context, id uint) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).
+		Preload("Booking.User").
+		Preload("Allocations").
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		First(&payment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) CreatePaymentCallback(ctx context.Context, callback *models.PaymentCallback) error {
+	return r.db.WithContext(ctx).Create(callback).Error
+}
+
+// GetPaymentCallbacks lists the callbacks received for a transaction
+// reference, oldest first.
+func (r *paymentRepository) GetPaymentCallbacks(ctx context.Context, txnRef string) ([]models.PaymentCallback, error) {
+	var callbacks []models.PaymentCallback
+	err := r.db.WithContext(ctx).Where("txn_ref = ?", txnRef).Order("created_at").Find(&callbacks).Error
+	return callbacks, err
+}
+
+// GetPaymentByTxnRefPrefix finds the latest payment through a method whose
+// txn ref starts with prefix, with its booking.
+func (r *paymentRepository) GetPaymentByTxnRefPrefix(ctx context.Context, method string, prefix string) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).
+		Preload("Booking").
+		Where("payment_method = ? AND txn_ref LIKE ?", method, prefix+"%").
+		Order("created_at DESC").
+		First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPendingPaymentsBefore lists payments through the given methods that
+// have been pending since before the given time and are not stuck. Payments
+// never looked up come first, as NULL sorts first, then the ones looked up
+// longest ago.
+func (r *paymentRepository) GetPendingPaymentsBefore(ctx context.Context, before time.Time, methods []string, limit int) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.WithContext(ctx).
+		Where("payment_status = ? AND created_at < ? AND payment_method IN ?", constant.PAYMENT_PENDING, before, methods).
+		Where("reconcile_errors < ?", constant.MaxReconcileErrors).
+		Order("reconcile_checked_at ASC, created_at ASC").
+		Limit(limit).
+		Find(&payments).Error
+	return payments, err
+}
+
+// MarkReconcileChecked records a lookup of a payment by the reconciler,
+// counting it when it failed and clearing the count otherwise.
+func (r *paymentRepository) MarkReconcileChecked(ctx context.Context, paymentID uint, checkedAt time.Time, failed bool) error {
+	errorCount := interface{}(0)
+	if failed {
+		errorCount = gorm.Expr("reconcile_errors + 1")
+	}
+	return r.db.WithContext(ctx).Model(&models.Payment{}).
+		Where("id = ?", paymentID).
+		UpdateColumns(map[string]interface{}{
+			"reconcile_checked_at": checkedAt,
+			"reconcile_errors":     errorCount,
+		}).Error
+}
+
+// GetStuckPayments lists the pending payments of a property (every property
+// when propertyID is 0) that the reconciler gave up on, with their booking.
+func (r *paymentRepository) GetStuckPayments(ctx context.Context, propertyID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	query := r.db.WithContext(ctx).Model(&models.Payment{}).
+		Joins("JOIN bookings ON bookings.id = payments.booking_id").
+		Preload("Booking.User").
+		Where("payments.payment_status = ? AND payments.reconcile_errors >= ?", constant.PAYMENT_PENDING, constant.MaxReconcileErrors)
+	if propertyID != constant.AllProperties {
+		query = query.Where("bookings.property_id = ?", propertyID)
+	}
+	err := query.Order("payments.created_at ASC").Find(&payments).Error
+	return payments, err
+}
+
+// ResetReconcileErrors hands a stuck payment back to the reconciler.
+func (r *paymentRepository) ResetReconcileErrors(ctx context.Context, paymentID uint) error {
+	return r.db.WithContext(ctx).Model(&models.Payment{}).
+		Where("id = ?", paymentID).
+		UpdateColumn("reconcile_errors", 0).Error
+}
+
+// openPaymentsCondition matches the payments that have been made or may
+// still be, and so count against what is left to pay: successful ones and
+// those pending since pendingSince. An older pending payment was abandoned
+// or is waiting for the reconciler, and must not keep the guest from paying
+// again.
+const openPaymentsCondition = "(payments.payment_status = ? OR (payments.payment_status = ? AND payments.created_at >= ?))"
+
+// SumOpenPaymentsTx adds up the payments of a booking that are paid or in
+// progress.
+func (r *paymentRepository) SumOpenPaymentsTx(ctx context.Context, tx *gorm.DB, bookingID uint, pendingSince time.Time) (float64, error) {
+	var total float64
+	err := tx.WithContext(ctx).Model(&models.Payment{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("booking_id = ?", bookingID).
+		Where(openPaymentsCondition, constant.PAYMENT_SUCCESS, constant.PAYMENT_PENDING, pendingSince).
+		Scan(&total).Error
+	return total, err
+}
+
+// GetOpenAllocationsTx lists what the paid or in-progress payments of a
+// booking are allocated to.
+func (r *paymentRepository) GetOpenAllocationsTx(ctx context.Context, tx *gorm.DB, bookingID uint, pendingSince time.Time) ([]models.PaymentAllocation, error) {
+	var allocations []models.PaymentAllocation
+	err := tx.WithContext(ctx).
+		Joins("JOIN payments ON payments.id = payment_allocations.payment_id").
+		Where("payments.booking_id = ? AND payments.deleted_at IS NULL", bookingID).
+		Where(openPaymentsCondition, constant.PAYMENT_SUCCESS, constant.PAYMENT_PENDING, pendingSince).
+		Find(&allocations).Error
+	return allocations, err
+}
+
+// GetUnbilledPaymentsTx lists the successful payments of a booking that are
+// not on a bill yet, oldest first, with their refunds.
+func (r *paymentRepository) GetUnbilledPaymentsTx(ctx context.Context, tx *gorm.DB, bookingID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := tx.WithContext(ctx).
+		Preload("Refunds").
+		Where("booking_id = ? AND payment_status = ? AND bill_id IS NULL", bookingID, constant.PAYMENT_SUCCESS).
+		Order("id").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepository) SetPaymentsBillTx(ctx context.Context, tx *gorm.DB, paymentIDs []uint, billID uint) error {
+	return tx.WithContext(ctx).Model(&models.Payment{}).
+		Where("id IN ?", paymentIDs).
+		Update("bill_id", billID).Error
 }
 
 func (r *paymentRepository) GetPaymentByTxnRefTx(ctx context.Context, tx *gorm.DB, txnRef string) (*models.Payment, error) {
@@ -40,6 +266,19 @@ func (r *paymentRepository) GetPaymentByTxnRefTx(ctx context.Context, tx *gorm.D
 	return &payment, nil
 }
 
+// GetPaymentByTxnRefForUpdateTx locks the payment until the transaction ends,
+// so a callback and the reconciler settle it one at a time.
+func (r *paymentRepository) GetPaymentByTxnRefForUpdateTx(ctx context.Context, tx *gorm.DB, txnRef string) (*models.Payment, error) {
+	var payment models.Payment
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("txn_ref = ?", txnRef).
+		First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
 func (r *paymentRepository) UpdatePaymentTx(ctx context.Context, tx *gorm.DB, payment *models.Payment) error {
 	return tx.WithContext(ctx).Save(payment).Error
 }
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type PropertyRepository interface {
+	GetAllProperties(ctx context.Context) ([]models.Property, error)
+	GetActiveProperties(ctx context.Context, city string) ([]models.Property, error)
+	FindPropertyByID(ctx context.Context, id uint) (*models.Property, error)
+	FindPropertyByCode(ctx context.Context, code string) (*models.Property, error)
+	GetPropertiesByStaffID(ctx context.Context, staffID uint) ([]models.Property, error)
+	GetStaffAccounts(ctx context.Context) ([]models.User, error)
+	CreatePropertyTx(ctx context.Context, tx *gorm.DB, property *models.Property) error
+	UpdatePropertyTx(ctx context.Context, tx *gorm.DB, property *models.Property) error
+	ReplacePropertyStaffTx(ctx context.Context, tx *gorm.DB, property *models.Property, staff []models.User) error
+	GetDB() *gorm.DB
+}
+
+type propertyRepository struct {
+	db *gorm.DB
+}
+
+func NewPropertyRepository(db *gorm.DB) PropertyRepository {
+	return &propertyRepository{db: db}
+}
+
+func (r *propertyRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *propertyRepository) GetAllProperties(ctx context.Context) ([]models.Property, error) {
+	var properties []models.Property
+	err := r.db.WithContext(ctx).Preload("Staff").Order("name").Find(&properties).Error
+	if err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+func (r *propertyRepository) GetActiveProperties(ctx context.Context, city string) ([]models.Property, error) {
+	var properties []models.Property
+	db := r.db.WithContext(ctx).Where("is_active = ?", true)
+	if city != "" {
+		db = db.Where("city = ?", city)
+	}
+	err := db.Order("city, name").Find(&properties).Error
+	if err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+func (r *propertyRepository) FindPropertyByID(ctx context.Context, id uint) (*models.Property, error) {
+	var property models.Property
+	err := r.db.WithContext(ctx).Preload("Staff").First(&property, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &property, nil
+}
+
+func (r *propertyRepository) FindPropertyByCode(ctx context.Context, code string) (*models.Property, error) {
+	var property models.Property
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&property).Error
+	if err != nil {
+		return nil, err
+	}
+	return &property, nil
+}
+
+func (r *propertyRepository) GetPropertiesByStaffID(ctx context.Context, staffID uint) ([]models.Property, error) {
+	var properties []models.Property
+	err := r.db.WithContext(ctx).
+		Joins("JOIN property_staff ON property_staff.property_id = properties.id").
+		Where("property_staff.user_id = ?", staffID).
+		Order("properties.name").
+		Find(&properties).Error
+	if err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+func (r *propertyRepository) GetStaffAccounts(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	err := r.db.WithContext(ctx).Where("role = ?", constant.STAFF).Order("name").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *propertyRepository) CreatePropertyTx(ctx context.Context, tx *gorm.DB, property *models.Property) error {
+	return tx.WithContext(ctx).Create(property).Error
+}
+
+func (r *propertyRepository) UpdatePropertyTx(ctx context.Context, tx *gorm.DB, property *models.Property) error {
+	return tx.WithContext(ctx).Model(property).
+		Select("Code", "Name", "City", "Address", "Phone", "IsActive").
+		Updates(property).Error
+}
+
+func (r *propertyRepository) ReplacePropertyStaffTx(ctx context.Context, tx *gorm.DB, property *models.Property, staff []models.User) error {
+	return tx.WithContext(ctx).Model(property).Association("Staff").Replace(staff)
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type RatePlanRepository interface {
+	GetRatePlans(ctx context.Context, propertyID uint, activeOnly bool) ([]models.RatePlan, error)
+	FindRatePlanByID(ctx context.Context, id uint) (*models.RatePlan, error)
+	FindRatePlanByIDTx(ctx context.Context, tx *gorm.DB, id uint) (*models.RatePlan, error)
+	CreateRatePlan(ctx context.Context, ratePlan *models.RatePlan) error
+	UpdateRatePlan(ctx context.Context, ratePlan *models.RatePlan) error
+}
+
+type ratePlanRepository struct {
+	db *gorm.DB
+}
+
+func NewRatePlanRepository(db *gorm.DB) RatePlanRepository {
+	return &ratePlanRepository{db: db}
+}
+
+// GetRatePlans lists the rate plans of a property, or of every property when
+// propertyID is 0.
+func (r *ratePlanRepository) GetRatePlans(ctx context.Context, propertyID uint, activeOnly bool) ([]models.RatePlan, error) {
+	var ratePlans []models.RatePlan
+	query := r.db.WithContext(ctx).Preload("Property").Order("property_id, name")
+	if propertyID != constant.AllProperties {
+		query = query.Where("property_id = ?", propertyID)
+	}
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Find(&ratePlans).Error
+	return ratePlans, err
+}
+
+func (r *ratePlanRepository) FindRatePlanByID(ctx context.Context, id uint) (*models.RatePlan, error) {
+	return r.FindRatePlanByIDTx(ctx, r.db, id)
+}
+
+func (r *ratePlanRepository) FindRatePlanByIDTx(ctx context.Context, tx *gorm.DB, id uint) (*models.RatePlan, error) {
+	var ratePlan models.RatePlan
+	if err := tx.WithContext(ctx).First(&ratePlan, id).Error; err != nil {
+		return nil, err
+	}
+	return &ratePlan, nil
+}
+
+// CreateRatePlan names the columns so that a plan created inactive is not
+// switched on by the is_active column default.
+func (r *ratePlanRepository) CreateRatePlan(ctx context.Context, ratePlan *models.RatePlan) error {
+	return r.db.WithContext(ctx).
+		Select("PropertyID", "Name", "Description", "PaymentTiming", "DepositPercent", "IsActive", "CreatedAt", "UpdatedAt").
+		Create(ratePlan).Error
+}
+
+// UpdateRatePlan saves every field, so that a plan can be deactivated.
+func (r *ratePlanRepository) UpdateRatePlan(ctx context.Context, ratePlan *models.RatePlan) error {
+	return r.db.WithContext(ctx).Save(ratePlan).Error
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"hotel-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ReconciliationRepository interface {
+	CreateReconciliation(ctx context.Context, run *models.PaymentReconciliation) error
+	ListReconciliations(ctx context.Context, limit int) ([]models.PaymentReconciliation, error)
+}
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+// CreateReconciliation saves a run together with its entries.
+func (r *reconciliationRepository) CreateReconciliation(ctx context.Context, run *models.PaymentReconciliation) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// ListReconciliations returns the latest runs, newest first, with their
+// entries.
+func (r *reconciliationRepository) ListReconciliations(ctx context.Context, limit int) ([]models.PaymentReconciliation, error) {
+	var runs []models.PaymentReconciliation
+	err := r.db.WithContext(ctx).
+		Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Order("started_at DESC").
+		Limit(limit).
+		Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefundRepository interface {
+	CreateRefundTx(ctx context.Context, tx *gorm.DB, refund *models.Refund) error
+	UpdateRefundTx(ctx context.Context, tx *gorm.DB, refund *models.Refund) error
+	SumOpenRefundsTx(ctx context.Context, tx *gorm.DB, paymentID uint) (float64, error)
+	GetRefundByIDForUpdateTx(ctx context.Context, tx *gorm.DB, id uint) (*models.Refund, error)
+	GetPendingRefundsBefore(ctx context.Context, before time.Time, limit int) ([]models.Refund, error)
+	GetDB() *gorm.DB
+}
+
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+func (r *refundRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *refundRepository) CreateRefundTx(ctx context.Context, tx *gorm.DB, refund *models.Refund) error {
+	return tx.WithContext(ctx).Create(refund).Error
+}
+
+func (r *refundRepository) UpdateRefundTx(ctx context.Context, tx *gorm.DB, refund *models.Refund) error {
+	return tx.WithContext(ctx).Save(refund).Error
+}
+
+// SumOpenRefundsTx adds up the refunds of a payment that succeeded or are
+// still being processed, which is what can no longer be refunded.
+func (r *refundRepository) SumOpenRefundsTx(ctx context.Context, tx *gorm.DB, paymentID uint) (float64, error) {
+	var total float64
+	err := tx.WithContext(ctx).Model(&models.Refund{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("payment_id = ? AND status IN ?", paymentID, []string{constant.REFUND_PENDING, constant.REFUND_SUCCESS}).
+		Scan(&total).Error
+	return total, err
+}
+
+// GetRefundByIDForUpdateTx locks the refund until the transaction ends, so
+// its outcome is only recorded once.
+func (r *refundRepository) GetRefundByIDForUpdateTx(ctx context.Context, tx *gorm.DB, id uint) (*models.Refund, error) {
+	var refund models.Refund
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&refund, id).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// GetPendingRefundsBefore lists refunds created before the given time whose
+// outcome is still unknown, oldest first, with their payment.
+func (r *refundRepository) GetPendingRefundsBefore(ctx context.Context, before time.Time, limit int) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.db.WithContext(ctx).
+		Preload("Payment").
+		Where("status = ? AND created_at < ?", constant.REFUND_PENDING, before).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&refunds).Error
+	return refunds, err
+}
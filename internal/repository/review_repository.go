@@ -2,15 +2,33 @@ package repository
 
 import (
 	"context"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
 	"hotel-management/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ReviewRepository interface {
 	CreateReview(ctx context.Context, review *models.Review) error
-	ExistsByBookingID(ctx context.Context, bookingID uint) (bool, error)
-	DeleteByRoomIDTx(ctx context.Context, tx *gorm.DB, id int) error
+	CreateReviewTx(ctx context.Context, tx *gorm.DB, review *models.Review) error
+	CreateReviewPhotoTx(ctx context.Context, tx *gorm.DB, photo *models.ReviewPhoto) error
+	UpdateReviewContentTx(ctx context.Context, tx *gorm.DB, review *models.Review) error
+	DeleteReviewTx(ctx context.Context, tx *gorm.DB, review *models.Review) error
+	CreateReviewRevisionTx(ctx context.Context, tx *gorm.DB, revision *models.ReviewRevision) error
+	ExistsByBookingRoom(ctx context.Context, bookingID uint, roomID uint) (bool, error)
+	GetPendingReviews(ctx context.Context, userID uint) ([]dto.PendingReviewResponse, error)
+	GetRatingSummaries(ctx context.Context, roomIDs []uint) (map[uint]dto.RatingSummary, error)
+	GetRecentReviewsByRoomID(ctx context.Context, roomID uint, limit int) ([]models.Review, error)
+	GetReviewsByRoomID(ctx context.Context, roomID uint, sort string, page dto.PageQuery) ([]models.Review, int64, error)
+	GetRoomRating(ctx context.Context, roomID uint) (*models.RoomRating, error)
+	RecomputeRoomRatingTx(ctx context.Context, tx *gorm.DB, roomID uint) error
+	SearchReviews(ctx context.Context, query dto.ReviewQuery) ([]models.Review, error)
+	FindReviewByID(ctx context.Context, id uint) (*models.Review, error)
+	UpdateReviewModerationTx(ctx context.Context, tx *gorm.DB, review *models.Review) error
+	UpdateReviewResponse(ctx context.Context, review *models.Review) error
+	GetDB() *gorm.DB
 }
 
 type reviewRepository struct {
@@ -28,19 +46,235 @@ func (r *reviewRepository) CreateReview(ctx context.Context, review *models.Revi
 	return nil
 }
 
-func (*reviewRepository) DeleteByRoomIDTx(ctx context.Context, tx *gorm.DB, id int) error {
-	err := tx.WithContext(ctx).Where("room_id = ?", id).Delete(&models.Review{}).Error
-	if err != nil {
-		return err
-	}
-	return nil
+func (r *reviewRepository) CreateReviewTx(ctx context.Context, tx *gorm.DB, review *models.Review) error {
+	return tx.WithContext(ctx).Create(review).Error
+}
+
+func (r *reviewRepository) CreateReviewPhotoTx(ctx context.Context, tx *gorm.DB, photo *models.ReviewPhoto) error {
+	return tx.WithContext(ctx).Create(photo).Error
+}
+
+func (r *reviewRepository) UpdateReviewContentTx(ctx context.Context, tx *gorm.DB, review *models.Review) error {
+	return tx.WithContext(ctx).Model(review).
+		Select("Rating", "Comment", "CleanlinessRating", "ServiceRating", "LocationRating", "ValueRating", "ComfortRating", "Status").
+		Updates(review).Error
+}
+
+func (r *reviewRepository) DeleteReviewTx(ctx context.Context, tx *gorm.DB, review *models.Review) error {
+	return tx.WithContext(ctx).Delete(review).Error
+}
+
+func (r *reviewRepository) CreateReviewRevisionTx(ctx context.Context, tx *gorm.DB, revision *models.ReviewRevision) error {
+	return tx.WithContext(ctx).Create(revision).Error
 }
 
-func (r *reviewRepository) ExistsByBookingID(ctx context.Context, bookingID uint) (bool, error) {
+// ExistsByBookingRoom also counts reviews the author deleted, since the
+// unique index on booking and room still holds their rows.
+func (r *reviewRepository) ExistsByBookingRoom(ctx context.Context, bookingID uint, roomID uint) (bool, error) {
 	var count int64
 	err := r.db.WithContext(ctx).
+		Unscoped().
 		Model(&models.Review{}).
-		Where("booking_id = ?", bookingID).
+		Where("booking_id = ? AND room_id = ?", bookingID, roomID).
 		Count(&count).Error
 	return count > 0, err
 }
+
+// GetPendingReviews lists the rooms of the user's checked-out bookings that
+// have no review yet, most recent stay first.
+func (r *reviewRepository) GetPendingReviews(ctx context.Context, userID uint) ([]dto.PendingReviewResponse, error) {
+	var pending []dto.PendingReviewResponse
+	err := r.db.WithContext(ctx).
+		Table("booking_rooms").
+		Select(`booking_rooms.booking_id, booking_rooms.room_id, rooms.name AS room_name,
+			rooms.number AS room_number, rooms.type AS room_type, bookings.start_date, bookings.end_date`).
+		Joins("JOIN bookings ON bookings.id = booking_rooms.booking_id AND bookings.deleted_at IS NULL").
+		Joins("JOIN rooms ON rooms.id = booking_rooms.room_id").
+		Where("booking_rooms.deleted_at IS NULL").
+		Where("bookings.user_id = ? AND bookings.booking_status = ?", userID, constant.CHECKED_OUT).
+		Where("NOT EXISTS (SELECT 1 FROM reviews WHERE reviews.booking_id = booking_rooms.booking_id AND reviews.room_id = booking_rooms.room_id)").
+		Order("bookings.end_date DESC, booking_rooms.room_id").
+		Scan(&pending).Error
+	return pending, err
+}
+
+// GetRatingSummaries reads the stored aggregates; rooms without reviews are
+// left out of the map.
+func (r *reviewRepository) GetRatingSummaries(ctx context.Context, roomIDs []uint) (map[uint]dto.RatingSummary, error) {
+	summaries := make(map[uint]dto.RatingSummary, len(roomIDs))
+	if len(roomIDs) == 0 {
+		return summaries, nil
+	}
+	var rows []dto.RatingSummary
+	err := r.db.WithContext(ctx).
+		Model(&models.RoomRating{}).
+		Select("room_id, avg_rating, review_count").
+		Where("room_id IN ?", roomIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		summaries[row.RoomID] = row
+	}
+	return summaries, nil
+}
+
+func (r *reviewRepository) GetRecentReviewsByRoomID(ctx context.Context, roomID uint, limit int) ([]models.Review, error) {
+	var reviews []models.Review
+	err := r.db.WithContext(ctx).
+		Scopes(publicReviews).
+		Preload("User").
+		Preload("Photos").
+		Where("room_id = ?", roomID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *reviewRepository) GetReviewsByRoomID(ctx context.Context, roomID uint, sort string, page dto.PageQuery) ([]models.Review, int64, error) {
+	var reviews []models.Review
+	var total int64
+	db := r.db.WithContext(ctx).Model(&models.Review{}).Scopes(publicReviews).Where("room_id = ?", roomID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	order := "created_at DESC, id DESC"
+	if sort == constant.REVIEW_SORT_HIGHEST {
+		order = "rating DESC, created_at DESC, id DESC"
+	}
+	err := db.
+		Preload("User").
+		Preload("Photos").
+		Order(order).
+		Offset(page.Offset()).
+		Limit(page.Limit).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+// GetRoomRating returns an empty aggregate for rooms nobody has reviewed.
+func (r *reviewRepository) GetRoomRating(ctx context.Context, roomID uint) (*models.RoomRating, error) {
+	var ratings []models.RoomRating
+	err := r.db.WithContext(ctx).Where("room_id = ?", roomID).Limit(1).Find(&ratings).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(ratings) == 0 {
+		return &models.RoomRating{RoomID: roomID}, nil
+	}
+	return &ratings[0], nil
+}
+
+// RecomputeRoomRatingTx rebuilds the stored aggregate of a room from its
+// public reviews. The room row is locked first so two reviews written at the
+// same time recompute one after the other; otherwise the later write could
+// store an aggregate that misses the other review.
+func (r *reviewRepository) RecomputeRoomRatingTx(ctx context.Context, tx *gorm.DB, roomID uint) error {
+	// Archived rooms keep their reviews, so the lock ignores soft deletes.
+	var room models.Room
+	err := tx.WithContext(ctx).Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", roomID).
+		Limit(1).
+		Find(&room).Error
+	if err != nil {
+		return err
+	}
+
+	rating := models.RoomRating{RoomID: roomID}
+	err = tx.WithContext(ctx).
+		Model(&models.Review{}).
+		Select(`COUNT(*) AS review_count, COALESCE(AVG(rating), 0) AS avg_rating,
+			COALESCE(SUM(rating = 1), 0) AS rating1_count, COALESCE(SUM(rating = 2), 0) AS rating2_count,
+			COALESCE(SUM(rating = 3), 0) AS rating3_count, COALESCE(SUM(rating = 4), 0) AS rating4_count,
+			COALESCE(SUM(rating = 5), 0) AS rating5_count,
+			COALESCE(AVG(NULLIF(cleanliness_rating, 0)), 0) AS avg_cleanliness,
+			COALESCE(AVG(NULLIF(service_rating, 0)), 0) AS avg_service,
+			COALESCE(AVG(NULLIF(location_rating, 0)), 0) AS avg_location,
+			COALESCE(AVG(NULLIF(value_rating, 0)), 0) AS avg_value,
+			COALESCE(AVG(NULLIF(comfort_rating, 0)), 0) AS avg_comfort`).
+		Scopes(publicReviews).
+		Where("room_id = ?", roomID).
+		Scan(&rating).Error
+	if err != nil {
+		return err
+	}
+	rating.RoomID = roomID
+	return tx.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&rating).Error
+}
+
+// publicReviews leaves out reviews hidden by a moderator.
+func publicReviews(db *gorm.DB) *gorm.DB {
+	return db.Where("reviews.status <> ?", constant.REVIEW_STATUS_HIDDEN)
+}
+
+func (r *reviewRepository) SearchReviews(ctx context.Context, query dto.ReviewQuery) ([]models.Review, error) {
+	var reviews []models.Review
+	tx := r.db.WithContext(ctx).Model(&models.Review{}).Preload("User").Preload("Room", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	})
+
+	if query.RoomID != 0 {
+		tx = tx.Where("room_id = ?", query.RoomID)
+	}
+	if query.Rating != 0 {
+		tx = tx.Where("rating = ?", query.Rating)
+	}
+	if query.Status != "" {
+		tx = tx.Where("status = ?", query.Status)
+	}
+	if query.PropertyID != constant.AllProperties {
+		// Archived rooms still count, so the subquery reads the table directly.
+		tx = tx.Where("room_id IN (?)", r.db.Table("rooms").Select("id").Where("property_id = ?", query.PropertyID))
+	}
+
+	err := tx.Order("created_at DESC").Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *reviewRepository) FindReviewByID(ctx context.Context, id uint) (*models.Review, error) {
+	var review models.Review
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Room", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Preload("ModeratedBy").
+		Preload("RespondedBy").
+		Preload("Photos").
+		Preload("Revisions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC")
+		}).
+		First(&review, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) UpdateReviewModerationTx(ctx context.Context, tx *gorm.DB, review *models.Review) error {
+	return tx.WithContext(ctx).Model(review).
+		Select("Status", "ModerationReason", "ModeratedByID", "ModeratedAt").
+		Updates(review).Error
+}
+
+func (r *reviewRepository) UpdateReviewResponse(ctx context.Context, review *models.Review) error {
+	return r.db.WithContext(ctx).Model(review).
+		Select("ManagementResponse", "RespondedByID", "RespondedAt").
+		Updates(review).Error
+}
+
+func (r *reviewRepository) GetDB() *gorm.DB {
+	return r.db
+}
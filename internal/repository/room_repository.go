@@ -2,14 +2,18 @@ package repository
 
 import (
 	"context"
+	"hotel-management/internal/constant"
 	"hotel-management/internal/dto"
 	"hotel-management/internal/models"
+	"strconv"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
 type RoomRepository interface {
-	FindAvailableRoom(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest) ([]models.Room, error)
+	FindAvailableRoom(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest, page dto.PageQuery) ([]models.Room, int64, error)
+	GetSearchFacets(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest) (*dto.SearchFacets, error)
 	CreateRoom(ctx context.Context, room *models.Room) error
 	CreateRoomImage(ctx context.Context, roomImage *models.RoomImage) error
 	GetAllRooms(ctx context.Context) ([]models.Room, error)
@@ -22,9 +26,26 @@ type RoomRepository interface {
 	DeleteRoomImageTx(ctx context.Context, tx *gorm.DB, id int) error
 	DeleteRoomTx(ctx context.Context, tx *gorm.DB, id int) error
 	DeleteRoom(ctx context.Context, id int) error
+	GetArchivedRooms(ctx context.Context, propertyID uint) ([]models.Room, error)
+	FindRoomsByNames(ctx context.Context, propertyID uint, names []string) ([]models.Room, error)
+	GetRoomsWithDetails(ctx context.Context, propertyID uint) ([]models.Room, error)
+	FindRoomPropertyID(ctx context.Context, id int) (*uint, error)
+	RestoreRoom(ctx context.Context, id int) error
 	FindRoomImageByRoomID(ctx context.Context, id int) ([]models.RoomImage, error)
 	DeleteRoomImagesByRoomIDTx(ctx context.Context, tx *gorm.DB, roomID int) error
 	SearchRooms(ctx context.Context, query dto.RoomQuery) ([]models.Room, error)
+	ReplaceRoomAmenitiesTx(ctx context.Context, tx *gorm.DB, room *models.Room, amenities []models.Amenity) error
+	UpdateRoomImageTx(ctx context.Context, tx *gorm.DB, roomImage *models.RoomImage) error
+	SetPrimaryRoomImageTx(ctx context.Context, tx *gorm.DB, roomID uint, imageID uint) error
+	ListPublicRooms(ctx context.Context, query dto.PublicRoomQuery) ([]models.Room, int64, error)
+	FindPublicRoomByID(ctx context.Context, id uint) (*models.Room, error)
+	GetRoomTypeSummaries(ctx context.Context) ([]dto.RoomTypeSummary, error)
+	FindCoverImageByRoomType(ctx context.Context, roomType string) (*models.RoomImage, error)
+	FindRoomByNumber(ctx context.Context, propertyID uint, number string) (*models.Room, error)
+	FindRoomsByNumbers(ctx context.Context, propertyID uint, numbers []string) ([]models.Room, error)
+	FindRoomsByIDs(ctx context.Context, ids []uint) ([]models.Room, error)
+	ReplaceConnectingRoomsTx(ctx context.Context, tx *gorm.DB, roomID uint, connectedIDs []uint) error
+	FindConnectingRoomPairs(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest) ([]models.RoomConnection, error)
 }
 
 type roomRepository struct {
@@ -35,39 +56,161 @@ func NewRoomRepository(db *gorm.DB) RoomRepository {
 	return &roomRepository{db: db}
 }
 
-func (r *roomRepository) FindAvailableRoom(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest) ([]models.Room, error) {
+func (r *roomRepository) FindAvailableRoom(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest, page dto.PageQuery) ([]models.Room, int64, error) {
 	var rooms []models.Room
+	var total int64
+	if err := r.availableRoomsQuery(ctx, searchRoomRequest).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	db := r.availableRoomsQuery(ctx, searchRoomRequest).
+		Select("rooms.*").
+		Preload("Property").
+		Preload("ConnectingRooms").
+		Preload("Images", orderedRoomImages).
+		Preload("Amenities.Translations")
+	for _, order := range roomSortOrder(searchRoomRequest.Sort) {
+		db = db.Order(order)
+	}
+	if err := db.Offset(page.Offset()).Limit(page.Limit).Find(&rooms).Error; err != nil {
+		return nil, 0, err
+	}
+	return rooms, total, nil
+}
+
+func (r *roomRepository) GetSearchFacets(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest) (*dto.SearchFacets, error) {
+	facets := &dto.SearchFacets{}
+	columns := []struct {
+		expr   string
+		target *[]dto.FacetCount
+	}{
+		{"rooms.view_type", &facets.ViewTypes},
+		{"rooms.type", &facets.Types},
+		{"CAST(rooms.bed_num AS CHAR)", &facets.BedNums},
+		{"CASE WHEN rooms.has_aircon THEN 'true' ELSE 'false' END", &facets.HasAircon},
+	}
+	for _, column := range columns {
+		err := r.availableRoomsQuery(ctx, searchRoomRequest).
+			Select(column.expr + " AS value, COUNT(*) AS count").
+			Group("value").
+			Order("value").
+			Scan(column.target).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return facets, nil
+}
+
+// availableRoomsQuery applies every search filter. It returns a fresh query on
+// each call so count, page and facet queries do not share state.
+func (r *roomRepository) availableRoomsQuery(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest) *gorm.DB {
 	subQuery := r.db.
 		Model(&models.BookingRoom{}).
 		Select("booking_rooms.room_id").
 		Joins("JOIN bookings ON bookings.id = booking_rooms.booking_id").
 		Where("(? < bookings.end_date) AND (? > bookings.start_date)", searchRoomRequest.StartDate, searchRoomRequest.EndDate).
-		Where("bookings.booking_status IN ?", []string{"booked", "checked_in"})
+		Where("bookings.booking_status IN ?", constant.OccupyingBookingStatuses)
 
 	db := r.db.WithContext(ctx).
 		Model(&models.Room{}).
-		Preload("Images").
-		Where("is_available = ?", true).
-		Where("id NOT IN (?)", subQuery)
+		Joins("LEFT JOIN room_ratings ON room_ratings.room_id = rooms.id").
+		Where("rooms.is_available = ?", true).
+		Where("rooms.property_id IN (?)", activePropertiesSubQuery(r.db, propertyFilter(searchRoomRequest.PropertyID), searchRoomRequest.City)).
+		Where("rooms.id NOT IN (?)", subQuery).
+		Where("rooms.id NOT IN (?)", blockedRoomsSubQuery(r.db))
 
 	if searchRoomRequest.BedNum != nil {
-		db = db.Where("bed_num = ?", *searchRoomRequest.BedNum)
+		db = db.Where("rooms.bed_num = ?", *searchRoomRequest.BedNum)
 	}
 	if searchRoomRequest.HasAircon != nil {
-		db = db.Where("has_aircon = ?", *searchRoomRequest.HasAircon)
+		db = db.Where("rooms.has_aircon = ?", *searchRoomRequest.HasAircon)
 	}
+	viewTypes := searchRoomRequest.ViewTypes
 	if searchRoomRequest.ViewType != nil {
-		db = db.Where("view_type = ?", *searchRoomRequest.ViewType)
+		viewTypes = append(viewTypes, *searchRoomRequest.ViewType)
+	}
+	if len(viewTypes) > 0 {
+		db = db.Where("rooms.view_type IN ?", viewTypes)
+	}
+	if searchRoomRequest.MinPrice != nil {
+		db = db.Where("rooms.price_per_night >= ?", *searchRoomRequest.MinPrice)
+	}
+	if searchRoomRequest.MaxPrice != nil {
+		db = db.Where("rooms.price_per_night <= ?", *searchRoomRequest.MaxPrice)
 	}
-	if searchRoomRequest.MinPrice != nil && searchRoomRequest.MaxPrice != nil {
-		db = db.Where("price_per_night BETWEEN ? AND ?", *searchRoomRequest.MinPrice, *searchRoomRequest.MaxPrice)
+	if searchRoomRequest.MinRating != nil {
+		db = db.Where("COALESCE(room_ratings.avg_rating, 0) >= ?", *searchRoomRequest.MinRating)
 	}
+	if keyword := strings.TrimSpace(searchRoomRequest.Keyword); keyword != "" {
+		pattern := "%" + escapeLike(keyword) + "%"
+		db = db.Where("(rooms.name LIKE ? OR rooms.description LIKE ?)", pattern, pattern)
+	}
+	if len(searchRoomRequest.AmenityIDs) > 0 {
+		// Rooms must have every requested amenity, not just one of them.
+		amenityQuery := r.db.
+			Table("room_amenities").
+			Select("room_id").
+			Where("amenity_id IN ?", searchRoomRequest.AmenityIDs).
+			Group("room_id").
+			Having("COUNT(DISTINCT amenity_id) = ?", len(searchRoomRequest.AmenityIDs))
+		db = db.Where("rooms.id IN (?)", amenityQuery)
+	}
+	return db
+}
 
-	if err := db.Find(&rooms).Error; err != nil {
-		return nil, err
+// inProperty limits a room query to one property; 0 means every property.
+func inProperty(propertyID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if propertyID == constant.AllProperties {
+			return db
+		}
+		return db.Where("rooms.property_id = ?", propertyID)
+	}
+}
+
+// activePropertiesSubQuery selects the IDs of the active properties guests
+// can book, optionally narrowed to one property or one city.
+func activePropertiesSubQuery(db *gorm.DB, propertyID uint, city string) *gorm.DB {
+	query := db.Model(&models.Property{}).Select("id").Where("is_active = ?", true)
+	if propertyID != constant.AllProperties {
+		query = query.Where("id = ?", propertyID)
+	}
+	if city = strings.TrimSpace(city); city != "" {
+		query = query.Where("city = ?", city)
+	}
+	return query
+}
+
+func propertyFilter(propertyID *uint) uint {
+	if propertyID == nil {
+		return constant.AllProperties
+	}
+	return *propertyID
+}
+
+func roomSortOrder(sort string) []string {
+	switch sort {
+	case constant.ROOM_SORT_PRICE_ASC:
+		return []string{"rooms.price_per_night ASC", "rooms.id"}
+	case constant.ROOM_SORT_PRICE_DESC:
+		return []string{"rooms.price_per_night DESC", "rooms.id"}
+	case constant.ROOM_SORT_RATING_DESC:
+		return []string{"COALESCE(room_ratings.avg_rating, 0) DESC", "COALESCE(room_ratings.review_count, 0) DESC", "rooms.id"}
+	case constant.ROOM_SORT_CAPACITY_ASC:
+		return []string{"rooms.bed_num ASC", "rooms.price_per_night ASC", "rooms.id"}
+	case constant.ROOM_SORT_CAPACITY_DESC:
+		return []string{"rooms.bed_num DESC", "rooms.price_per_night ASC", "rooms.id"}
+	default:
+		return []string{"rooms.id"}
 	}
-	return rooms, nil
 }
+
+// escapeLike escapes the LIKE wildcards in user input.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
 func (r *roomRepository) CreateRoom(ctx context.Context, room *models.Room) error {
 	err := r.db.WithContext(ctx).Create(room).Error
 	if err != nil {
@@ -125,10 +268,84 @@ func (r *roomRepository) DeleteRoom(ctx context.Context, id int) error {
 	}
 	return nil
 }
+
+// GetArchivedRooms lists soft-deleted rooms, most recently archived first.
+func (r *roomRepository) GetArchivedRooms(ctx context.Context, propertyID uint) ([]models.Room, error) {
+	var rooms []models.Room
+	err := r.db.WithContext(ctx).Unscoped().
+		Scopes(inProperty(propertyID)).
+		Preload("Property").
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&rooms).Error
+	if err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+func (r *roomRepository) FindRoomsByNames(ctx context.Context, propertyID uint, names []string) ([]models.Room, error) {
+	var rooms []models.Room
+	err := r.db.WithContext(ctx).
+		Scopes(inProperty(propertyID)).
+		Preload("Images", orderedRoomImages).
+		Preload("Amenities").
+		Where("name IN ?", names).
+		Order("id").
+		Find(&rooms).Error
+	if err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+// GetRoomsWithDetails loads every active room of a property (or of all
+// properties when propertyID is 0) with its images and amenities, for
+// exporting the inventory.
+func (r *roomRepository) GetRoomsWithDetails(ctx context.Context, propertyID uint) ([]models.Room, error) {
+	var rooms []models.Room
+	err := r.db.WithContext(ctx).
+		Scopes(inProperty(propertyID)).
+		Preload("Property").
+		Preload("ConnectingRooms").
+		Preload("Images", orderedRoomImages).
+		Preload("Amenities").
+		Order("id").
+		Find(&rooms).Error
+	if err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+// FindRoomPropertyID returns the property of a room, archived or not.
+func (r *roomRepository) FindRoomPropertyID(ctx context.Context, id int) (*uint, error) {
+	var room models.Room
+	err := r.db.WithContext(ctx).Unscoped().Select("id", "property_id").First(&room, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return room.PropertyID, nil
+}
+
+func (r *roomRepository) RestoreRoom(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Unscoped().
+		Model(&models.Room{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (r *roomRepository) UpdateRoomTx(ctx context.Context, tx *gorm.DB, room *models.Room) error {
 	err := tx.Model(&room).Select(
-		"Name", "Type", "PricePerNight", "BedNum", "HasAircon",
-		"ViewType", "Description", "IsAvailable",
+		"PropertyID", "Number", "Floor", "Building", "Name", "Type", "PricePerNight",
+		"BedNum", "HasAircon", "ViewType", "Description", "IsAvailable",
 	).Updates(&room).Error
 	if err != nil {
 		return err
@@ -147,7 +364,7 @@ func (r *roomRepository) GetAllRooms(ctx context.Context) ([]models.Room, error)
 
 func (r *roomRepository) FindRoomByID(ctx context.Context, id int) (*models.Room, error) {
 	var room models.Room
-	err := r.db.WithContext(ctx).Preload("Images").Where("id = ?", id).First(&room).Error
+	err := r.db.WithContext(ctx).Preload("Property").Preload("ConnectingRooms").Preload("Images", orderedRoomImages).Preload("Amenities").Where("id = ?", id).First(&room).Error
 	if err != nil {
 		return nil, err
 	}
@@ -178,10 +395,16 @@ func (r *roomRepository) FindRoomImageByRoomID(ctx context.Context, id int) ([]m
 
 func (r *roomRepository) SearchRooms(ctx context.Context, query dto.RoomQuery) ([]models.Room, error) {
 	var rooms []models.Room
-	tx := r.db.WithContext(ctx)
+	tx := r.db.WithContext(ctx).Scopes(inProperty(query.PropertyID)).Preload("Property").Preload("ConnectingRooms")
 
 	if query.Name != "" {
-		tx = tx.Where("name LIKE ?", "%"+query.Name+"%")
+		tx = tx.Where("(name LIKE ? OR number LIKE ?)", "%"+query.Name+"%", "%"+query.Name+"%")
+	}
+	if floor, err := strconv.Atoi(query.Floor); err == nil {
+		tx = tx.Where("floor = ?", floor)
+	}
+	if query.Building != "" {
+		tx = tx.Where("building = ?", query.Building)
 	}
 
 	if query.HasAircon == "true" {
@@ -197,6 +420,205 @@ func (r *roomRepository) SearchRooms(ctx context.Context, query dto.RoomQuery) (
 		tx = tx.Where("price_per_night <= ?", query.MaxPrice)
 	}
 
-	err := tx.Order("created_at DESC").Find(&rooms).Error
+	if query.GroupBy == constant.ROOM_GROUP_BY_FLOOR {
+		tx = tx.Order("building, floor, number, id")
+	} else {
+		tx = tx.Order("created_at DESC")
+	}
+	err := tx.Find(&rooms).Error
 	return rooms, err
 }
+
+func (r *roomRepository) ReplaceRoomAmenitiesTx(ctx context.Context, tx *gorm.DB, room *models.Room, amenities []models.Amenity) error {
+	return tx.WithContext(ctx).Model(room).Association("Amenities").Replace(amenities)
+}
+
+func (r *roomRepository) UpdateRoomImageTx(ctx context.Context, tx *gorm.DB, roomImage *models.RoomImage) error {
+	return tx.WithContext(ctx).Model(roomImage).Select("SortOrder", "IsPrimary", "AltText").Updates(roomImage).Error
+}
+
+// SetPrimaryRoomImageTx marks imageID as the room's primary image and clears
+// the flag on every other image of the room.
+func (r *roomRepository) SetPrimaryRoomImageTx(ctx context.Context, tx *gorm.DB, roomID uint, imageID uint) error {
+	return tx.WithContext(ctx).
+		Model(&models.RoomImage{}).
+		Where("room_id = ?", roomID).
+		Update("is_primary", gorm.Expr("id = ?", imageID)).Error
+}
+
+func orderedRoomImages(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order, id")
+}
+
+func (r *roomRepository) ListPublicRooms(ctx context.Context, query dto.PublicRoomQuery) ([]models.Room, int64, error) {
+	var rooms []models.Room
+	var total int64
+	db := r.db.WithContext(ctx).Model(&models.Room{}).
+		Where("is_available = ?", true).
+		Where("rooms.property_id IN (?)", activePropertiesSubQuery(r.db, query.PropertyID, query.City))
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := db.
+		Preload("Property").
+		Preload("ConnectingRooms").
+		Preload("Images", orderedRoomImages).
+		Preload("Amenities.Translations").
+		Order("price_per_night, id").
+		Offset(query.Offset()).
+		Limit(query.Limit).
+		Find(&rooms).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return rooms, total, nil
+}
+
+func (r *roomRepository) FindPublicRoomByID(ctx context.Context, id uint) (*models.Room, error) {
+	var room models.Room
+	err := r.db.WithContext(ctx).
+		Preload("Property").
+		Preload("ConnectingRooms").
+		Preload("Images", orderedRoomImages).
+		Preload("Amenities.Translations").
+		Where("id = ? AND is_available = ?", id, true).
+		Where("rooms.property_id IN (?)", activePropertiesSubQuery(r.db, constant.AllProperties, "")).
+		First(&room).Error
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+func (r *roomRepository) GetRoomTypeSummaries(ctx context.Context) ([]dto.RoomTypeSummary, error) {
+	var summaries []dto.RoomTypeSummary
+	err := r.db.WithContext(ctx).
+		Model(&models.Room{}).
+		Select("type, COUNT(*) AS room_count, MIN(price_per_night) AS min_price, MAX(price_per_night) AS max_price").
+		Where("is_available = ?", true).
+		Where("rooms.property_id IN (?)", activePropertiesSubQuery(r.db, constant.AllProperties, "")).
+		Group("type").
+		Order("min_price").
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// FindCoverImageByRoomType picks the primary image of the cheapest room of
+// the given type that has one.
+func (r *roomRepository) FindCoverImageByRoomType(ctx context.Context, roomType string) (*models.RoomImage, error) {
+	var image models.RoomImage
+	err := r.db.WithContext(ctx).
+		Joins("JOIN rooms ON rooms.id = room_images.room_id AND rooms.deleted_at IS NULL").
+		Where("rooms.type = ? AND rooms.is_available = ?", roomType, true).
+		Order("room_images.is_primary DESC, rooms.price_per_night, room_images.sort_order, room_images.id").
+		First(&image).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// FindRoomByNumber also finds archived rooms, which keep their number.
+func (r *roomRepository) FindRoomByNumber(ctx context.Context, propertyID uint, number string) (*models.Room, error) {
+	var room models.Room
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("property_id = ? AND number = ?", propertyID, number).
+		First(&room).Error
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// FindRoomsByNumbers also finds archived rooms, which keep their number.
+func (r *roomRepository) FindRoomsByNumbers(ctx context.Context, propertyID uint, numbers []string) ([]models.Room, error) {
+	var rooms []models.Room
+	err := r.db.WithContext(ctx).Unscoped().
+		Preload("Images", orderedRoomImages).
+		Preload("Amenities").
+		Where("property_id = ? AND number IN ?", propertyID, numbers).
+		Find(&rooms).Error
+	if err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+func (r *roomRepository) FindRoomsByIDs(ctx context.Context, ids []uint) ([]models.Room, error) {
+	var rooms []models.Room
+	if len(ids) == 0 {
+		return rooms, nil
+	}
+	err := r.db.WithContext(ctx).
+		Preload("Property").
+		Preload("ConnectingRooms").
+		Preload("Images", orderedRoomImages).
+		Preload("Amenities.Translations").
+		Where("id IN ?", ids).
+		Find(&rooms).Error
+	if err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+// ReplaceConnectingRoomsTx stores each connection in both directions, so a
+// room's connecting rooms can be read from either side.
+func (r *roomRepository) ReplaceConnectingRoomsTx(ctx context.Context, tx *gorm.DB, roomID uint, connectedIDs []uint) error {
+	err := tx.WithContext(ctx).
+		Where("room_id = ? OR connected_room_id = ?", roomID, roomID).
+		Delete(&models.RoomConnection{}).Error
+	if err != nil {
+		return err
+	}
+	if len(connectedIDs) == 0 {
+		return nil
+	}
+	connections := make([]models.RoomConnection, 0, len(connectedIDs)*2)
+	for _, id := range connectedIDs {
+		connections = append(connections,
+			models.RoomConnection{RoomID: roomID, ConnectedRoomID: id},
+			models.RoomConnection{RoomID: id, ConnectedRoomID: roomID},
+		)
+	}
+	return tx.WithContext(ctx).Create(&connections).Error
+}
+
+// FindConnectingRoomPairs lists pairs of connecting rooms that are both free
+// and match the search, cheapest pair first. Each pair is returned once, with
+// the lower room ID first. A requested bed count applies to the pair.
+func (r *roomRepository) FindConnectingRoomPairs(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest) ([]models.RoomConnection, error) {
+	perRoom := *searchRoomRequest
+	perRoom.BedNum = nil
+	available := func() *gorm.DB {
+		return r.availableRoomsQuery(ctx, &perRoom).Select("rooms.id")
+	}
+
+	db := r.db.WithContext(ctx).
+		Model(&models.RoomConnection{}).
+		Joins("JOIN rooms AS first_rooms ON first_rooms.id = room_connections.room_id").
+		Joins("JOIN rooms AS second_rooms ON second_rooms.id = room_connections.connected_room_id").
+		Where("room_connections.room_id < room_connections.connected_room_id").
+		Where("room_connections.room_id IN (?)", available()).
+		Where("room_connections.connected_room_id IN (?)", available())
+	if searchRoomRequest.BedNum != nil {
+		db = db.Where("first_rooms.bed_num + second_rooms.bed_num >= ?", *searchRoomRequest.BedNum)
+	}
+
+	var pairs []models.RoomConnection
+	err := db.
+		Select("room_connections.room_id, room_connections.connected_room_id").
+		Order("first_rooms.price_per_night + second_rooms.price_per_night, room_connections.room_id").
+		Limit(constant.MaxConnectingRoomSuggestions).
+		Find(&pairs).Error
+	if err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
@@ -10,7 +10,7 @@ import (
 )
 
 type StatRepository interface {
-	GetDashboardStatistics(ctx context.Context) (*dto.StatisticDashboard, error)
+	GetDashboardStatistics(ctx context.Context, propertyID uint) (*dto.StatisticDashboard, error)
 }
 
 type statRepository struct {
@@ -20,31 +20,55 @@ type statRepository struct {
 func NewStatRepository(db *gorm.DB) StatRepository {
 	return &statRepository{db: db}
 }
-func (r *statRepository) GetDashboardStatistics(ctx context.Context) (*dto.StatisticDashboard, error) {
+
+// GetDashboardStatistics sums up one property, or every property when
+// propertyID is 0. For a single property only customers who booked there are
+// counted.
+func (r *statRepository) GetDashboardStatistics(ctx context.Context, propertyID uint) (*dto.StatisticDashboard, error) {
 	var stat dto.StatisticDashboard
+	bookings := func() *gorm.DB {
+		db := r.db.WithContext(ctx).Model(&models.Booking{})
+		if propertyID != constant.AllProperties {
+			db = db.Where("property_id = ?", propertyID)
+		}
+		return db
+	}
 
-	if err := r.db.WithContext(ctx).Model(&models.Room{}).Count(&stat.TotalRooms).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&models.Room{}).
+		Scopes(inProperty(propertyID)).
+		Count(&stat.TotalRooms).Error; err != nil {
 		return nil, err
 	}
 
-	if err := r.db.WithContext(ctx).Model(&models.User{}).
-		Where("role = ?", constant.CUSTOMER).
-		Count(&stat.TotalCustomers).Error; err != nil {
+	customers := r.db.WithContext(ctx).Model(&models.User{}).Where("role = ?", constant.CUSTOMER)
+	if propertyID != constant.AllProperties {
+		customers = customers.Where("id IN (?)", bookings().Select("user_id"))
+	}
+	if err := customers.Count(&stat.TotalCustomers).Error; err != nil {
 		return nil, err
 	}
 
-	if err := r.db.WithContext(ctx).Model(&models.Booking{}).
-		Count(&stat.TotalBookings).Error; err != nil {
+	if err := bookings().Count(&stat.TotalBookings).Error; err != nil {
 		return nil, err
 	}
 
-	if err := r.db.WithContext(ctx).
-		Model(&models.Booking{}).
+	if err := bookings().
 		Select("SUM(total_price)").
 		Where("is_paid = ?", true).
 		Scan(&stat.TotalRevenue).Error; err != nil {
 		return nil, err
 	}
 
+	refunds := r.db.WithContext(ctx).Model(&models.Refund{}).
+		Joins("JOIN payments ON payments.id = refunds.payment_id").
+		Where("refunds.status = ?", constant.REFUND_SUCCESS)
+	if propertyID != constant.AllProperties {
+		refunds = refunds.Where("payments.booking_id IN (?)", bookings().Select("id"))
+	}
+	if err := refunds.Select("COALESCE(SUM(refunds.amount), 0)").Scan(&stat.TotalRefunded).Error; err != nil {
+		return nil, err
+	}
+	stat.TotalRevenue -= stat.TotalRefunded
+
 	return &stat, nil
 }
@@ -15,6 +15,8 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
 	GetAll(ctx context.Context) ([]models.User, error)
 	GetAllCustomers(ctx context.Context) ([]models.User, error)
+	GetStaffByPropertyID(ctx context.Context, propertyID uint) ([]models.User, error)
+	IsStaffOfProperty(ctx context.Context, userID uint, propertyID uint) (bool, error)
 	DeleteUser(ctx context.Context, id int) error
 }
 
@@ -70,6 +72,31 @@ func (r *userRepository) GetAllCustomers(ctx context.Context) ([]models.User, er
 	return customers, nil
 }
 
+// GetStaffByPropertyID returns the staff accounts assigned to a property.
+func (r *userRepository) GetStaffByPropertyID(ctx context.Context, propertyID uint) ([]models.User, error) {
+	var staffs []models.User
+	err := r.db.WithContext(ctx).
+		Joins("JOIN property_staff ON property_staff.user_id = users.id").
+		Where("property_staff.property_id = ? AND users.role = ?", propertyID, constant.STAFF).
+		Order("users.name").
+		Find(&staffs).Error
+	if err != nil {
+		return nil, err
+	}
+	return staffs, nil
+}
+
+func (r *userRepository) IsStaffOfProperty(ctx context.Context, userID uint, propertyID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("property_staff").
+		Where("user_id = ? AND property_id = ?", userID, propertyID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 func (r *userRepository) DeleteUser(ctx context.Context, id int) error {
 	return r.db.WithContext(ctx).Delete(&models.User{}, id).Error
 }
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	"hotel-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type WorkOrderRepository interface {
+	CreateWorkOrderTx(ctx context.Context, tx *gorm.DB, workOrder *models.WorkOrder) error
+	CreateWorkOrderPhotoTx(ctx context.Context, tx *gorm.DB, photo *models.WorkOrderPhoto) error
+	GetWorkOrderByID(ctx context.Context, id uint) (*models.WorkOrder, error)
+	UpdateWorkOrder(ctx context.Context, workOrder *models.WorkOrder) error
+	SearchWorkOrders(ctx context.Context, query dto.WorkOrderQuery) ([]models.WorkOrder, error)
+	GetDB() *gorm.DB
+}
+
+type workOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkOrderRepository(db *gorm.DB) WorkOrderRepository {
+	return &workOrderRepository{db: db}
+}
+
+func (r *workOrderRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *workOrderRepository) CreateWorkOrderTx(ctx context.Context, tx *gorm.DB, workOrder *models.WorkOrder) error {
+	return tx.WithContext(ctx).Create(workOrder).Error
+}
+
+func (r *workOrderRepository) CreateWorkOrderPhotoTx(ctx context.Context, tx *gorm.DB, photo *models.WorkOrderPhoto) error {
+	return tx.WithContext(ctx).Create(photo).Error
+}
+
+func (r *workOrderRepository) GetWorkOrderByID(ctx context.Context, id uint) (*models.WorkOrder, error) {
+	var workOrder models.WorkOrder
+	err := r.db.WithContext(ctx).
+		Preload("Room").
+		Preload("Reporter").
+		Preload("Assignee").
+		Preload("Photos").
+		First(&workOrder, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &workOrder, nil
+}
+
+func (r *workOrderRepository) UpdateWorkOrder(ctx context.Context, workOrder *models.WorkOrder) error {
+	return r.db.WithContext(ctx).Model(workOrder).Select(
+		"AssigneeID", "Status", "BlocksRoom", "ResolutionNote", "ResolvedAt", "ClosedAt",
+	).Updates(workOrder).Error
+}
+
+func (r *workOrderRepository) SearchWorkOrders(ctx context.Context, query dto.WorkOrderQuery) ([]models.WorkOrder, error) {
+	var workOrders []models.WorkOrder
+	tx := r.db.WithContext(ctx).Model(&models.WorkOrder{}).Preload("Room").Preload("Assignee")
+
+	if query.RoomID != 0 {
+		tx = tx.Where("room_id = ?", query.RoomID)
+	}
+	if query.Status != "" {
+		tx = tx.Where("status = ?", query.Status)
+	}
+	if query.Priority != "" {
+		tx = tx.Where("priority = ?", query.Priority)
+	}
+	if query.Category != "" {
+		tx = tx.Where("category = ?", query.Category)
+	}
+	if query.AssigneeID != 0 {
+		tx = tx.Where("assignee_id = ?", query.AssigneeID)
+	}
+	if query.PropertyID != constant.AllProperties {
+		// Archived rooms still count, so the subquery reads the table directly.
+		tx = tx.Where("room_id IN (?)", r.db.Table("rooms").Select("id").Where("property_id = ?", query.PropertyID))
+	}
+
+	err := tx.Order("created_at DESC").Find(&workOrders).Error
+	return workOrders, err
+}
+
+// blockedRoomsSubQuery selects rooms taken out of service by an unfinished
+// work order that blocks availability.
+func blockedRoomsSubQuery(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.WorkOrder{}).
+		Select("work_orders.room_id").
+		Where("work_orders.blocks_room = ?", true).
+		Where("work_orders.status NOT IN ?", constant.FinishedWorkOrderStatuses)
+}
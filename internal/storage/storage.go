@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hotel-management/internal/constant"
+	"os"
+	"strings"
+)
+
+const (
+	DRIVER_LOCAL = "local"
+	DRIVER_S3    = "s3"
+)
+
+var ErrObjectNotFound = errors.New("storage: object not found")
+
+// Storage stores uploaded files under a key such as "rooms/12/abc_large.jpg"
+// and knows the public URL each key is served from.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete removes the object. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+	// KeyFromURL reverses URL. ok is false for URLs that do not belong to
+	// this storage, e.g. images hosted elsewhere.
+	KeyFromURL(url string) (key string, ok bool)
+}
+
+// NewStorageFromEnv builds the backend selected by STORAGE_DRIVER. Local disk
+// is used when the variable is empty.
+func NewStorageFromEnv() (Storage, error) {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE_DRIVER")))
+	switch driver {
+	case "", DRIVER_LOCAL:
+		return NewLocalStorage(
+			envOrDefault("STORAGE_LOCAL_DIR", constant.UploadDir),
+			envOrDefault("STORAGE_LOCAL_BASE_URL", constant.ImageURL),
+		), nil
+	case DRIVER_S3:
+		return NewS3Storage(S3Config{
+			Endpoint:     os.Getenv("S3_ENDPOINT"),
+			Region:       envOrDefault("S3_REGION", "us-east-1"),
+			Bucket:       os.Getenv("S3_BUCKET"),
+			AccessKey:    os.Getenv("S3_ACCESS_KEY"),
+			SecretKey:    os.Getenv("S3_SECRET_KEY"),
+			PublicURL:    os.Getenv("S3_PUBLIC_URL"),
+			UsePathStyle: os.Getenv("S3_USE_PATH_STYLE") != "false",
+		})
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", driver)
+	}
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
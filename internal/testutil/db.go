@@ -0,0 +1,65 @@
+// Package testutil holds helpers shared by tests that need a database.
+package testutil
+
+import (
+	"fmt"
+	"hotel-management/internal/models"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// NewDB opens a private in-memory SQLite database with every model
+// migrated. Row locks are ignored by SQLite, so tests exercise the logic of
+// the locked sections rather than the locking itself.
+func NewDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared&_pragma=foreign_keys(0)", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	err = db.AutoMigrate(
+		&models.Property{},
+		&models.User{},
+		&models.Room{},
+		&models.RoomConnection{},
+		&models.RoomImage{},
+		&models.RatePlan{},
+		&models.Booking{},
+		&models.BookingRoom{},
+		&models.BookingCharge{},
+		&models.Review{},
+		&models.ReviewPhoto{},
+		&models.ReviewRevision{},
+		&models.RoomRating{},
+		&models.Bill{},
+		&models.Shift{},
+		&models.Payment{},
+		&models.PaymentAllocation{},
+		&models.PaymentCallback{},
+		&models.Refund{},
+		&models.PaymentReconciliation{},
+		&models.PaymentReconciliationEntry{},
+		&models.IdempotencyKey{},
+		&models.WorkOrder{},
+		&models.WorkOrderPhoto{},
+		&models.Amenity{},
+		&models.AmenityTranslation{},
+	)
+	if err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,132 @@
+package admin_usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type AmenityUseCase struct {
+	amenityRepo repository.AmenityRepository
+}
+
+func NewAmenityUseCase(amenityRepo repository.AmenityRepository) *AmenityUseCase {
+	return &AmenityUseCase{amenityRepo: amenityRepo}
+}
+
+func (u *AmenityUseCase) GetAllAmenities(ctx context.Context) ([]models.Amenity, error) {
+	amenities, err := u.amenityRepo.GetAllAmenities(ctx)
+	if err != nil {
+		return nil, appError.ErrFailedToGetAmenity
+	}
+	return amenities, nil
+}
+
+func (u *AmenityUseCase) GetAmenityByID(ctx context.Context, id int) (*models.Amenity, error) {
+	amenity, err := u.amenityRepo.FindAmenityByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appError.ErrAmenityNotFound
+		}
+		return nil, appError.ErrFailedToGetAmenity
+	}
+	return amenity, nil
+}
+
+func (u *AmenityUseCase) CreateAmenity(ctx context.Context, req *dto.AmenityRequest) error {
+	if err := u.validateAmenity(ctx, req, 0); err != nil {
+		return err
+	}
+	amenity := &models.Amenity{
+		Code:     req.Code,
+		Name:     req.Name,
+		Icon:     req.Icon,
+		Category: req.Category,
+	}
+
+	db := u.amenityRepo.GetDB()
+	return utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if err := u.amenityRepo.CreateAmenityTx(ctx, tx, amenity); err != nil {
+			return appError.ErrFailedToSaveAmenity
+		}
+		if err := u.amenityRepo.ReplaceAmenityTranslationsTx(ctx, tx, amenity.ID, buildTranslations(req.Translations)); err != nil {
+			return appError.ErrFailedToSaveAmenity
+		}
+		return nil
+	})
+}
+
+func (u *AmenityUseCase) UpdateAmenity(ctx context.Context, id int, req *dto.AmenityRequest) error {
+	amenity, err := u.GetAmenityByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := u.validateAmenity(ctx, req, amenity.ID); err != nil {
+		return err
+	}
+	amenity.Code = req.Code
+	amenity.Name = req.Name
+	amenity.Icon = req.Icon
+	amenity.Category = req.Category
+
+	db := u.amenityRepo.GetDB()
+	return utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if err := u.amenityRepo.UpdateAmenityTx(ctx, tx, amenity); err != nil {
+			return appError.ErrFailedToSaveAmenity
+		}
+		if err := u.amenityRepo.ReplaceAmenityTranslationsTx(ctx, tx, amenity.ID, buildTranslations(req.Translations)); err != nil {
+			return appError.ErrFailedToSaveAmenity
+		}
+		return nil
+	})
+}
+
+func (u *AmenityUseCase) DeleteAmenity(ctx context.Context, id int) error {
+	if _, err := u.GetAmenityByID(ctx, id); err != nil {
+		return err
+	}
+	db := u.amenityRepo.GetDB()
+	return utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if err := u.amenityRepo.DeleteAmenityTx(ctx, tx, id); err != nil {
+			return appError.ErrFailedToDeleteAmenity
+		}
+		return nil
+	})
+}
+
+func (u *AmenityUseCase) validateAmenity(ctx context.Context, req *dto.AmenityRequest, currentID uint) error {
+	if req.Code == "" || req.Name == "" {
+		return errors.New("error.invalid_request")
+	}
+	if !constant.IsValidAmenityCategory(req.Category) {
+		return appError.ErrInvalidAmenityCategory
+	}
+	existing, err := u.amenityRepo.FindAmenityByCode(ctx, req.Code)
+	if err == nil && existing.ID != currentID {
+		return appError.ErrAmenityCodeExists
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return appError.ErrFailedToGetAmenity
+	}
+	return nil
+}
+
+func buildTranslations(names map[string]string) []models.AmenityTranslation {
+	var translations []models.AmenityTranslation
+	for _, lang := range constant.TranslatedLanguages {
+		name := strings.TrimSpace(names[lang])
+		if name == "" {
+			continue
+		}
+		translations = append(translations, models.AmenityTranslation{Lang: lang, Name: name})
+	}
+	return translations
+}
@@ -0,0 +1,166 @@
+package admin_usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/gateway"
+	"hotel-management/internal/models"
+	"hotel-management/internal/utils"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// GetPendingTransfers lists the bank transfers of the property still waiting
+// for the money to arrive, with the reference each transfer must carry.
+func (u *PaymentUseCase) GetPendingTransfers(ctx context.Context, propertyID uint) ([]models.Payment, map[uint]string, error) {
+	payments, err := u.paymentRepo.SearchPayments(ctx, propertyID, dto.PaymentFilter{
+		PaymentMethod: constant.PAYMENT_METHOD_BANK_TRANSFER,
+		PaymentStatus: constant.PAYMENT_PENDING,
+	})
+	if err != nil {
+		return nil, nil, appError.ErrFailedToGetPayment
+	}
+	references := make(map[uint]string, len(payments))
+	for _, payment := range payments {
+		references[payment.ID] = gateway.TransferReference(payment.TxnRef)
+	}
+	return payments, references, nil
+}
+
+// ConfirmBankTransfer records that a transfer showed up on the hotel's
+// account. reference is the bank's transaction ID.
+func (u *PaymentUseCase) ConfirmBankTransfer(ctx context.Context, paymentID uint, propertyID uint, amount float64, reference string) error {
+	payment, err := u.paymentRepo.GetPaymentDetail(ctx, paymentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return appError.ErrPaymentNotFound
+	}
+	if err != nil {
+		return appError.ErrFailedToGetPayment
+	}
+	if !paymentInProperty(payment, propertyID) {
+		return appError.ErrPropertyAccessDenied
+	}
+	if payment.PaymentMethod != constant.PAYMENT_METHOD_BANK_TRANSFER {
+		return appError.ErrPaymentNotBankTransfer
+	}
+	return u.callbacks.HandleCallback(ctx, payment.PaymentMethod, transferParams(payment.TxnRef, amount, reference))
+}
+
+// ImportBankStatement confirms the transfers listed on a bank statement. A
+// line is matched to a payment by the transfer reference in its description,
+// then settled like a staff confirmation would, so the amount must be the
+// one asked for. Lines are settled one by one: a line that fails does not
+// stop the others, and importing a statement again only reports the
+// transfers already recorded as duplicates.
+func (u *PaymentUseCase) ImportBankStatement(ctx context.Context, req *dto.BankStatementImportRequest) (*dto.BankStatementReport, error) {
+	records, err := utils.ReadSpreadsheet(req.FileName, req.Data)
+	if err != nil {
+		return nil, err
+	}
+	records = dropBlankRecords(records)
+	if len(records) < 2 {
+		return nil, appError.ErrImportEmpty
+	}
+	if len(records)-1 > constant.MaxImportRows {
+		return nil, appError.ErrImportTooManyRows
+	}
+	columns := importColumnIndex(records[0])
+	for _, column := range constant.BankStatementColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, appError.ErrImportMissingColumns
+		}
+	}
+
+	report := &dto.BankStatementReport{}
+	for i, record := range records[1:] {
+		row := dto.BankStatementRowResult{
+			// The header is line 1, so data starts on line 2.
+			Line:          i + 2,
+			TransactionID: importCell(record, columns, constant.STATEMENT_COLUMN_TRANSACTION_ID),
+			Description:   importCell(record, columns, constant.STATEMENT_COLUMN_DESCRIPTION),
+		}
+		u.matchStatementRow(ctx, req.PropertyID, &row, importCell(record, columns, constant.STATEMENT_COLUMN_AMOUNT))
+		switch row.Outcome {
+		case constant.STATEMENT_ROW_MATCHED:
+			report.Matched++
+		case constant.STATEMENT_ROW_DUPLICATE:
+			report.Duplicate++
+		case constant.STATEMENT_ROW_UNMATCHED:
+			report.Unmatched++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	return report, nil
+}
+
+// matchStatementRow settles the payment a statement line pays, if any, and
+// sets the line's outcome. Lines without a transfer reference, such as
+// transfers unrelated to bookings or money going out, are left unmatched.
+func (u *PaymentUseCase) matchStatementRow(ctx context.Context, propertyID uint, row *dto.BankStatementRowResult, amountCell string) {
+	amount, err := parseStatementAmount(amountCell)
+	if err != nil {
+		row.Outcome, row.Error = constant.STATEMENT_ROW_FAILED, err.Error()
+		return
+	}
+	row.Amount = amount
+	prefix, ok := gateway.ParseTransferReference(row.Description)
+	if !ok || amount <= 0 {
+		row.Outcome = constant.STATEMENT_ROW_UNMATCHED
+		return
+	}
+	payment, err := u.paymentRepo.GetPaymentByTxnRefPrefix(ctx, constant.PAYMENT_METHOD_BANK_TRANSFER, prefix)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		row.Outcome, row.Error = constant.STATEMENT_ROW_UNMATCHED, appError.ErrTransferNotMatched.Error()
+		return
+	}
+	if err != nil {
+		row.Outcome, row.Error = constant.STATEMENT_ROW_FAILED, appError.ErrFailedToGetPayment.Error()
+		return
+	}
+	row.PaymentID, row.BookingID = payment.ID, payment.BookingID
+	if !paymentInProperty(payment, propertyID) {
+		row.Outcome, row.Error = constant.STATEMENT_ROW_FAILED, appError.ErrPropertyAccessDenied.Error()
+		return
+	}
+
+	params := transferParams(payment.TxnRef, amount, row.TransactionID)
+	params.Set(gateway.BANK_TRANSFER_PARAM_DESCRIPTION, row.Description)
+	err = u.callbacks.HandleCallback(ctx, payment.PaymentMethod, params)
+	switch {
+	case err == nil:
+		row.Outcome = constant.STATEMENT_ROW_MATCHED
+	case errors.Is(err, appError.ErrPaymentAlreadyProcessed):
+		row.Outcome = constant.STATEMENT_ROW_DUPLICATE
+	default:
+		row.Outcome, row.Error = constant.STATEMENT_ROW_FAILED, err.Error()
+	}
+}
+
+func transferParams(txnRef string, amount float64, reference string) url.Values {
+	params := url.Values{}
+	params.Set(gateway.MANUAL_PARAM_TXN_REF, txnRef)
+	params.Set(gateway.MANUAL_PARAM_AMOUNT, strconv.FormatInt(int64(math.Round(amount)), 10))
+	params.Set(gateway.MANUAL_PARAM_REFERENCE, strings.TrimSpace(reference))
+	return params
+}
+
+// parseStatementAmount reads an amount in dong. Banks export amounts with
+// thousands separators ("1,500,000") and sometimes the currency.
+func parseStatementAmount(value string) (float64, error) {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(value)), "VND"))
+	value = strings.NewReplacer(",", "", " ", "").Replace(value)
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, appError.ErrInvalidStatementAmount
+	}
+	return math.Round(amount), nil
+}
@@ -17,9 +17,9 @@ func NewBillUseCase(billRepository repository.BillRepository) *BillUseCase {
 	return &BillUseCase{billRepository: billRepository}
 }
 
-func (u *BillUseCase) GetFilteredBills(ctx context.Context, userName string, bookingID int, exportDate string) ([]models.Bill, error) {
+func (u *BillUseCase) GetFilteredBills(ctx context.Context, propertyID uint, userName string, bookingID int, exportDate string) ([]models.Bill, error) {
 	var bills []models.Bill
-	bills, err := u.billRepository.SearchBills(ctx, userName, bookingID, exportDate)
+	bills, err := u.billRepository.SearchBills(ctx, propertyID, userName, bookingID, exportDate)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return bills, errors.New("error.bill_not_found")
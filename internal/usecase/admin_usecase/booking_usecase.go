@@ -3,9 +3,14 @@ package admin_usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
 	"hotel-management/internal/models"
 	"hotel-management/internal/repository"
+	"hotel-management/internal/utils"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -30,6 +35,22 @@ func (u *BookingUseCase) GetBookingDetail(ctx context.Context, id uint) (*models
 	return u.bookingRepo.GetBookingByID(ctx, id)
 }
 
+// CheckBookingAccess reports whether a booking belongs to the property being
+// managed. A propertyID of 0 gives access to every booking.
+func (u *BookingUseCase) CheckBookingAccess(ctx context.Context, id uint, propertyID uint) error {
+	booking, err := u.bookingRepo.GetBookingByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("error.booking_not_found")
+		}
+		return errors.New("error.failed_to_get_booking")
+	}
+	if propertyID != constant.AllProperties && (booking.PropertyID == nil || *booking.PropertyID != propertyID) {
+		return appError.ErrPropertyAccessDenied
+	}
+	return nil
+}
+
 func (u *BookingUseCase) UpdateBookingStatus(ctx context.Context, bookingID uint, status string) error {
 	booking, err := u.bookingRepo.GetBookingByID(ctx, bookingID)
 	if err != nil {
@@ -58,9 +79,9 @@ func (u *BookingUseCase) UpdateBookingStatus(ctx context.Context, bookingID uint
 	return nil
 }
 
-func (u *BookingUseCase) SearchBookings(ctx context.Context, userName, bookingStatus string) ([]models.Booking, error) {
+func (u *BookingUseCase) SearchBookings(ctx context.Context, propertyID uint, userName, bookingStatus string) ([]models.Booking, error) {
 	var bookings []models.Booking
-	bookings, err := u.bookingRepo.SearchBookings(ctx, userName, bookingStatus)
+	bookings, err := u.bookingRepo.SearchBookings(ctx, propertyID, userName, bookingStatus)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("error.booking_not_found")
@@ -69,3 +90,72 @@ func (u *BookingUseCase) SearchBookings(ctx context.Context, userName, bookingSt
 	}
 	return bookings, nil
 }
+
+// AddCharge bills an extra on a booking that is under way or just checked
+// out. The charge is added to the booking's balance.
+func (u *BookingUseCase) AddCharge(ctx context.Context, bookingID uint, req *dto.CreateChargeRequest, createdBy uint) error {
+	return utils.WithTransaction(u.bookingRepo.GetDB(), func(tx *gorm.DB) error {
+		booking, err := u.bookingRepo.GetBookingByIDForUpdateTx(ctx, tx, bookingID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("error.booking_not_found")
+		}
+		if err != nil {
+			return errors.New("error.failed_to_get_booking")
+		}
+		switch booking.BookingStatus {
+		case constant.BOOKED, constant.CHECKED_IN, constant.CHECKED_OUT:
+		default:
+			return appError.ErrBookingNotChargeable
+		}
+		charge := &models.BookingCharge{
+			BookingID:   booking.ID,
+			Description: strings.TrimSpace(req.Description),
+			Amount:      req.Amount,
+			CreatedBy:   createdBy,
+		}
+		if err := u.bookingRepo.AddChargeTx(ctx, tx, booking, charge); err != nil {
+			return appError.ErrFailedToAddCharge
+		}
+		return nil
+	})
+}
+
+// GetLineItems lists the rooms and charges of a booking with what its
+// successful payments allocated to each of them.
+func (u *BookingUseCase) GetLineItems(booking *models.Booking, payments []models.Payment) []dto.BookingLineItem {
+	paidRooms := make(map[uint]float64)
+	paidCharges := make(map[uint]float64)
+	for _, payment := range payments {
+		if payment.PaymentStatus != constant.PAYMENT_SUCCESS {
+			continue
+		}
+		for _, allocation := range payment.Allocations {
+			if allocation.BookingRoomID != nil {
+				paidRooms[*allocation.BookingRoomID] += allocation.Amount
+			}
+			if allocation.BookingChargeID != nil {
+				paidCharges[*allocation.BookingChargeID] += allocation.Amount
+			}
+		}
+	}
+	items := make([]dto.BookingLineItem, 0, len(booking.BookingRooms)+len(booking.Charges))
+	for _, room := range booking.BookingRooms {
+		items = append(items, dto.BookingLineItem{
+			Type:        constant.LINE_ITEM_ROOM,
+			ID:          room.ID,
+			Description: fmt.Sprintf("%s x %d", room.Room.Name, booking.Nights()),
+			Amount:      booking.RoomAmount(room),
+			Paid:        paidRooms[room.ID],
+		})
+	}
+	for _, charge := range booking.Charges {
+		items = append(items, dto.BookingLineItem{
+			Type:        constant.LINE_ITEM_CHARGE,
+			ID:          charge.ID,
+			Description: charge.Description,
+			Amount:      charge.Amount,
+			Paid:        paidCharges[charge.ID],
+		})
+	}
+	return items
+}
@@ -0,0 +1,117 @@
+package admin_usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/gateway"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"net/url"
+
+	"gorm.io/gorm"
+)
+
+// PaymentCallbackHandler settles payments from a gateway callback, through
+// the same path as the notifications providers send.
+type PaymentCallbackHandler interface {
+	HandleCallback(ctx context.Context, method string, params url.Values) error
+}
+
+type PaymentUseCase struct {
+	paymentRepo repository.PaymentRepository
+	bookingRepo repository.BookingRepository
+	gateways    *gateway.Registry
+	callbacks   PaymentCallbackHandler
+}
+
+func NewPaymentUseCase(paymentRepo repository.PaymentRepository, bookingRepo repository.BookingRepository, gateways *gateway.Registry, callbacks PaymentCallbackHandler) *PaymentUseCase {
+	return &PaymentUseCase{paymentRepo: paymentRepo, bookingRepo: bookingRepo, gateways: gateways, callbacks: callbacks}
+}
+
+// PaymentMethods lists the methods payments can be filtered by.
+func (u *PaymentUseCase) PaymentMethods() []string {
+	return u.gateways.Methods()
+}
+
+func (u *PaymentUseCase) ListPayments(ctx context.Context, propertyID uint, filter dto.PaymentFilter) ([]models.Payment, error) {
+	payments, err := u.paymentRepo.SearchPayments(ctx, propertyID, filter)
+	if err != nil {
+		return nil, appError.ErrFailedToGetPayment
+	}
+	return payments, nil
+}
+
+// ListOverpaidBookings lists the bookings of the property that were paid
+// more than their total, for an admin to refund the excess. This happens when
+// a payment the guest gave up on still succeeds after they paid again.
+func (u *PaymentUseCase) ListOverpaidBookings(ctx context.Context, propertyID uint) ([]models.Booking, error) {
+	bookings, err := u.bookingRepo.GetOverpaidBookings(ctx, propertyID)
+	if err != nil {
+		return nil, appError.ErrFailedToGetBooking
+	}
+	return bookings, nil
+}
+
+// ListStuckPayments lists the pending payments of the property that the
+// reconciler could not look up and gave up on.
+func (u *PaymentUseCase) ListStuckPayments(ctx context.Context, propertyID uint) ([]models.Payment, error) {
+	payments, err := u.paymentRepo.GetStuckPayments(ctx, propertyID)
+	if err != nil {
+		return nil, appError.ErrFailedToGetPayment
+	}
+	return payments, nil
+}
+
+// RetryReconcile hands a stuck payment of the property back to the
+// reconciler, for instance once the gateway is reachable again.
+func (u *PaymentUseCase) RetryReconcile(ctx context.Context, id uint, propertyID uint) error {
+	payment, err := u.paymentRepo.GetPaymentDetail(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return appError.ErrPaymentNotFound
+	}
+	if err != nil {
+		return appError.ErrFailedToGetPayment
+	}
+	if !paymentInProperty(payment, propertyID) {
+		return appError.ErrPropertyAccessDenied
+	}
+	if payment.PaymentStatus != constant.PAYMENT_PENDING {
+		return appError.ErrPaymentAlreadyProcessed
+	}
+	if err := u.paymentRepo.ResetReconcileErrors(ctx, payment.ID); err != nil {
+		return appError.ErrFailedToUpdatePayment
+	}
+	return nil
+}
+
+// GetPaymentDetail loads a payment of the property being managed with the
+// callbacks received for it.
+func (u *PaymentUseCase) GetPaymentDetail(ctx context.Context, id uint, propertyID uint) (*models.Payment, []models.PaymentCallback, error) {
+	payment, err := u.paymentRepo.GetPaymentDetail(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, appError.ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, nil, appError.ErrFailedToGetPayment
+	}
+	if !paymentInProperty(payment, propertyID) {
+		return nil, nil, appError.ErrPropertyAccessDenied
+	}
+	callbacks, err := u.paymentRepo.GetPaymentCallbacks(ctx, payment.TxnRef)
+	if err != nil {
+		return nil, nil, appError.ErrFailedToGetPayment
+	}
+	return payment, callbacks, nil
+}
+
+// paymentInProperty reports whether a payment, loaded with its booking, is
+// for the property being managed.
+func paymentInProperty(payment *models.Payment, propertyID uint) bool {
+	if propertyID == constant.AllProperties {
+		return true
+	}
+	return payment.Booking.PropertyID != nil && *payment.Booking.PropertyID == propertyID
+}
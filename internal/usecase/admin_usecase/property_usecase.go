@@ -0,0 +1,211 @@
+package admin_usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/utils"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+type PropertyUseCase struct {
+	propertyRepo repository.PropertyRepository
+}
+
+func NewPropertyUseCase(propertyRepo repository.PropertyRepository) *PropertyUseCase {
+	return &PropertyUseCase{propertyRepo: propertyRepo}
+}
+
+func (u *PropertyUseCase) GetAllProperties(ctx context.Context) ([]models.Property, error) {
+	properties, err := u.propertyRepo.GetAllProperties(ctx)
+	if err != nil {
+		return nil, appError.ErrFailedToGetProperty
+	}
+	return properties, nil
+}
+
+func (u *PropertyUseCase) GetPropertyByID(ctx context.Context, id uint) (*models.Property, error) {
+	property, err := u.propertyRepo.FindPropertyByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appError.ErrPropertyNotFound
+		}
+		return nil, appError.ErrFailedToGetProperty
+	}
+	return property, nil
+}
+
+func (u *PropertyUseCase) GetStaffAccounts(ctx context.Context) ([]models.User, error) {
+	staff, err := u.propertyRepo.GetStaffAccounts(ctx)
+	if err != nil {
+		return nil, appError.ErrFailedToGetProperty
+	}
+	return staff, nil
+}
+
+func (u *PropertyUseCase) CreateProperty(ctx context.Context, req *dto.PropertyRequest) error {
+	if err := u.validateProperty(ctx, req, 0); err != nil {
+		return err
+	}
+	staff, err := u.findStaff(ctx, req.StaffIDs)
+	if err != nil {
+		return err
+	}
+	property := &models.Property{}
+	applyPropertyRequest(property, req)
+
+	return utils.WithTransaction(u.propertyRepo.GetDB(), func(tx *gorm.DB) error {
+		if err := u.propertyRepo.CreatePropertyTx(ctx, tx, property); err != nil {
+			return appError.ErrFailedToSaveProperty
+		}
+		if err := u.propertyRepo.ReplacePropertyStaffTx(ctx, tx, property, staff); err != nil {
+			return appError.ErrFailedToSaveProperty
+		}
+		return nil
+	})
+}
+
+func (u *PropertyUseCase) UpdateProperty(ctx context.Context, id uint, req *dto.PropertyRequest) error {
+	property, err := u.GetPropertyByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := u.validateProperty(ctx, req, property.ID); err != nil {
+		return err
+	}
+	staff, err := u.findStaff(ctx, req.StaffIDs)
+	if err != nil {
+		return err
+	}
+	applyPropertyRequest(property, req)
+
+	return utils.WithTransaction(u.propertyRepo.GetDB(), func(tx *gorm.DB) error {
+		if err := u.propertyRepo.UpdatePropertyTx(ctx, tx, property); err != nil {
+			return appError.ErrFailedToSaveProperty
+		}
+		if err := u.propertyRepo.ReplacePropertyStaffTx(ctx, tx, property, staff); err != nil {
+			return appError.ErrFailedToSaveProperty
+		}
+		return nil
+	})
+}
+
+// GetAccessibleProperties returns every property for admins and the assigned
+// properties for staff.
+func (u *PropertyUseCase) GetAccessibleProperties(ctx context.Context, userID uint, role string) ([]models.Property, error) {
+	var properties []models.Property
+	var err error
+	if role == constant.ADMIN {
+		properties, err = u.propertyRepo.GetAllProperties(ctx)
+	} else {
+		properties, err = u.propertyRepo.GetPropertiesByStaffID(ctx, userID)
+	}
+	if err != nil {
+		return nil, appError.ErrFailedToGetProperty
+	}
+	return properties, nil
+}
+
+// ResolveCurrentProperty picks the property an admin page works on: the one
+// stored in the session when the user may access it, otherwise the first
+// accessible property. Admins may also work on all properties at once.
+func (u *PropertyUseCase) ResolveCurrentProperty(ctx context.Context, userID uint, role string, requested uint) (uint, error) {
+	properties, err := u.GetAccessibleProperties(ctx, userID, role)
+	if err != nil {
+		return 0, err
+	}
+	if role == constant.ADMIN && requested == constant.AllProperties {
+		return constant.AllProperties, nil
+	}
+	for _, property := range properties {
+		if property.ID == requested {
+			return requested, nil
+		}
+	}
+	if role == constant.ADMIN {
+		return constant.AllProperties, nil
+	}
+	if len(properties) == 0 {
+		return 0, appError.ErrNoPropertyAssigned
+	}
+	return properties[0].ID, nil
+}
+
+// CheckPropertyAccess reports whether the user may manage propertyID.
+func (u *PropertyUseCase) CheckPropertyAccess(ctx context.Context, userID uint, role string, propertyID uint) error {
+	if propertyID == constant.AllProperties {
+		return appError.ErrPropertyRequired
+	}
+	properties, err := u.GetAccessibleProperties(ctx, userID, role)
+	if err != nil {
+		return err
+	}
+	for _, property := range properties {
+		if property.ID == propertyID {
+			return nil
+		}
+	}
+	return appError.ErrPropertyAccessDenied
+}
+
+func (u *PropertyUseCase) GetSwitcher(ctx context.Context, userID uint, role string, current uint) (*dto.PropertySwitcher, error) {
+	properties, err := u.GetAccessibleProperties(ctx, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	switcher := &dto.PropertySwitcher{
+		CurrentID:  current,
+		AllowAll:   role == constant.ADMIN,
+		Properties: make([]dto.PropertyResponse, 0, len(properties)),
+	}
+	for _, property := range properties {
+		switcher.Properties = append(switcher.Properties, dto.NewPropertyResponse(property))
+	}
+	return switcher, nil
+}
+
+func (u *PropertyUseCase) validateProperty(ctx context.Context, req *dto.PropertyRequest, currentID uint) error {
+	if req.Code == "" || req.Name == "" || req.City == "" {
+		return errors.New("error.invalid_request")
+	}
+	existing, err := u.propertyRepo.FindPropertyByCode(ctx, req.Code)
+	if err == nil && existing.ID != currentID {
+		return appError.ErrPropertyCodeExists
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return appError.ErrFailedToGetProperty
+	}
+	return nil
+}
+
+func (u *PropertyUseCase) findStaff(ctx context.Context, ids []uint) ([]models.User, error) {
+	staff, err := u.propertyRepo.GetStaffAccounts(ctx)
+	if err != nil {
+		return nil, appError.ErrFailedToGetProperty
+	}
+	selected := make([]models.User, 0, len(ids))
+	for _, user := range staff {
+		if slices.Contains(ids, user.ID) {
+			selected = append(selected, user)
+		}
+	}
+	if len(selected) != len(ids) {
+		return nil, appError.ErrInvalidStaffIDs
+	}
+	return selected, nil
+}
+
+func applyPropertyRequest(property *models.Property, req *dto.PropertyRequest) {
+	property.Code = req.Code
+	property.Name = req.Name
+	property.City = req.City
+	property.Address = req.Address
+	property.Phone = req.Phone
+	property.IsActive = req.IsActive
+}
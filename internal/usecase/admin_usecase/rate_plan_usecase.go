@@ -0,0 +1,106 @@
+package admin_usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type RatePlanUseCase struct {
+	ratePlanRepo repository.RatePlanRepository
+	propertyRepo repository.PropertyRepository
+}
+
+func NewRatePlanUseCase(ratePlanRepo repository.RatePlanRepository, propertyRepo repository.PropertyRepository) *RatePlanUseCase {
+	return &RatePlanUseCase{ratePlanRepo: ratePlanRepo, propertyRepo: propertyRepo}
+}
+
+func (u *RatePlanUseCase) GetRatePlans(ctx context.Context, propertyID uint) ([]models.RatePlan, error) {
+	ratePlans, err := u.ratePlanRepo.GetRatePlans(ctx, propertyID, false)
+	if err != nil {
+		return nil, appError.ErrFailedToGetRatePlan
+	}
+	return ratePlans, nil
+}
+
+func (u *RatePlanUseCase) GetProperties(ctx context.Context) ([]models.Property, error) {
+	properties, err := u.propertyRepo.GetAllProperties(ctx)
+	if err != nil {
+		return nil, appError.ErrFailedToGetProperty
+	}
+	return properties, nil
+}
+
+func (u *RatePlanUseCase) GetRatePlan(ctx context.Context, id uint) (*models.RatePlan, error) {
+	ratePlan, err := u.ratePlanRepo.FindRatePlanByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, appError.ErrRatePlanNotFound
+	}
+	if err != nil {
+		return nil, appError.ErrFailedToGetRatePlan
+	}
+	return ratePlan, nil
+}
+
+func (u *RatePlanUseCase) CreateRatePlan(ctx context.Context, req *dto.RatePlanRequest) error {
+	ratePlan := &models.RatePlan{}
+	if err := u.fill(ctx, ratePlan, req); err != nil {
+		return err
+	}
+	if err := u.ratePlanRepo.CreateRatePlan(ctx, ratePlan); err != nil {
+		return appError.ErrFailedToSaveRatePlan
+	}
+	return nil
+}
+
+// UpdateRatePlan changes a plan for new bookings only; existing bookings
+// keep the payment timing they were made with.
+func (u *RatePlanUseCase) UpdateRatePlan(ctx context.Context, id uint, req *dto.RatePlanRequest) error {
+	ratePlan, err := u.GetRatePlan(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := u.fill(ctx, ratePlan, req); err != nil {
+		return err
+	}
+	if err := u.ratePlanRepo.UpdateRatePlan(ctx, ratePlan); err != nil {
+		return appError.ErrFailedToSaveRatePlan
+	}
+	return nil
+}
+
+func (u *RatePlanUseCase) fill(ctx context.Context, ratePlan *models.RatePlan, req *dto.RatePlanRequest) error {
+	if req.PropertyID == 0 || strings.TrimSpace(req.Name) == "" {
+		return errors.New("error.invalid_request")
+	}
+	if !constant.IsValidPaymentTiming(req.PaymentTiming) {
+		return appError.ErrInvalidPaymentTiming
+	}
+	depositPercent := 0
+	if req.PaymentTiming == constant.PAYMENT_TIMING_DEPOSIT {
+		if req.DepositPercent < constant.MinDepositPercent || req.DepositPercent > constant.MaxDepositPercent {
+			return appError.ErrInvalidDepositPercent
+		}
+		depositPercent = req.DepositPercent
+	}
+	if _, err := u.propertyRepo.FindPropertyByID(ctx, req.PropertyID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appError.ErrPropertyNotFound
+		}
+		return appError.ErrFailedToGetRatePlan
+	}
+	ratePlan.PropertyID = req.PropertyID
+	ratePlan.Name = strings.TrimSpace(req.Name)
+	ratePlan.Description = strings.TrimSpace(req.Description)
+	ratePlan.PaymentTiming = req.PaymentTiming
+	ratePlan.DepositPercent = depositPercent
+	ratePlan.IsActive = req.IsActive
+	return nil
+}
@@ -0,0 +1,27 @@
+package admin_usecase
+
+import (
+	"context"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+)
+
+// reconciliationReportRuns is how many recent runs the report shows.
+const reconciliationReportRuns = 20
+
+type ReconciliationUseCase struct {
+	reconciliationRepo repository.ReconciliationRepository
+}
+
+func NewReconciliationUseCase(reconciliationRepo repository.ReconciliationRepository) *ReconciliationUseCase {
+	return &ReconciliationUseCase{reconciliationRepo: reconciliationRepo}
+}
+
+func (u *ReconciliationUseCase) ListReconciliations(ctx context.Context) ([]models.PaymentReconciliation, error) {
+	runs, err := u.reconciliationRepo.ListReconciliations(ctx, reconciliationReportRuns)
+	if err != nil {
+		return nil, appError.ErrFailedToGetReconciliations
+	}
+	return runs, nil
+}
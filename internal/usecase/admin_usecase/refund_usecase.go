@@ -0,0 +1,258 @@
+package admin_usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/gateway"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/utils"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RefundUseCase struct {
+	paymentRepo repository.PaymentRepository
+	refundRepo  repository.RefundRepository
+	bookingRepo repository.BookingRepository
+	billRepo    repository.BillRepository
+	gateways    *gateway.Registry
+}
+
+func NewRefundUseCase(paymentRepo repository.PaymentRepository, refundRepo repository.RefundRepository, bookingRepo repository.BookingRepository, billRepo repository.BillRepository, gateways *gateway.Registry) *RefundUseCase {
+	return &RefundUseCase{paymentRepo: paymentRepo, refundRepo: refundRepo, bookingRepo: bookingRepo, billRepo: billRepo, gateways: gateways}
+}
+
+func (u *RefundUseCase) GetBookingPayments(ctx context.Context, bookingID uint) ([]models.Payment, error) {
+	payments, err := u.paymentRepo.GetPaymentsByBookingID(ctx, bookingID)
+	if err != nil {
+		return nil, appError.ErrFailedToGetPayment
+	}
+	return payments, nil
+}
+
+// StartRefund refunds part or all of a successful payment of the booking
+// through the payment's gateway. The refund is saved as pending before the
+// gateway is called, so two admins cannot refund the same money twice, and
+// is then marked successful, or failed when the gateway rejects it. A refund
+// whose outcome the gateway did not give stays pending, with
+// ErrRefundPending, until SettlePendingRefunds learns it. A successful
+// refund is added to the booking's refunded amount and to its bill.
+func (u *RefundUseCase) StartRefund(ctx context.Context, bookingID uint, req *dto.CreateRefundRequest, createdBy uint, clientIP string) (*models.Refund, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, appError.ErrRefundReasonRequired
+	}
+
+	var payment *models.Payment
+	refund := &models.Refund{
+		PaymentID: req.PaymentID,
+		Reason:    reason,
+		Status:    constant.REFUND_PENDING,
+		CreatedBy: createdBy,
+	}
+	err := utils.WithTransaction(u.refundRepo.GetDB(), func(tx *gorm.DB) error {
+		var err error
+		payment, err = u.paymentRepo.GetPaymentByIDForUpdateTx(ctx, tx, req.PaymentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && payment.BookingID != bookingID) {
+			return appError.ErrPaymentNotFound
+		}
+		if err != nil {
+			return appError.ErrFailedToGetPayment
+		}
+		if payment.PaymentStatus != constant.PAYMENT_SUCCESS {
+			return appError.ErrPaymentNotRefundable
+		}
+		refunded, err := u.refundRepo.SumOpenRefundsTx(ctx, tx, payment.ID)
+		if err != nil {
+			return appError.ErrFailedToCreateRefund
+		}
+		refundable := payment.Amount - refunded
+		if refundable <= 0 {
+			return appError.ErrPaymentFullyRefunded
+		}
+
+		switch req.Type {
+		case constant.REFUND_TYPE_FULL:
+			refund.Amount = refundable
+		case constant.REFUND_TYPE_PARTIAL:
+			refund.Amount = math.Round(req.Amount)
+			if refund.Amount <= 0 || refund.Amount > refundable {
+				return appError.ErrInvalidRefundAmount
+			}
+		default:
+			return appError.ErrInvalidRefundType
+		}
+		if err := u.refundRepo.CreateRefundTx(ctx, tx, refund); err != nil {
+			return appError.ErrFailedToCreateRefund
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := u.executeRefund(ctx, payment, refund, createdBy, clientIP)
+	if err != nil {
+		// A timeout or an answer that could not be read says nothing about
+		// whether the provider made the refund. It stays pending until
+		// SettlePendingRefunds looks it up.
+		log.Printf("refund %d of payment %d has an unknown outcome: %v", refund.ID, payment.ID, err)
+		return refund, appError.ErrRefundPending
+	}
+	if result.Status == constant.PAYMENT_PENDING {
+		return refund, appError.ErrRefundPending
+	}
+	refund, err = u.finishRefund(ctx, refund.ID, result)
+	if err != nil {
+		return nil, err
+	}
+	switch refund.Status {
+	case constant.REFUND_SUCCESS:
+		return refund, nil
+	case constant.REFUND_PENDING:
+		return refund, appError.ErrRefundPending
+	default:
+		return refund, appError.ErrRefundFailed
+	}
+}
+
+// RefundSettlement counts what SettlePendingRefunds did.
+type RefundSettlement struct {
+	Checked int
+	Settled int
+	Failed  int
+	Pending int
+	Errors  int
+}
+
+// SettlePendingRefunds looks up refunds whose outcome is still unknown with
+// their gateway and records the ones the gateway has decided.
+func (u *RefundUseCase) SettlePendingRefunds(ctx context.Context) (*RefundSettlement, error) {
+	before := time.Now().Add(-constant.RefundQueryAfterMinutes * time.Minute)
+	refunds, err := u.refundRepo.GetPendingRefundsBefore(ctx, before, constant.ReconcileBatchSize)
+	if err != nil {
+		return nil, appError.ErrFailedToGetPayment
+	}
+	run := &RefundSettlement{}
+	for i := range refunds {
+		refund := &refunds[i]
+		run.Checked++
+		result, err := u.queryRefund(ctx, refund)
+		if err != nil {
+			log.Printf("query refund %d of payment %d: %v", refund.ID, refund.PaymentID, err)
+			run.Errors++
+			continue
+		}
+		if result.Status == constant.PAYMENT_PENDING {
+			run.Pending++
+			continue
+		}
+		settled, err := u.finishRefund(ctx, refund.ID, result)
+		if err != nil {
+			log.Printf("settle refund %d of payment %d: %v", refund.ID, refund.PaymentID, err)
+			run.Errors++
+			continue
+		}
+		switch settled.Status {
+		case constant.REFUND_SUCCESS:
+			run.Settled++
+		case constant.REFUND_FAILED:
+			run.Failed++
+		}
+	}
+	return run, nil
+}
+
+// finishRefund records the gateway's final answer on a pending refund. A
+// refund settled in the meantime, by the admin's request or by
+// SettlePendingRefunds, is returned unchanged. A successful refund is added
+// to the booking and to the bill of its payment.
+func (u *RefundUseCase) finishRefund(ctx context.Context, refundID uint, result *gateway.RefundResult) (*models.Refund, error) {
+	var refund *models.Refund
+	err := utils.WithTransaction(u.refundRepo.GetDB(), func(tx *gorm.DB) error {
+		var err error
+		refund, err = u.refundRepo.GetRefundByIDForUpdateTx(ctx, tx, refundID)
+		if err != nil {
+			return appError.ErrFailedToUpdateRefund
+		}
+		if refund.Status != constant.REFUND_PENDING {
+			return nil
+		}
+		now := time.Now()
+		refund.ProcessedAt = &now
+		refund.Status = constant.REFUND_FAILED
+		if result.Status == constant.PAYMENT_SUCCESS {
+			refund.Status = constant.REFUND_SUCCESS
+			refund.ProviderRef = result.RefundID
+		}
+		if err := u.refundRepo.UpdateRefundTx(ctx, tx, refund); err != nil {
+			return appError.ErrFailedToUpdateRefund
+		}
+		if refund.Status != constant.REFUND_SUCCESS {
+			return nil
+		}
+		// The payment may have been invoiced while the gateway was called. A
+		// payment not invoiced yet carries its refunds onto its bill when the
+		// bill is issued.
+		current, err := u.paymentRepo.GetPaymentByIDForUpdateTx(ctx, tx, refund.PaymentID)
+		if err != nil {
+			return appError.ErrFailedToUpdateRefund
+		}
+		if err := u.bookingRepo.AddRefundTx(ctx, tx, current.BookingID, refund.Amount); err != nil {
+			return appError.ErrFailedToUpdateRefund
+		}
+		if current.BillID != nil {
+			if err := u.billRepo.AddRefundTx(ctx, tx, *current.BillID, refund.Amount); err != nil {
+				return appError.ErrFailedToUpdateRefund
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (u *RefundUseCase) queryRefund(ctx context.Context, refund *models.Refund) (*gateway.RefundResult, error) {
+	paymentGateway, err := u.gateways.Get(refund.Payment.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+	return paymentGateway.QueryRefund(ctx, refundRequest(&refund.Payment, refund, "", refund.CreatedBy))
+}
+
+func (u *RefundUseCase) executeRefund(ctx context.Context, payment *models.Payment, refund *models.Refund, createdBy uint, clientIP string) (*gateway.RefundResult, error) {
+	paymentGateway, err := u.gateways.Get(payment.PaymentMethod)
+	if err != nil {
+		// The refund was never sent, so it definitely failed.
+		log.Printf("refund %d of payment %d: %v", refund.ID, payment.ID, err)
+		return &gateway.RefundResult{Status: constant.PAYMENT_FAILED}, nil
+	}
+	return paymentGateway.Refund(ctx, refundRequest(payment, refund, clientIP, createdBy))
+}
+
+func refundRequest(payment *models.Payment, refund *models.Refund, clientIP string, createdBy uint) gateway.RefundRequest {
+	return gateway.RefundRequest{
+		Transaction: gateway.Transaction{
+			TxnRef:        payment.TxnRef,
+			TransactionID: payment.TransactionID,
+			Amount:        int64(payment.Amount),
+			CreatedAt:     payment.CreatedAt,
+			ClientIP:      clientIP,
+		},
+		RefundAmount: int64(refund.Amount),
+		Full:         refund.Amount == payment.Amount,
+		Reason:       refund.Reason,
+		CreatedBy:    strconv.FormatUint(uint64(createdBy), 10),
+	}
+}
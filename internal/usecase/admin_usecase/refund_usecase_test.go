@@ -0,0 +1,317 @@
+package admin_usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/gateway"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/testutil"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const testPaymentAmount = 1_000_000
+
+// lostAnswerGateway is the fake provider with an unreliable line: refunds
+// reach the provider, or not when dropped is set, but the answer is lost or
+// only says the refund is being processed.
+type lostAnswerGateway struct {
+	*gateway.FakeGateway
+	dropped bool
+	status  string
+}
+
+func (g *lostAnswerGateway) Refund(ctx context.Context, req gateway.RefundRequest) (*gateway.RefundResult, error) {
+	if !g.dropped {
+		g.FakeGateway.Refund(ctx, req)
+	}
+	if g.status != "" {
+		return &gateway.RefundResult{Status: g.status}, nil
+	}
+	return nil, errors.New("read: connection reset by peer")
+}
+
+func newTestRefundUseCase(t *testing.T, paymentGateway gateway.Gateway) (*RefundUseCase, *gorm.DB) {
+	t.Helper()
+	db := testutil.NewDB(t)
+	refunds := NewRefundUseCase(
+		repository.NewPaymentRepository(db),
+		repository.NewRefundRepository(db),
+		repository.NewBookingRepository(db),
+		repository.NewBillRepository(db),
+		gateway.NewRegistry(paymentGateway),
+	)
+	return refunds, db
+}
+
+// createPaidBooking stores a booking paid by one successful fake payment,
+// invoiced when billed is set.
+func createPaidBooking(t *testing.T, db *gorm.DB, billed bool) (*models.Booking, *models.Payment) {
+	t.Helper()
+	user := models.User{Name: "Guest", Email: "guest@example.com", Role: constant.CUSTOMER}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	booking := models.Booking{
+		UserID:        user.ID,
+		BookingStatus: constant.BOOKED,
+		TotalPrice:    testPaymentAmount,
+		IsPaid:        true,
+		PaidAmount:    testPaymentAmount,
+		StartDate:     time.Now().AddDate(0, 0, 7),
+		EndDate:       time.Now().AddDate(0, 0, 8),
+	}
+	if err := db.Create(&booking).Error; err != nil {
+		t.Fatal(err)
+	}
+	payment := models.Payment{
+		BookingID:     booking.ID,
+		PaymentMethod: constant.PAYMENT_METHOD_FAKE,
+		PaymentStatus: constant.PAYMENT_SUCCESS,
+		TxnRef:        "txn-1",
+		TransactionID: "FAKE-1",
+		Amount:        testPaymentAmount,
+		PaidAt:        time.Now(),
+	}
+	if billed {
+		bill := models.Bill{BookingID: booking.ID, TotalAmount: testPaymentAmount, ExportAt: time.Now()}
+		if err := db.Create(&bill).Error; err != nil {
+			t.Fatal(err)
+		}
+		payment.BillID = &bill.ID
+	}
+	if err := db.Create(&payment).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &booking, &payment
+}
+
+func startRefund(refunds *RefundUseCase, booking *models.Booking, payment *models.Payment, refundType string, amount float64) (*models.Refund, error) {
+	req := &dto.CreateRefundRequest{PaymentID: payment.ID, Type: refundType, Amount: amount, Reason: "Guest complaint"}
+	return refunds.StartRefund(context.Background(), booking.ID, req, 1, "127.0.0.1")
+}
+
+func reloadBooking(t *testing.T, db *gorm.DB, id uint) models.Booking {
+	t.Helper()
+	var booking models.Booking
+	if err := db.First(&booking, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return booking
+}
+
+func TestStartRefund(t *testing.T) {
+	tests := []struct {
+		name       string
+		refundType string
+		amount     float64
+		billed     bool
+		want       float64
+		wantFull   bool
+	}{
+		{name: "full", refundType: constant.REFUND_TYPE_FULL, want: testPaymentAmount, wantFull: true},
+		{name: "partial", refundType: constant.REFUND_TYPE_PARTIAL, amount: 300_000, want: 300_000},
+		{name: "partial of everything", refundType: constant.REFUND_TYPE_PARTIAL, amount: testPaymentAmount, want: testPaymentAmount, wantFull: true},
+		{name: "billed", refundType: constant.REFUND_TYPE_PARTIAL, amount: 300_000, billed: true, want: 300_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := gateway.NewFakeGateway()
+			refunds, db := newTestRefundUseCase(t, fake)
+			booking, payment := createPaidBooking(t, db, tt.billed)
+
+			refund, err := startRefund(refunds, booking, payment, tt.refundType, tt.amount)
+			if err != nil {
+				t.Fatalf("StartRefund: %v", err)
+			}
+			if refund.Status != constant.REFUND_SUCCESS || refund.Amount != tt.want {
+				t.Errorf("refund = %s %v, want %s %v", refund.Status, refund.Amount, constant.REFUND_SUCCESS, tt.want)
+			}
+			if len(fake.Refunds) != 1 {
+				t.Fatalf("gateway got %d refunds, want 1", len(fake.Refunds))
+			}
+			if sent := fake.Refunds[0]; sent.RefundAmount != int64(tt.want) || sent.Full != tt.wantFull {
+				t.Errorf("gateway got %v full=%v, want %v full=%v", sent.RefundAmount, sent.Full, tt.want, tt.wantFull)
+			}
+
+			updated := reloadBooking(t, db, booking.ID)
+			if updated.RefundedAmount != tt.want {
+				t.Errorf("booking refunded %v, want %v", updated.RefundedAmount, tt.want)
+			}
+			if updated.PaidAmount != testPaymentAmount || updated.NetPaid() != testPaymentAmount-tt.want {
+				t.Errorf("booking paid %v net %v, want %v net %v", updated.PaidAmount, updated.NetPaid(), float64(testPaymentAmount), testPaymentAmount-tt.want)
+			}
+			if tt.billed {
+				var bill models.Bill
+				db.First(&bill, *payment.BillID)
+				if bill.RefundedAmount != tt.want {
+					t.Errorf("bill refunded %v, want %v", bill.RefundedAmount, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStartRefundCapsAtRefundable(t *testing.T) {
+	refunds, db := newTestRefundUseCase(t, gateway.NewFakeGateway())
+	booking, payment := createPaidBooking(t, db, false)
+
+	if _, err := startRefund(refunds, booking, payment, constant.REFUND_TYPE_PARTIAL, 600_000); err != nil {
+		t.Fatalf("first refund: %v", err)
+	}
+	if _, err := startRefund(refunds, booking, payment, constant.REFUND_TYPE_PARTIAL, 400_001); !errors.Is(err, appError.ErrInvalidRefundAmount) {
+		t.Errorf("refund over what is left: got %v, want %v", err, appError.ErrInvalidRefundAmount)
+	}
+	if _, err := startRefund(refunds, booking, payment, constant.REFUND_TYPE_PARTIAL, 0); !errors.Is(err, appError.ErrInvalidRefundAmount) {
+		t.Errorf("zero refund: got %v, want %v", err, appError.ErrInvalidRefundAmount)
+	}
+	refund, err := startRefund(refunds, booking, payment, constant.REFUND_TYPE_FULL, 0)
+	if err != nil {
+		t.Fatalf("full refund of the rest: %v", err)
+	}
+	if refund.Amount != 400_000 {
+		t.Errorf("full refund of the rest = %v, want 400000", refund.Amount)
+	}
+	if _, err := startRefund(refunds, booking, payment, constant.REFUND_TYPE_FULL, 0); !errors.Is(err, appError.ErrPaymentFullyRefunded) {
+		t.Errorf("refund of a refunded payment: got %v, want %v", err, appError.ErrPaymentFullyRefunded)
+	}
+	if got := reloadBooking(t, db, booking.ID).RefundedAmount; got != testPaymentAmount {
+		t.Errorf("booking refunded %v, want %v", got, float64(testPaymentAmount))
+	}
+}
+
+func TestStartRefundRejectsPaymentNotPaid(t *testing.T) {
+	refunds, db := newTestRefundUseCase(t, gateway.NewFakeGateway())
+	booking, payment := createPaidBooking(t, db, false)
+	db.Model(payment).Update("payment_status", constant.PAYMENT_PENDING)
+
+	if _, err := startRefund(refunds, booking, payment, constant.REFUND_TYPE_FULL, 0); !errors.Is(err, appError.ErrPaymentNotRefundable) {
+		t.Errorf("got %v, want %v", err, appError.ErrPaymentNotRefundable)
+	}
+}
+
+func TestStartRefundWithUnknownOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		gateway *lostAnswerGateway
+	}{
+		{name: "answer lost", gateway: &lostAnswerGateway{}},
+		{name: "still processing", gateway: &lostAnswerGateway{status: constant.PAYMENT_PENDING}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.gateway.FakeGateway = gateway.NewFakeGateway()
+			refunds, db := newTestRefundUseCase(t, tt.gateway)
+			booking, payment := createPaidBooking(t, db, false)
+
+			refund, err := startRefund(refunds, booking, payment, constant.REFUND_TYPE_PARTIAL, 300_000)
+			if !errors.Is(err, appError.ErrRefundPending) {
+				t.Fatalf("StartRefund: got %v, want %v", err, appError.ErrRefundPending)
+			}
+			if refund.Status != constant.REFUND_PENDING {
+				t.Errorf("refund status = %s, want %s", refund.Status, constant.REFUND_PENDING)
+			}
+			if got := reloadBooking(t, db, booking.ID).RefundedAmount; got != 0 {
+				t.Errorf("booking refunded %v before the outcome is known, want 0", got)
+			}
+			// The pending refund is held against the payment.
+			if _, err := startRefund(refunds, booking, payment, constant.REFUND_TYPE_PARTIAL, 700_001); !errors.Is(err, appError.ErrInvalidRefundAmount) {
+				t.Errorf("refund over what is left: got %v, want %v", err, appError.ErrInvalidRefundAmount)
+			}
+		})
+	}
+}
+
+// ageRefunds makes every refund old enough for SettlePendingRefunds.
+func ageRefunds(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	err := db.Model(&models.Refund{}).Where("1 = 1").
+		Update("created_at", time.Now().Add(-(constant.RefundQueryAfterMinutes+1)*time.Minute)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSettlePendingRefunds(t *testing.T) {
+	tests := []struct {
+		name         string
+		dropped      bool
+		age          bool
+		want         RefundSettlement
+		wantStatus   string
+		wantRefunded float64
+	}{
+		{
+			name:         "made by the provider",
+			age:          true,
+			want:         RefundSettlement{Checked: 1, Settled: 1},
+			wantStatus:   constant.REFUND_SUCCESS,
+			wantRefunded: 300_000,
+		},
+		{
+			name:       "never reached the provider",
+			dropped:    true,
+			age:        true,
+			want:       RefundSettlement{Checked: 1, Failed: 1},
+			wantStatus: constant.REFUND_FAILED,
+		},
+		{
+			name:       "too recent",
+			want:       RefundSettlement{},
+			wantStatus: constant.REFUND_PENDING,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refunds, db := newTestRefundUseCase(t, &lostAnswerGateway{FakeGateway: gateway.NewFakeGateway(), dropped: tt.dropped})
+			booking, payment := createPaidBooking(t, db, true)
+			refund, err := startRefund(refunds, booking, payment, constant.REFUND_TYPE_PARTIAL, 300_000)
+			if !errors.Is(err, appError.ErrRefundPending) {
+				t.Fatalf("StartRefund: got %v, want %v", err, appError.ErrRefundPending)
+			}
+			if tt.age {
+				ageRefunds(t, db)
+			}
+
+			run, err := refunds.SettlePendingRefunds(context.Background())
+			if err != nil {
+				t.Fatalf("SettlePendingRefunds: %v", err)
+			}
+			if *run != tt.want {
+				t.Errorf("settlement = %+v, want %+v", *run, tt.want)
+			}
+
+			var settled models.Refund
+			db.First(&settled, refund.ID)
+			if settled.Status != tt.wantStatus {
+				t.Errorf("refund status = %s, want %s", settled.Status, tt.wantStatus)
+			}
+			if got := reloadBooking(t, db, booking.ID).RefundedAmount; got != tt.wantRefunded {
+				t.Errorf("booking refunded %v, want %v", got, tt.wantRefunded)
+			}
+			var bill models.Bill
+			db.First(&bill, *payment.BillID)
+			if bill.RefundedAmount != tt.wantRefunded {
+				t.Errorf("bill refunded %v, want %v", bill.RefundedAmount, tt.wantRefunded)
+			}
+
+			// A second run finds nothing left to settle once it is decided.
+			run, err = refunds.SettlePendingRefunds(context.Background())
+			if err != nil {
+				t.Fatalf("second SettlePendingRefunds: %v", err)
+			}
+			if tt.age && run.Checked != 0 {
+				t.Errorf("second run checked %d refunds, want 0", run.Checked)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package admin_usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReviewUseCase struct {
+	reviewRepo repository.ReviewRepository
+	roomRepo   repository.RoomRepository
+}
+
+func NewReviewUseCase(reviewRepo repository.ReviewRepository, roomRepo repository.RoomRepository) *ReviewUseCase {
+	return &ReviewUseCase{reviewRepo: reviewRepo, roomRepo: roomRepo}
+}
+
+func (u *ReviewUseCase) SearchReviews(ctx context.Context, query dto.ReviewQuery) ([]models.Review, error) {
+	if query.Status != "" && !constant.IsValidReviewStatus(query.Status) {
+		return nil, appError.ErrInvalidReviewStatus
+	}
+	if query.Rating < 0 || query.Rating > 5 {
+		return nil, appError.ErrInvalidReviewRating
+	}
+	reviews, err := u.reviewRepo.SearchReviews(ctx, query)
+	if err != nil {
+		return nil, appError.ErrFailedToGetReviews
+	}
+	return reviews, nil
+}
+
+func (u *ReviewUseCase) GetReview(ctx context.Context, id uint) (*models.Review, error) {
+	review, err := u.reviewRepo.FindReviewByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appError.ErrReviewNotFound
+		}
+		return nil, appError.ErrFailedToGetReviews
+	}
+	return review, nil
+}
+
+// CheckReviewAccess reports whether a review is for a room of the property
+// being managed. A propertyID of 0 gives access to every review.
+func (u *ReviewUseCase) CheckReviewAccess(ctx context.Context, id uint, propertyID uint) error {
+	review, err := u.GetReview(ctx, id)
+	if err != nil {
+		return err
+	}
+	if propertyID == constant.AllProperties {
+		return nil
+	}
+	roomPropertyID, err := u.roomRepo.FindRoomPropertyID(ctx, int(review.RoomID))
+	if err != nil {
+		return appError.ErrFailedToGetReviews
+	}
+	if roomPropertyID == nil || *roomPropertyID != propertyID {
+		return appError.ErrPropertyAccessDenied
+	}
+	return nil
+}
+
+// ModerateReview approves, flags or hides a review. Flagging and hiding need
+// a reason. The room's rating aggregate is recomputed in the same
+// transaction, since hiding or un-hiding a review changes it.
+func (u *ReviewUseCase) ModerateReview(ctx context.Context, id uint, req *dto.ModerateReviewRequest, moderatorID uint) (*models.Review, error) {
+	status, ok := constant.ReviewActionStatus(req.Action)
+	if !ok {
+		return nil, appError.ErrInvalidReviewAction
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" && constant.ReviewActionNeedsReason(req.Action) {
+		return nil, appError.ErrModerationReasonRequired
+	}
+	review, err := u.GetReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	review.Status = status
+	review.ModerationReason = reason
+	review.ModeratedByID = &moderatorID
+	review.ModeratedAt = &now
+	err = utils.WithTransaction(u.reviewRepo.GetDB(), func(tx *gorm.DB) error {
+		if err := u.reviewRepo.UpdateReviewModerationTx(ctx, tx, review); err != nil {
+			return appError.ErrFailedToUpdateReview
+		}
+		if err := u.reviewRepo.RecomputeRoomRatingTx(ctx, tx, review.RoomID); err != nil {
+			return appError.ErrFailedToUpdateReview
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// RespondToReview sets or, with an empty response, removes the hotel's
+// public reply to a review.
+func (u *ReviewUseCase) RespondToReview(ctx context.Context, id uint, req *dto.ReviewReplyRequest, userID uint) (*models.Review, error) {
+	review, err := u.GetReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	review.ManagementResponse = strings.TrimSpace(req.Response)
+	if review.ManagementResponse == "" {
+		review.RespondedByID = nil
+		review.RespondedAt = nil
+	} else {
+		now := time.Now()
+		review.RespondedByID = &userID
+		review.RespondedAt = &now
+	}
+	if err := u.reviewRepo.UpdateReviewResponse(ctx, review); err != nil {
+		return nil, appError.ErrFailedToUpdateReview
+	}
+	return review, nil
+}
@@ -0,0 +1,586 @@
+package admin_usecase
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/models"
+	"hotel-management/internal/utils"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var allowedImportImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// roomImportRow is one parsed data row of an import file.
+type roomImportRow struct {
+	result       *dto.RoomImportRowResult
+	room         models.Room
+	existing     *models.Room
+	amenities    []models.Amenity
+	setAmenities bool
+	imageRefs    []string
+	// hasFloor is false when the floor cell was left empty, so updates keep
+	// the room's current floor.
+	hasFloor bool
+}
+
+// ImportRooms creates or updates rooms of one property from a CSV or XLSX
+// file. A row updates the room with the same room number when it has one,
+// and otherwise the room with the same name. New rooms need a room number,
+// and numbers must stay unique within the property. Every row is validated
+// first; nothing is written when the request is a dry run or when any row has
+// errors, so a file is either imported completely or not at all.
+func (u *RoomUseCase) ImportRooms(ctx context.Context, req *dto.RoomImportRequest) (*dto.RoomImportReport, error) {
+	if req.PropertyID == constant.AllProperties {
+		return nil, appError.ErrPropertyRequired
+	}
+	records, err := utils.ReadSpreadsheet(req.FileName, req.Data)
+	if err != nil {
+		return nil, err
+	}
+	records = dropBlankRecords(records)
+	if len(records) < 2 {
+		return nil, appError.ErrImportEmpty
+	}
+	if len(records)-1 > constant.MaxImportRows {
+		return nil, appError.ErrImportTooManyRows
+	}
+	columns := importColumnIndex(records[0])
+	for _, column := range constant.RequiredRoomColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, appError.ErrImportMissingColumns
+		}
+	}
+
+	allAmenities, err := u.amenityRepo.GetAllAmenities(ctx)
+	if err != nil {
+		return nil, appError.ErrFailedToGetAmenity
+	}
+	amenitiesByCode := make(map[string]models.Amenity, len(allAmenities))
+	for _, amenity := range allAmenities {
+		amenitiesByCode[strings.ToLower(amenity.Code)] = amenity
+	}
+	names := make([]string, 0, len(records)-1)
+	numbers := make([]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		names = append(names, importCell(record, columns, constant.ROOM_COLUMN_NAME))
+		if number := importCell(record, columns, constant.ROOM_COLUMN_NUMBER); number != "" {
+			numbers = append(numbers, number)
+		}
+	}
+	existingRooms, err := u.roomRepo.FindRoomsByNames(ctx, req.PropertyID, names)
+	if err != nil {
+		return nil, appError.ErrFailedToImportRooms
+	}
+	roomsByName := make(map[string][]models.Room, len(existingRooms))
+	for _, room := range existingRooms {
+		key := strings.ToLower(room.Name)
+		roomsByName[key] = append(roomsByName[key], room)
+	}
+	numberedRooms, err := u.roomRepo.FindRoomsByNumbers(ctx, req.PropertyID, numbers)
+	if err != nil {
+		return nil, appError.ErrFailedToImportRooms
+	}
+	roomsByNumber := make(map[string]*models.Room, len(numberedRooms))
+	roomIDsByNumber := make(map[string]uint, len(numberedRooms))
+	for i, room := range numberedRooms {
+		roomIDsByNumber[room.Number] = room.ID
+		// An archived room keeps its number but is not updated by an import.
+		if !room.DeletedAt.Valid {
+			roomsByNumber[room.Number] = &numberedRooms[i]
+		}
+	}
+
+	report := &dto.RoomImportReport{DryRun: req.DryRun}
+	rows := make([]*roomImportRow, 0, len(records)-1)
+	seen := map[string]int{}
+	seenNumbers := map[string]int{}
+	for i, record := range records[1:] {
+		row := parseImportRow(record, columns, amenitiesByCode)
+		row.room.PropertyID = &req.PropertyID
+		// The header is line 1, so data starts on line 2.
+		row.result.Line = i + 2
+		key := strings.ToLower(row.room.Name)
+		if row.room.Name != "" {
+			if line, ok := seen[key]; ok {
+				addImportError(row.result, constant.ROOM_COLUMN_NAME, appError.ErrImportDuplicateName, strconv.Itoa(line))
+			} else {
+				seen[key] = row.result.Line
+			}
+		}
+		byNumber, numbered := roomsByNumber[row.room.Number]
+		switch matches := roomsByName[key]; {
+		case numbered:
+			row.existing = byNumber
+			row.result.Action = constant.ROOM_IMPORT_ACTION_UPDATE
+		case len(matches) > 1:
+			row.result.Action = constant.ROOM_IMPORT_ACTION_UPDATE
+			addImportError(row.result, constant.ROOM_COLUMN_NAME, appError.ErrImportAmbiguousRoom, row.room.Name)
+		case len(matches) == 1:
+			row.existing = &matches[0]
+			row.result.Action = constant.ROOM_IMPORT_ACTION_UPDATE
+		default:
+			row.result.Action = constant.ROOM_IMPORT_ACTION_CREATE
+		}
+		checkImportRoomLayout(row, roomIDsByNumber, seenNumbers)
+		u.validateImageRefs(row, req.Bundle)
+		rows = append(rows, row)
+	}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row.result)
+		switch {
+		case len(row.result.Errors) > 0:
+			report.Failed++
+		case row.result.Action == constant.ROOM_IMPORT_ACTION_CREATE:
+			report.Created++
+		default:
+			report.Updated++
+		}
+	}
+	if req.DryRun || report.Failed > 0 {
+		return report, nil
+	}
+
+	var savedFiles, removedFiles []string
+	var current *roomImportRow
+	err = utils.WithTransaction(u.roomRepo.GetDB(), func(tx *gorm.DB) error {
+		for _, row := range rows {
+			current = row
+			saved, removed, err := u.applyImportRow(ctx, tx, row, req.Bundle)
+			savedFiles = append(savedFiles, saved...)
+			removedFiles = append(removedFiles, removed...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		deleteStoredFiles(ctx, u.fileStorage, savedFiles)
+		if !isImportRowError(err) {
+			return nil, appError.ErrFailedToImportRooms
+		}
+		// A row that validated can still fail while its images are processed;
+		// report it like any other row error.
+		addImportError(current.result, constant.ROOM_COLUMN_IMAGES, err, "")
+		report.Rows = report.Rows[:0]
+		report.Created, report.Updated, report.Failed = 0, 0, 1
+		for _, row := range rows {
+			report.Rows = append(report.Rows, *row.result)
+		}
+		return report, nil
+	}
+	deleteStoredFiles(ctx, u.fileStorage, removedFiles)
+	report.Applied = true
+	return report, nil
+}
+
+// ExportRooms writes the room inventory of a property (every property when
+// propertyID is 0) in the import file layout.
+func (u *RoomUseCase) ExportRooms(ctx context.Context, propertyID uint, format string) (*dto.RoomExportFile, error) {
+	if format != constant.ROOM_FILE_FORMAT_CSV && format != constant.ROOM_FILE_FORMAT_XLSX {
+		return nil, appError.ErrUnsupportedExportFormat
+	}
+	rooms, err := u.roomRepo.GetRoomsWithDetails(ctx, propertyID)
+	if err != nil {
+		return nil, appError.ErrFailedToExportRooms
+	}
+
+	records := [][]string{constant.RoomFileColumns}
+	for _, room := range rooms {
+		codes := make([]string, 0, len(room.Amenities))
+		for _, amenity := range room.Amenities {
+			codes = append(codes, amenity.Code)
+		}
+		images := make([]string, 0, len(room.Images))
+		for _, image := range room.Images {
+			images = append(images, image.ImageURL)
+		}
+		records = append(records, []string{
+			room.Name,
+			room.Number,
+			strconv.Itoa(room.Floor),
+			room.Building,
+			room.Type,
+			strconv.FormatFloat(room.PricePerNight, 'f', -1, 64),
+			strconv.Itoa(room.BedNum),
+			strconv.FormatBool(room.HasAircon),
+			room.ViewType,
+			room.Description,
+			strconv.FormatBool(room.IsAvailable),
+			strings.Join(codes, constant.ImportListSeparator),
+			strings.Join(images, constant.ImportListSeparator),
+		})
+	}
+
+	file := &dto.RoomExportFile{FileName: "rooms-" + time.Now().Format("20060102") + "." + format}
+	if format == constant.ROOM_FILE_FORMAT_XLSX {
+		file.ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		file.Data, err = utils.WriteXLSX("Rooms", records)
+	} else {
+		file.ContentType = "text/csv; charset=utf-8"
+		file.Data, err = utils.WriteCSV(records)
+	}
+	if err != nil {
+		return nil, appError.ErrFailedToExportRooms
+	}
+	return file, nil
+}
+
+func parseImportRow(record []string, columns map[string]int, amenitiesByCode map[string]models.Amenity) *roomImportRow {
+	cell := func(column string) string {
+		return importCell(record, columns, column)
+	}
+	row := &roomImportRow{result: &dto.RoomImportRowResult{Name: cell(constant.ROOM_COLUMN_NAME)}}
+	result := row.result
+	room := &row.room
+
+	for _, column := range constant.RequiredRoomColumns {
+		if cell(column) == "" {
+			addImportError(result, column, appError.ErrImportRequiredField, "")
+		}
+	}
+	room.Name = cell(constant.ROOM_COLUMN_NAME)
+	room.Type = cell(constant.ROOM_COLUMN_TYPE)
+	room.ViewType = cell(constant.ROOM_COLUMN_VIEW_TYPE)
+	room.Description = cell(constant.ROOM_COLUMN_DESCRIPTION)
+	room.Number = cell(constant.ROOM_COLUMN_NUMBER)
+	room.Building = cell(constant.ROOM_COLUMN_BUILDING)
+	// Limits follow the column sizes of the rooms table.
+	for _, field := range []struct {
+		column string
+		limit  int
+	}{
+		{constant.ROOM_COLUMN_NAME, 100},
+		{constant.ROOM_COLUMN_TYPE, 50},
+		{constant.ROOM_COLUMN_VIEW_TYPE, 100},
+		{constant.ROOM_COLUMN_NUMBER, 20},
+		{constant.ROOM_COLUMN_BUILDING, 50},
+	} {
+		if utf8.RuneCountInString(cell(field.column)) > field.limit {
+			addImportError(result, field.column, appError.ErrImportValueTooLong, strconv.Itoa(field.limit))
+		}
+	}
+
+	if value := cell(constant.ROOM_COLUMN_PRICE); value != "" {
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil || price < 0 {
+			addImportError(result, constant.ROOM_COLUMN_PRICE, appError.ErrImportInvalidPrice, value)
+		}
+		room.PricePerNight = price
+	}
+	if value := cell(constant.ROOM_COLUMN_BED_NUM); value != "" {
+		beds, err := strconv.Atoi(value)
+		if err != nil || beds < 1 {
+			addImportError(result, constant.ROOM_COLUMN_BED_NUM, appError.ErrImportInvalidBedNum, value)
+		}
+		room.BedNum = beds
+	}
+	if value := cell(constant.ROOM_COLUMN_FLOOR); value != "" {
+		floor, err := strconv.Atoi(value)
+		if err != nil {
+			addImportError(result, constant.ROOM_COLUMN_FLOOR, appError.ErrImportInvalidFloor, value)
+		}
+		room.Floor = floor
+		row.hasFloor = true
+	}
+	room.HasAircon = parseImportBool(result, constant.ROOM_COLUMN_HAS_AIRCON, cell(constant.ROOM_COLUMN_HAS_AIRCON), true)
+	room.IsAvailable = parseImportBool(result, constant.ROOM_COLUMN_IS_AVAILABLE, cell(constant.ROOM_COLUMN_IS_AVAILABLE), true)
+
+	if value := cell(constant.ROOM_COLUMN_AMENITIES); value != "" {
+		row.setAmenities = true
+		for _, code := range splitImportList(value) {
+			amenity, ok := amenitiesByCode[strings.ToLower(code)]
+			if !ok {
+				addImportError(result, constant.ROOM_COLUMN_AMENITIES, appError.ErrImportUnknownAmenity, code)
+				continue
+			}
+			row.amenities = append(row.amenities, amenity)
+		}
+	}
+	row.imageRefs = splitImportList(cell(constant.ROOM_COLUMN_IMAGES))
+	if len(row.imageRefs) > constant.MaxImportImages {
+		addImportError(result, constant.ROOM_COLUMN_IMAGES, appError.ErrImportTooManyImages, strconv.Itoa(len(row.imageRefs)))
+	}
+	return row
+}
+
+// checkImportRoomLayout fills in the room number, floor and building an
+// update leaves empty from the existing room, then checks that the number is
+// set and not used by another row or another room of the property.
+func checkImportRoomLayout(row *roomImportRow, roomIDsByNumber map[string]uint, seenNumbers map[string]int) {
+	room := &row.room
+	var roomID uint
+	if row.existing != nil {
+		roomID = row.existing.ID
+		if room.Number == "" {
+			room.Number = row.existing.Number
+		}
+		if !row.hasFloor {
+			room.Floor = row.existing.Floor
+		}
+		if room.Building == "" {
+			room.Building = row.existing.Building
+		}
+	}
+	if room.Number == "" {
+		addImportError(row.result, constant.ROOM_COLUMN_NUMBER, appError.ErrImportRequiredField, "")
+		return
+	}
+	if line, ok := seenNumbers[room.Number]; ok {
+		addImportError(row.result, constant.ROOM_COLUMN_NUMBER, appError.ErrImportDuplicateNumber, strconv.Itoa(line))
+	} else {
+		seenNumbers[room.Number] = row.result.Line
+	}
+	if id, ok := roomIDsByNumber[room.Number]; ok && id != roomID {
+		addImportError(row.result, constant.ROOM_COLUMN_NUMBER, appError.ErrRoomNumberExists, room.Number)
+	}
+}
+
+// validateImageRefs checks that every image reference is either one of the
+// room's current images or a readable JPEG, PNG or WebP file.
+func (u *RoomUseCase) validateImageRefs(row *roomImportRow, bundle *zip.Reader) {
+	for _, ref := range row.imageRefs {
+		if row.existing != nil && findImageByRef(row.existing.Images, ref) != nil {
+			continue
+		}
+		data, err := u.loadImportImage(bundle, ref)
+		if err != nil {
+			addImportError(row.result, constant.ROOM_COLUMN_IMAGES, err, ref)
+			continue
+		}
+		if !allowedImportImageTypes[http.DetectContentType(data)] {
+			addImportError(row.result, constant.ROOM_COLUMN_IMAGES, appError.ErrInvalidImageType, ref)
+		}
+	}
+}
+
+// applyImportRow writes one validated row. It returns the storage keys it
+// created (to clean up on rollback) and the keys of images the row removed
+// (to delete once the transaction commits).
+func (u *RoomUseCase) applyImportRow(ctx context.Context, tx *gorm.DB, row *roomImportRow, bundle *zip.Reader) ([]string, []string, error) {
+	room := &row.room
+	if row.existing != nil {
+		room.ID = row.existing.ID
+		if err := u.roomRepo.UpdateRoomTx(ctx, tx, room); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return nil, nil, appError.ErrRoomNumberExists
+			}
+			return nil, nil, appError.ErrFailedToImportRooms
+		}
+	} else if err := u.roomRepo.CreateRoomTx(ctx, tx, room); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, nil, appError.ErrRoomNumberExists
+		}
+		return nil, nil, appError.ErrFailedToImportRooms
+	}
+	if row.setAmenities {
+		if err := u.roomRepo.ReplaceRoomAmenitiesTx(ctx, tx, room, row.amenities); err != nil {
+			return nil, nil, appError.ErrFailedToImportRooms
+		}
+	}
+	if len(row.imageRefs) == 0 {
+		return nil, nil, nil
+	}
+
+	var savedFiles, removedFiles []string
+	images := make([]models.RoomImage, 0, len(row.imageRefs))
+	kept := map[uint]bool{}
+	for i, ref := range row.imageRefs {
+		if row.existing != nil {
+			if image := findImageByRef(row.existing.Images, ref); image != nil {
+				image.SortOrder = i
+				if err := u.roomRepo.UpdateRoomImageTx(ctx, tx, image); err != nil {
+					return savedFiles, nil, appError.ErrFailedToImportRooms
+				}
+				kept[image.ID] = true
+				images = append(images, *image)
+				continue
+			}
+		}
+		data, err := u.loadImportImage(bundle, ref)
+		if err != nil {
+			return savedFiles, nil, err
+		}
+		image, keys, err := u.storeRoomImage(ctx, tx, room, data, i)
+		savedFiles = append(savedFiles, keys...)
+		if err != nil {
+			return savedFiles, nil, err
+		}
+		images = append(images, *image)
+	}
+	if row.existing != nil {
+		for _, image := range row.existing.Images {
+			if kept[image.ID] {
+				continue
+			}
+			if err := u.roomRepo.DeleteRoomImageTx(ctx, tx, int(image.ID)); err != nil {
+				return savedFiles, nil, appError.ErrFailedToImportRooms
+			}
+			removedFiles = append(removedFiles, roomImageKeys(u.fileStorage, &image)...)
+		}
+	}
+	primaryID, err := pickPrimaryImage(images, 0)
+	if err != nil {
+		return savedFiles, nil, appError.ErrFailedToImportRooms
+	}
+	if err := u.roomRepo.SetPrimaryRoomImageTx(ctx, tx, room.ID, primaryID); err != nil {
+		return savedFiles, nil, appError.ErrFailedToImportRooms
+	}
+	return savedFiles, removedFiles, nil
+}
+
+// loadImportImage reads an image referenced by an import file, from the zip
+// bundle when one was uploaded and from the import directory otherwise.
+// References are relative paths; os.Root keeps them (and any symlinks) inside
+// the import directory.
+func (u *RoomUseCase) loadImportImage(bundle *zip.Reader, ref string) ([]byte, error) {
+	name := path.Clean(strings.ReplaceAll(ref, "\\", "/"))
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || strings.Contains(ref, "://") {
+		return nil, appError.ErrInvalidImportImagePath
+	}
+
+	var reader io.ReadCloser
+	if bundle != nil {
+		file, err := bundle.Open(name)
+		if err != nil {
+			return nil, appError.ErrImportImageNotFound
+		}
+		reader = file
+	} else {
+		dir := os.Getenv("ROOM_IMPORT_DIR")
+		if dir == "" {
+			dir = constant.DefaultRoomImportDir
+		}
+		root, err := os.OpenRoot(dir)
+		if err != nil {
+			return nil, appError.ErrImportImageNotFound
+		}
+		defer root.Close()
+		file, err := root.Open(filepath.FromSlash(name))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, appError.ErrImportImageNotFound
+			}
+			return nil, appError.ErrInvalidImportImagePath
+		}
+		reader = file
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, constant.MaxImageBytes+1))
+	if err != nil {
+		return nil, appError.ErrImportImageNotFound
+	}
+	if len(data) > constant.MaxImageBytes {
+		return nil, appError.ErrImageTooLarge
+	}
+	return data, nil
+}
+
+// findImageByRef matches an exported image URL back to the room's image.
+func findImageByRef(images []models.RoomImage, ref string) *models.RoomImage {
+	for i := range images {
+		image := &images[i]
+		if ref == image.ImageURL || ref == image.LargeURL || ref == image.MediumURL || ref == image.ThumbnailURL {
+			return image
+		}
+	}
+	return nil
+}
+
+func isImportRowError(err error) bool {
+	for _, rowErr := range []error{
+		appError.ErrInvalidImage,
+		appError.ErrInvalidImageType,
+		appError.ErrImageTooLarge,
+		appError.ErrImageDimensionsTooLarge,
+		appError.ErrImportImageNotFound,
+		appError.ErrInvalidImportImagePath,
+		appError.ErrFailedToSaveFile,
+	} {
+		if errors.Is(err, rowErr) {
+			return true
+		}
+	}
+	return false
+}
+
+func importColumnIndex(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.ReplaceAll(name, " ", "_")
+		if _, ok := columns[name]; !ok && name != "" {
+			columns[name] = i
+		}
+	}
+	return columns
+}
+
+func importCell(record []string, columns map[string]int, column string) string {
+	i, ok := columns[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func dropBlankRecords(records [][]string) [][]string {
+	kept := records[:0]
+	for _, record := range records {
+		for _, value := range record {
+			if strings.TrimSpace(value) != "" {
+				kept = append(kept, record)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+func splitImportList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, constant.ImportListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseImportBool(result *dto.RoomImportRowResult, column, value string, fallback bool) bool {
+	switch strings.ToLower(value) {
+	case "":
+		return fallback
+	case "true", "yes", "y", "1":
+		return true
+	case "false", "no", "n", "0":
+		return false
+	default:
+		addImportError(result, column, appError.ErrImportInvalidBoolean, value)
+		return fallback
+	}
+}
+
+func addImportError(result *dto.RoomImportRowResult, column string, err error, value string) {
+	result.Errors = append(result.Errors, dto.RoomImportError{Column: column, Key: err.Error(), Value: value})
+}
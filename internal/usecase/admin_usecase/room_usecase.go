@@ -1,21 +1,22 @@
 package admin_usecase
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"hotel-management/internal/constant"
 	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
 	"hotel-management/internal/models"
 	"hotel-management/internal/repository"
+	"hotel-management/internal/storage"
 	"hotel-management/internal/utils"
 	"log"
 	"mime/multipart"
-	"os"
-	"path/filepath"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -23,45 +24,193 @@ type RoomUseCase struct {
 	roomRepo    repository.RoomRepository
 	bookingRepo repository.BookingRepository
 	reviewRepo  repository.ReviewRepository
+	amenityRepo repository.AmenityRepository
+	fileStorage storage.Storage
 }
 
-func NewRoomUseCase(roomRepo repository.RoomRepository, bookingRepo repository.BookingRepository, reviewRepo repository.ReviewRepository) *RoomUseCase {
-	return &RoomUseCase{roomRepo: roomRepo, bookingRepo: bookingRepo, reviewRepo: reviewRepo}
+func NewRoomUseCase(roomRepo repository.RoomRepository, bookingRepo repository.BookingRepository, reviewRepo repository.ReviewRepository, amenityRepo repository.AmenityRepository, fileStorage storage.Storage) *RoomUseCase {
+	return &RoomUseCase{roomRepo: roomRepo, bookingRepo: bookingRepo, reviewRepo: reviewRepo, amenityRepo: amenityRepo, fileStorage: fileStorage}
 }
 
-func (u *RoomUseCase) saveRoomImages(ctx *gin.Context, tx *gorm.DB, roomID uint, fileHeaders []*multipart.FileHeader) ([]string, error) {
-	uploadDir := constant.UploadDir
+// saveRoomImages runs every upload through the image pipeline and stores the
+// resulting variants under generated names, so the client's file name never
+// reaches the disk. New images are ordered after startOrder.
+func (u *RoomUseCase) saveRoomImages(ctx *gin.Context, tx *gorm.DB, room *models.Room, fileHeaders []*multipart.FileHeader, startOrder int) ([]models.RoomImage, []string, error) {
 	savedFiles := []string{}
-	for _, fileHeader := range fileHeaders {
-		filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(fileHeader.Filename))
-		savePath := filepath.Join(uploadDir, filename)
-		savedFiles = append(savedFiles, savePath)
-		if err := ctx.SaveUploadedFile(fileHeader, savePath); err != nil {
-			deleteSavedFiles(savedFiles)
-			return savedFiles, errors.New("error.failed_to_save_file")
+	images := []models.RoomImage{}
+	for i, fileHeader := range fileHeaders {
+		data, err := utils.ReadUploadedImage(fileHeader)
+		if err != nil {
+			return images, savedFiles, err
 		}
+		image, keys, err := u.storeRoomImage(ctx.Request.Context(), tx, room, data, startOrder+i)
+		savedFiles = append(savedFiles, keys...)
+		if err != nil {
+			return images, savedFiles, err
+		}
+		images = append(images, *image)
+	}
+	return images, savedFiles, nil
+}
+
+// storeRoomImage processes one image, writes its variants to storage and
+// creates the RoomImage row. The stored keys are returned even on error so the
+// caller can clean them up.
+func (u *RoomUseCase) storeRoomImage(ctx context.Context, tx *gorm.DB, room *models.Room, data []byte, sortOrder int) (*models.RoomImage, []string, error) {
+	savedFiles := []string{}
+	processed, err := utils.ProcessImage(data)
+	if err != nil {
+		return nil, savedFiles, err
+	}
+
+	baseName := uuid.New().String()
+	urls := make(map[string]string, len(constant.ImageVariants))
+	written := make(map[string][]byte, len(constant.ImageVariants))
+	for _, variant := range constant.ImageVariants {
+		content := processed.Variants[variant.Name]
+		// Small images come out identical for several variants; share one file.
+		if url, ok := findWrittenVariant(written, urls, content); ok {
+			urls[variant.Name] = url
+			continue
+		}
+		key := fmt.Sprintf("rooms/%d/%s_%s%s", room.ID, baseName, variant.Name, processed.Ext)
+		if err := u.fileStorage.Put(ctx, key, content, processed.ContentType); err != nil {
+			return nil, savedFiles, appError.ErrFailedToSaveFile
+		}
+		savedFiles = append(savedFiles, key)
+		urls[variant.Name] = u.fileStorage.URL(key)
+		written[variant.Name] = content
+	}
+
+	roomImage := models.RoomImage{
+		RoomID:       room.ID,
+		ImageURL:     urls[constant.IMAGE_VARIANT_LARGE],
+		ThumbnailURL: urls[constant.IMAGE_VARIANT_THUMBNAIL],
+		MediumURL:    urls[constant.IMAGE_VARIANT_MEDIUM],
+		LargeURL:     urls[constant.IMAGE_VARIANT_LARGE],
+		SortOrder:    sortOrder,
+		AltText:      room.Name,
+	}
+	if err := u.roomRepo.CreateRoomImageTx(ctx, tx, &roomImage); err != nil {
+		return nil, savedFiles, appError.ErrFailedToSaveRoomImage
+	}
+	return &roomImage, savedFiles, nil
+}
+
+func findWrittenVariant(written map[string][]byte, urls map[string]string, content []byte) (string, bool) {
+	for name, data := range written {
+		if bytes.Equal(data, content) {
+			return urls[name], true
+		}
+	}
+	return "", false
+}
+
+// roomImageKeys lists the distinct stored objects behind a room image.
+func roomImageKeys(fileStorage storage.Storage, image *models.RoomImage) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, url := range []string{image.ImageURL, image.ThumbnailURL, image.MediumURL, image.LargeURL} {
+		key, ok := fileStorage.KeyFromURL(url)
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// pickPrimaryImage returns the image that should be primary: the requested
+// one, else the current primary, else the first image in display order.
+func pickPrimaryImage(images []models.RoomImage, requestedID uint) (uint, error) {
+	if requestedID != 0 {
+		for _, image := range images {
+			if image.ID == requestedID {
+				return requestedID, nil
+			}
+		}
+		return 0, appError.ErrRoomImageNotFound
+	}
+	for _, image := range images {
+		if image.IsPrimary {
+			return image.ID, nil
+		}
+	}
+	if len(images) == 0 {
+		return 0, nil
+	}
+	first := images[0]
+	for _, image := range images[1:] {
+		if image.SortOrder < first.SortOrder || (image.SortOrder == first.SortOrder && image.ID < first.ID) {
+			first = image
+		}
+	}
+	return first.ID, nil
+}
+
+func (u *RoomUseCase) findAmenities(ctx context.Context, ids []uint) ([]models.Amenity, error) {
+	amenities, err := u.amenityRepo.FindAmenitiesByIDs(ctx, ids)
+	if err != nil {
+		return nil, appError.ErrFailedToGetAmenity
+	}
+	if len(amenities) != len(ids) {
+		return nil, appError.ErrInvalidAmenityIDs
+	}
+	return amenities, nil
+}
 
-		roomImage := &models.RoomImage{
-			RoomID:   roomID,
-			ImageURL: constant.ImageURL + filename,
+// checkRoomNumber requires a number that no other room of the property uses.
+func (u *RoomUseCase) checkRoomNumber(ctx context.Context, room *models.Room) error {
+	if room.Number == "" {
+		return appError.ErrRoomNumberRequired
+	}
+	existing, err := u.roomRepo.FindRoomByNumber(ctx, *room.PropertyID, room.Number)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
 		}
-		if err := u.roomRepo.CreateRoomImageTx(ctx.Request.Context(), tx, roomImage); err != nil {
-			deleteSavedFiles(savedFiles)
-			return savedFiles, errors.New("error.failed_to_save_room_image")
+		return errors.New("error.failed_to_get_room")
+	}
+	if existing.ID != room.ID {
+		return appError.ErrRoomNumberExists
+	}
+	return nil
+}
+
+// checkConnectingRooms accepts only other rooms of the same property.
+func (u *RoomUseCase) checkConnectingRooms(ctx context.Context, room *models.Room, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	rooms, err := u.roomRepo.FindRoomsByIDs(ctx, ids)
+	if err != nil {
+		return errors.New("error.failed_to_get_room")
+	}
+	if len(rooms) != len(ids) {
+		return appError.ErrInvalidConnectingRooms
+	}
+	for _, connected := range rooms {
+		if connected.ID == room.ID || connected.PropertyID == nil || *connected.PropertyID != *room.PropertyID {
+			return appError.ErrInvalidConnectingRooms
 		}
 	}
-	return savedFiles, nil
+	return nil
 }
-func deleteSavedFiles(paths []string) {
-	for _, path := range paths {
-		err := os.Remove(path)
-		if err != nil && !os.IsNotExist(err) {
-			log.Printf("failed to delete file %s: %v", path, err)
+
+func deleteStoredFiles(ctx context.Context, fileStorage storage.Storage, keys []string) {
+	for _, key := range keys {
+		if err := fileStorage.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete file %s: %v", key, err)
 		}
 	}
 }
 func (u *RoomUseCase) CreateRoom(ctx *gin.Context, createRoomRequest *dto.CreateRoomRequest) error {
+	if createRoomRequest.PropertyID == constant.AllProperties {
+		return appError.ErrPropertyRequired
+	}
 	room := &models.Room{
+		PropertyID:    &createRoomRequest.PropertyID,
 		Name:          createRoomRequest.Name,
 		Type:          createRoomRequest.Type,
 		PricePerNight: createRoomRequest.PricePerNight,
@@ -70,20 +219,47 @@ func (u *RoomUseCase) CreateRoom(ctx *gin.Context, createRoomRequest *dto.Create
 		ViewType:      createRoomRequest.ViewType,
 		Description:   createRoomRequest.Description,
 		IsAvailable:   createRoomRequest.IsAvailable,
+		Number:        createRoomRequest.Number,
+		Floor:         createRoomRequest.Floor,
+		Building:      createRoomRequest.Building,
+	}
+	if err := u.checkRoomNumber(ctx.Request.Context(), room); err != nil {
+		return err
+	}
+	if err := u.checkConnectingRooms(ctx.Request.Context(), room, createRoomRequest.ConnectingRoomIDs); err != nil {
+		return err
+	}
+	amenities, err := u.findAmenities(ctx.Request.Context(), createRoomRequest.AmenityIDs)
+	if err != nil {
+		return err
 	}
 
 	db := u.roomRepo.GetDB()
 	return utils.WithTransaction(db, func(tx *gorm.DB) error {
 		if err := u.roomRepo.CreateRoomTx(ctx.Request.Context(), tx, room); err != nil {
+			// Lost a race with another request for the same number.
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return appError.ErrRoomNumberExists
+			}
 			return errors.New("error.failed_to_create_room")
 		}
+		if err := u.roomRepo.ReplaceRoomAmenitiesTx(ctx.Request.Context(), tx, room, amenities); err != nil {
+			return appError.ErrFailedToSaveAmenity
+		}
+		if err := u.roomRepo.ReplaceConnectingRoomsTx(ctx.Request.Context(), tx, room.ID, createRoomRequest.ConnectingRoomIDs); err != nil {
+			return appError.ErrFailedToSaveConnectingRooms
+		}
 
 		if len(createRoomRequest.ImageFiles) > 0 {
-			savedFiles, err := u.saveRoomImages(ctx, tx, room.ID, createRoomRequest.ImageFiles)
+			images, savedFiles, err := u.saveRoomImages(ctx, tx, room, createRoomRequest.ImageFiles, 0)
 			if err != nil {
-				deleteSavedFiles(savedFiles)
+				deleteStoredFiles(ctx.Request.Context(), u.fileStorage, savedFiles)
 				return err
 			}
+			if err := u.roomRepo.SetPrimaryRoomImageTx(ctx.Request.Context(), tx, room.ID, images[0].ID); err != nil {
+				deleteStoredFiles(ctx.Request.Context(), u.fileStorage, savedFiles)
+				return appError.ErrFailedToSaveRoomImage
+			}
 		}
 		return nil
 	})
@@ -94,6 +270,10 @@ func (u *RoomUseCase) UpdateRoom(ctx *gin.Context, editRoomRequest *dto.EditRoom
 	if err != nil {
 		return errors.New("error.room_not_found")
 	}
+	if editRoomRequest.PropertyID == constant.AllProperties {
+		return appError.ErrPropertyRequired
+	}
+	room.PropertyID = &editRoomRequest.PropertyID
 	room.Name = editRoomRequest.Name
 	room.Type = editRoomRequest.Type
 	room.PricePerNight = editRoomRequest.PricePerNight
@@ -102,33 +282,90 @@ func (u *RoomUseCase) UpdateRoom(ctx *gin.Context, editRoomRequest *dto.EditRoom
 	room.ViewType = editRoomRequest.ViewType
 	room.Description = editRoomRequest.Description
 	room.IsAvailable = editRoomRequest.IsAvailable
+	room.Number = editRoomRequest.Number
+	room.Floor = editRoomRequest.Floor
+	room.Building = editRoomRequest.Building
+	if err := u.checkRoomNumber(ctx.Request.Context(), room); err != nil {
+		return err
+	}
+	if err := u.checkConnectingRooms(ctx.Request.Context(), room, editRoomRequest.ConnectingRoomIDs); err != nil {
+		return err
+	}
+	amenities, err := u.findAmenities(ctx.Request.Context(), editRoomRequest.AmenityIDs)
+	if err != nil {
+		return err
+	}
 
 	db := u.roomRepo.GetDB()
 	return utils.WithTransaction(db, func(tx *gorm.DB) error {
 		//Update room information
 		if err := u.roomRepo.UpdateRoomTx(ctx.Request.Context(), tx, room); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return appError.ErrRoomNumberExists
+			}
 			return errors.New("error.failed_to_update_room")
 		}
+		if err := u.roomRepo.ReplaceRoomAmenitiesTx(ctx.Request.Context(), tx, room, amenities); err != nil {
+			return appError.ErrFailedToSaveAmenity
+		}
+		if err := u.roomRepo.ReplaceConnectingRoomsTx(ctx.Request.Context(), tx, room.ID, editRoomRequest.ConnectingRoomIDs); err != nil {
+			return appError.ErrFailedToSaveConnectingRooms
+		}
 		//Delete room images
+		deleted := make(map[uint]bool, len(editRoomRequest.ImageDeletes))
 		for _, imageID := range editRoomRequest.ImageDeletes {
 			image, err := u.roomRepo.FindRoomImageByID(ctx, imageID)
-			if err != nil {
-				return errors.New("error.room_image_not_found")
+			if err != nil || image.RoomID != room.ID {
+				return appError.ErrRoomImageNotFound
 			}
 			err = u.roomRepo.DeleteRoomImageTx(ctx, tx, imageID)
 			if err != nil {
 				return errors.New("error.failed_to_delete_image")
 			}
-			filename := filepath.Base(image.ImageURL)
-			_ = os.Remove(filepath.Join(constant.UploadDir, filename))
+			deleted[image.ID] = true
+			deleteStoredFiles(ctx.Request.Context(), u.fileStorage, roomImageKeys(u.fileStorage, image))
+		}
+		//Update order and alt text of the remaining images
+		remaining := make([]models.RoomImage, 0, len(room.Images))
+		nextOrder := 0
+		for _, image := range room.Images {
+			if deleted[image.ID] {
+				continue
+			}
+			for _, meta := range editRoomRequest.ImageMeta {
+				if uint(meta.ID) != image.ID {
+					continue
+				}
+				image.AltText = meta.AltText
+				image.SortOrder = meta.SortOrder
+				if err := u.roomRepo.UpdateRoomImageTx(ctx.Request.Context(), tx, &image); err != nil {
+					return appError.ErrFailedToSaveRoomImage
+				}
+			}
+			nextOrder = max(nextOrder, image.SortOrder+1)
+			remaining = append(remaining, image)
 		}
 		//Upload new images
+		var savedFiles []string
 		if len(editRoomRequest.ImageFiles) > 0 {
-			savedFiles, err := u.saveRoomImages(ctx, tx, room.ID, editRoomRequest.ImageFiles)
+			images, files, err := u.saveRoomImages(ctx, tx, room, editRoomRequest.ImageFiles, nextOrder)
+			savedFiles = files
 			if err != nil {
-				deleteSavedFiles(savedFiles)
+				deleteStoredFiles(ctx.Request.Context(), u.fileStorage, savedFiles)
 				return err
 			}
+			remaining = append(remaining, images...)
+		}
+		primaryID, err := pickPrimaryImage(remaining, uint(editRoomRequest.PrimaryImageID))
+		if err != nil {
+			deleteStoredFiles(ctx.Request.Context(), u.fileStorage, savedFiles)
+			return err
+		}
+		if primaryID != 0 {
+			if err := u.roomRepo.SetPrimaryRoomImageTx(ctx.Request.Context(), tx, room.ID, primaryID); err != nil {
+				deleteStoredFiles(ctx.Request.Context(), u.fileStorage, savedFiles)
+				return appError.ErrFailedToSaveRoomImage
+			}
 		}
 		return nil
 	})
@@ -141,6 +378,10 @@ func (u *RoomUseCase) GetAllRooms(ctx context.Context) ([]models.Room, error) {
 	}
 	return rooms, nil
 }
+func (u *RoomUseCase) GetAllAmenities(ctx context.Context) ([]models.Amenity, error) {
+	return u.amenityRepo.GetAllAmenities(ctx)
+}
+
 func (u *RoomUseCase) GetRoomByID(ctx context.Context, id int) (*models.Room, error) {
 	room, err := u.roomRepo.FindRoomByID(ctx, id)
 	if err != nil {
@@ -149,9 +390,61 @@ func (u *RoomUseCase) GetRoomByID(ctx context.Context, id int) (*models.Room, er
 	return room, nil
 }
 
-func (u *RoomUseCase) DeleteRoom(ctx context.Context, id int) error {
-	if err := u.roomRepo.DeleteRoom(ctx, id); err != nil {
-		return errors.New("error.failed_to_delete_room")
+// DeleteRoom archives a room. Rooms are soft-deleted so past bookings, reviews
+// and reports keep pointing at them; archiving is refused while the room still
+// has bookings that are booked or checked in and not yet over, and those
+// bookings are returned so the caller can show them.
+func (u *RoomUseCase) DeleteRoom(ctx context.Context, id int) ([]models.Booking, error) {
+	var conflicts []models.Booking
+	err := utils.WithTransaction(u.roomRepo.GetDB(), func(tx *gorm.DB) error {
+		bookings, err := u.bookingRepo.GetUpcomingBookingsByRoomIDTx(ctx, tx, id)
+		if err != nil {
+			return appError.ErrFailedToArchiveRoom
+		}
+		if len(bookings) > 0 {
+			conflicts = bookings
+			return appError.ErrRoomHasUpcomingBookings
+		}
+		if err := u.roomRepo.DeleteRoomTx(ctx, tx, id); err != nil {
+			return appError.ErrFailedToArchiveRoom
+		}
+		return nil
+	})
+	if errors.Is(err, appError.ErrRoomHasUpcomingBookings) {
+		return conflicts, err
+	}
+	if err != nil {
+		return nil, appError.ErrFailedToArchiveRoom
+	}
+	return nil, nil
+}
+
+func (u *RoomUseCase) GetArchivedRooms(ctx context.Context, propertyID uint) ([]models.Room, error) {
+	return u.roomRepo.GetArchivedRooms(ctx, propertyID)
+}
+
+// CheckRoomAccess reports whether a room, archived or not, belongs to the
+// property being managed. A propertyID of 0 gives access to every room.
+func (u *RoomUseCase) CheckRoomAccess(ctx context.Context, id int, propertyID uint) error {
+	roomPropertyID, err := u.roomRepo.FindRoomPropertyID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("error.room_not_found")
+		}
+		return errors.New("error.failed_to_get_room")
+	}
+	if propertyID != constant.AllProperties && (roomPropertyID == nil || *roomPropertyID != propertyID) {
+		return appError.ErrPropertyAccessDenied
+	}
+	return nil
+}
+
+func (u *RoomUseCase) RestoreRoom(ctx context.Context, id int) error {
+	if err := u.roomRepo.RestoreRoom(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appError.ErrArchivedRoomNotFound
+		}
+		return appError.ErrFailedToRestoreRoom
 	}
 	return nil
 }
@@ -160,6 +453,37 @@ func (u *RoomUseCase) SearchRooms(ctx context.Context, query dto.RoomQuery) ([]m
 	return u.roomRepo.SearchRooms(ctx, query)
 }
 
+// GroupRoomsByFloor splits rooms, already ordered by building and floor, into
+// one group per building floor.
+func GroupRoomsByFloor(rooms []models.Room) []dto.RoomFloorGroup {
+	var groups []dto.RoomFloorGroup
+	for _, room := range rooms {
+		last := len(groups) - 1
+		if last < 0 || groups[last].Building != room.Building || groups[last].Floor != room.Floor {
+			groups = append(groups, dto.RoomFloorGroup{Building: room.Building, Floor: room.Floor})
+			last++
+		}
+		groups[last].Rooms = append(groups[last].Rooms, room)
+	}
+	return groups
+}
+
+// GetConnectableRooms lists the rooms of a property that a room can be
+// connected to, leaving out the room itself.
+func (u *RoomUseCase) GetConnectableRooms(ctx context.Context, propertyID uint, excludeID uint) ([]models.Room, error) {
+	rooms, err := u.roomRepo.SearchRooms(ctx, dto.RoomQuery{PropertyID: propertyID, GroupBy: constant.ROOM_GROUP_BY_FLOOR})
+	if err != nil {
+		return nil, err
+	}
+	connectable := make([]models.Room, 0, len(rooms))
+	for _, room := range rooms {
+		if room.ID != excludeID {
+			connectable = append(connectable, room)
+		}
+	}
+	return connectable, nil
+}
+
 func (u *RoomUseCase) GetRoomDetail(ctx context.Context, id int) (*dto.RoomDetailResponse, error) {
 	room, err := u.roomRepo.FindRoomByID(ctx, id)
 	if err != nil {
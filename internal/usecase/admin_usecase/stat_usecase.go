@@ -14,6 +14,6 @@ func NewStatUseCase(statRepo repository.StatRepository) *StatUseCase {
 	return &StatUseCase{statRepo: statRepo}
 }
 
-func (u *StatUseCase) GetDashboardStatistics(ctx context.Context) (*dto.StatisticDashboard, error) {
-	return u.statRepo.GetDashboardStatistics(ctx)
+func (u *StatUseCase) GetDashboardStatistics(ctx context.Context, propertyID uint) (*dto.StatisticDashboard, error) {
+	return u.statRepo.GetDashboardStatistics(ctx, propertyID)
 }
@@ -0,0 +1,282 @@
+package admin_usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/storage"
+	"hotel-management/internal/utils"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WorkOrderUseCase struct {
+	workOrderRepo repository.WorkOrderRepository
+	roomRepo      repository.RoomRepository
+	userRepo      repository.UserRepository
+	fileStorage   storage.Storage
+}
+
+func NewWorkOrderUseCase(workOrderRepo repository.WorkOrderRepository, roomRepo repository.RoomRepository, userRepo repository.UserRepository, fileStorage storage.Storage) *WorkOrderUseCase {
+	return &WorkOrderUseCase{workOrderRepo: workOrderRepo, roomRepo: roomRepo, userRepo: userRepo, fileStorage: fileStorage}
+}
+
+// CreateWorkOrder opens a work order for a room of propertyID (any room when
+// propertyID is 0).
+func (u *WorkOrderUseCase) CreateWorkOrder(ctx *gin.Context, req *dto.CreateWorkOrderRequest, reporterID uint, propertyID uint) (*models.WorkOrder, error) {
+	if !constant.IsValidWorkOrderCategory(req.Category) {
+		return nil, appError.ErrInvalidWorkOrderCategory
+	}
+	if !constant.IsValidWorkOrderPriority(req.Priority) {
+		return nil, appError.ErrInvalidWorkOrderPriority
+	}
+	room, err := u.roomRepo.FindRoomByID(ctx.Request.Context(), int(req.RoomID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appError.ErrRoomNotFound
+		}
+		return nil, errors.New("error.failed_to_get_room")
+	}
+	if propertyID != constant.AllProperties && (room.PropertyID == nil || *room.PropertyID != propertyID) {
+		return nil, appError.ErrPropertyAccessDenied
+	}
+
+	workOrder := &models.WorkOrder{
+		RoomID:      req.RoomID,
+		ReporterID:  reporterID,
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+		Category:    req.Category,
+		Priority:    req.Priority,
+		Status:      constant.WORK_ORDER_OPEN,
+		BlocksRoom:  constant.IsBlockingPriority(req.Priority),
+	}
+	if req.BlocksRoom != nil {
+		workOrder.BlocksRoom = *req.BlocksRoom
+	}
+	if req.AssigneeID != nil {
+		if err := u.validateAssignee(ctx.Request.Context(), *req.AssigneeID, roomProperty(room)); err != nil {
+			return nil, err
+		}
+		workOrder.AssigneeID = req.AssigneeID
+		workOrder.Status = constant.WORK_ORDER_ASSIGNED
+	}
+
+	db := u.workOrderRepo.GetDB()
+	err = utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if err := u.workOrderRepo.CreateWorkOrderTx(ctx.Request.Context(), tx, workOrder); err != nil {
+			return appError.ErrFailedToCreateWorkOrder
+		}
+		if len(req.Photos) > 0 {
+			savedFiles, err := u.saveWorkOrderPhotos(ctx, tx, workOrder.ID, req.Photos)
+			if err != nil {
+				deleteStoredFiles(ctx.Request.Context(), u.fileStorage, savedFiles)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return workOrder, nil
+}
+
+// saveWorkOrderPhotos runs each photo through the image pipeline, which strips
+// EXIF (including GPS) and caps the size, and keeps the large variant under a
+// generated name. The stored keys are returned even on error so the caller can
+// clean them up.
+func (u *WorkOrderUseCase) saveWorkOrderPhotos(ctx *gin.Context, tx *gorm.DB, workOrderID uint, fileHeaders []*multipart.FileHeader) ([]string, error) {
+	savedFiles := []string{}
+	for _, fileHeader := range fileHeaders {
+		data, err := utils.ReadUploadedImage(fileHeader)
+		if err != nil {
+			return savedFiles, err
+		}
+		processed, err := utils.ProcessImage(data)
+		if err != nil {
+			return savedFiles, err
+		}
+		key := fmt.Sprintf("work-orders/%d/%s_%s%s", workOrderID, uuid.New().String(), constant.IMAGE_VARIANT_LARGE, processed.Ext)
+		if err := u.fileStorage.Put(ctx.Request.Context(), key, processed.Variants[constant.IMAGE_VARIANT_LARGE], processed.ContentType); err != nil {
+			return savedFiles, appError.ErrFailedToSaveFile
+		}
+		savedFiles = append(savedFiles, key)
+
+		photo := &models.WorkOrderPhoto{
+			WorkOrderID: workOrderID,
+			ImageURL:    u.fileStorage.URL(key),
+		}
+		if err := u.workOrderRepo.CreateWorkOrderPhotoTx(ctx.Request.Context(), tx, photo); err != nil {
+			return savedFiles, appError.ErrFailedToCreateWorkOrder
+		}
+	}
+	return savedFiles, nil
+}
+
+// validateAssignee checks that assigneeID may work on a room of propertyID:
+// admins work across every property, staff only on the ones assigned to them.
+// A propertyID of 0 (a room not yet tied to a property) accepts any staff.
+func (u *WorkOrderUseCase) validateAssignee(ctx context.Context, assigneeID uint, propertyID uint) error {
+	assignee, err := u.userRepo.GetUserByID(ctx, int(assigneeID))
+	if err != nil {
+		return appError.ErrInvalidAssignee
+	}
+	switch assignee.Role {
+	case constant.ADMIN:
+		return nil
+	case constant.STAFF:
+	default:
+		return appError.ErrInvalidAssignee
+	}
+	if propertyID == constant.AllProperties {
+		return nil
+	}
+	assigned, err := u.userRepo.IsStaffOfProperty(ctx, assigneeID, propertyID)
+	if err != nil {
+		return appError.ErrFailedToUpdateWorkOrder
+	}
+	if !assigned {
+		return appError.ErrInvalidAssignee
+	}
+	return nil
+}
+
+// roomProperty returns the property a room belongs to, or
+// constant.AllProperties for a room not yet tied to one.
+func roomProperty(room *models.Room) uint {
+	if room == nil || room.PropertyID == nil {
+		return constant.AllProperties
+	}
+	return *room.PropertyID
+}
+
+// GetWorkOrder returns a work order for a room of propertyID. A propertyID of
+// 0 gives access to every work order.
+func (u *WorkOrderUseCase) GetWorkOrder(ctx context.Context, id uint, propertyID uint) (*models.WorkOrder, error) {
+	workOrder, err := u.workOrderRepo.GetWorkOrderByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appError.ErrWorkOrderNotFound
+		}
+		return nil, appError.ErrFailedToGetWorkOrder
+	}
+	if propertyID == constant.AllProperties {
+		return workOrder, nil
+	}
+	roomPropertyID, err := u.roomRepo.FindRoomPropertyID(ctx, int(workOrder.RoomID))
+	if err != nil {
+		return nil, appError.ErrFailedToGetWorkOrder
+	}
+	if roomPropertyID == nil || *roomPropertyID != propertyID {
+		return nil, appError.ErrPropertyAccessDenied
+	}
+	return workOrder, nil
+}
+
+// CheckWorkOrderAccess reports whether a work order is for a room of the
+// property being managed. A propertyID of 0 gives access to every work order.
+func (u *WorkOrderUseCase) CheckWorkOrderAccess(ctx context.Context, id uint, propertyID uint) error {
+	_, err := u.GetWorkOrder(ctx, id, propertyID)
+	return err
+}
+
+func (u *WorkOrderUseCase) SearchWorkOrders(ctx context.Context, query dto.WorkOrderQuery) ([]models.WorkOrder, error) {
+	if query.Status != "" && !constant.IsValidWorkOrderStatus(query.Status) {
+		return nil, appError.ErrInvalidWorkOrderStatus
+	}
+	if query.Priority != "" && !constant.IsValidWorkOrderPriority(query.Priority) {
+		return nil, appError.ErrInvalidWorkOrderPriority
+	}
+	if query.Category != "" && !constant.IsValidWorkOrderCategory(query.Category) {
+		return nil, appError.ErrInvalidWorkOrderCategory
+	}
+	workOrders, err := u.workOrderRepo.SearchWorkOrders(ctx, query)
+	if err != nil {
+		return nil, appError.ErrFailedToGetWorkOrder
+	}
+	return workOrders, nil
+}
+
+func (u *WorkOrderUseCase) UpdateStatus(ctx context.Context, id uint, propertyID uint, req *dto.UpdateWorkOrderStatusRequest) (*models.WorkOrder, error) {
+	if !constant.IsValidWorkOrderStatus(req.Status) {
+		return nil, appError.ErrInvalidWorkOrderStatus
+	}
+	workOrder, err := u.GetWorkOrder(ctx, id, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	if workOrder.Status == req.Status {
+		return workOrder, nil
+	}
+	if !constant.CanTransitionWorkOrder(workOrder.Status, req.Status) {
+		return nil, appError.ErrInvalidWorkOrderStatusTransition
+	}
+
+	note := strings.TrimSpace(req.ResolutionNote)
+	now := time.Now()
+	switch req.Status {
+	case constant.WORK_ORDER_RESOLVED:
+		if note == "" {
+			return nil, appError.ErrResolutionNoteRequired
+		}
+		workOrder.ResolutionNote = note
+		workOrder.ResolvedAt = &now
+	case constant.WORK_ORDER_CLOSED, constant.WORK_ORDER_CANCELLED:
+		if note != "" {
+			workOrder.ResolutionNote = note
+		}
+		workOrder.ClosedAt = &now
+	case constant.WORK_ORDER_IN_PROGRESS:
+		// Reopening a resolved ticket clears the previous resolution.
+		workOrder.ResolvedAt = nil
+	}
+	workOrder.Status = req.Status
+
+	if err := u.workOrderRepo.UpdateWorkOrder(ctx, workOrder); err != nil {
+		return nil, appError.ErrFailedToUpdateWorkOrder
+	}
+	return workOrder, nil
+}
+
+func (u *WorkOrderUseCase) AssignWorkOrder(ctx context.Context, id uint, propertyID uint, assigneeID uint) (*models.WorkOrder, error) {
+	workOrder, err := u.GetWorkOrder(ctx, id, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	if workOrder.Status == constant.WORK_ORDER_CLOSED || workOrder.Status == constant.WORK_ORDER_CANCELLED {
+		return nil, appError.ErrInvalidWorkOrderStatusTransition
+	}
+	if err := u.validateAssignee(ctx, assigneeID, roomProperty(&workOrder.Room)); err != nil {
+		return nil, err
+	}
+	workOrder.AssigneeID = &assigneeID
+	workOrder.Assignee = nil
+	if workOrder.Status == constant.WORK_ORDER_OPEN {
+		workOrder.Status = constant.WORK_ORDER_ASSIGNED
+	}
+	if err := u.workOrderRepo.UpdateWorkOrder(ctx, workOrder); err != nil {
+		return nil, appError.ErrFailedToUpdateWorkOrder
+	}
+	return workOrder, nil
+}
+
+// GetAssignees lists the staff who can take a work order: those assigned to
+// the property of its room.
+func (u *WorkOrderUseCase) GetAssignees(ctx context.Context, workOrder *models.WorkOrder) ([]models.User, error) {
+	propertyID := roomProperty(&workOrder.Room)
+	if propertyID == constant.AllProperties {
+		return u.userRepo.GetAll(ctx)
+	}
+	return u.userRepo.GetStaffByPropertyID(ctx, propertyID)
+}
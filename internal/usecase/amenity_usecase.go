@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"context"
+	"hotel-management/internal/dto"
+	"hotel-management/internal/repository"
+)
+
+type AmenityUseCase struct {
+	amenityRepo repository.AmenityRepository
+}
+
+func NewAmenityUseCase(amenityRepo repository.AmenityRepository) *AmenityUseCase {
+	return &AmenityUseCase{amenityRepo: amenityRepo}
+}
+
+func (u *AmenityUseCase) ListAmenities(ctx context.Context, lang string) ([]dto.AmenityResponse, error) {
+	amenities, err := u.amenityRepo.GetAllAmenities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.AmenityResponse, 0, len(amenities))
+	for _, amenity := range amenities {
+		responses = append(responses, dto.NewAmenityResponse(amenity, lang))
+	}
+	return responses, nil
+}
@@ -3,26 +3,40 @@ package usecase
 import (
 	"context"
 	"errors"
+	"hotel-management/internal/constant"
 	"hotel-management/internal/dto"
-
+	appError "hotel-management/internal/error"
 	"hotel-management/internal/models"
 	"hotel-management/internal/repository"
+	"hotel-management/internal/utils"
+	"log"
 	"math"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type BookingUseCase struct {
-	bookingRepo repository.BookingRepository
+	bookingRepo  repository.BookingRepository
+	ratePlanRepo repository.RatePlanRepository
+	holdDuration time.Duration
 }
 
-func NewBookingUseCase(bookingRepo repository.BookingRepository) *BookingUseCase {
-	return &BookingUseCase{bookingRepo: bookingRepo}
+func NewBookingUseCase(bookingRepo repository.BookingRepository, ratePlanRepo repository.RatePlanRepository) *BookingUseCase {
+	return &BookingUseCase{
+		bookingRepo:  bookingRepo,
+		ratePlanRepo: ratePlanRepo,
+		holdDuration: envMinutes("BOOKING_HOLD_MINUTES", constant.DefaultBookingHoldMinutes),
+	}
 }
 
-func (u *BookingUseCase) CreateBooking(ctx context.Context, createBookingRequest *dto.CreateBookingRequest, userID uint) error {
+// CreateBooking books the rooms under the chosen rate plan. Without a rate
+// plan the guest pays at the hotel. Pay-now and deposit bookings hold their
+// rooms as pending_payment until the payment succeeds or the hold runs out.
+func (u *BookingUseCase) CreateBooking(ctx context.Context, createBookingRequest *dto.CreateBookingRequest, userID uint) (*dto.CreateBookingResponse, error) {
 	var bookingRooms []*models.BookingRoom
 	var totalPrice float64
+	var propertyID *uint
 
 	db := u.bookingRepo.GetDB()
 	tx := db.Begin()
@@ -32,24 +46,35 @@ func (u *BookingUseCase) CreateBooking(ctx context.Context, createBookingRequest
 		}
 	}()
 
-	for _, roomID := range createBookingRequest.RoomIDs {
+	for i, roomID := range createBookingRequest.RoomIDs {
 		if roomID <= 0 {
-			return errors.New("error.invalid_room_id")
+			return nil, errors.New("error.invalid_room_id")
 		}
 		isAvailable, err := u.bookingRepo.IsAvailableRoom(ctx, tx, roomID, createBookingRequest.StartDate, createBookingRequest.EndDate)
 		if err != nil || !isAvailable {
 			tx.Rollback()
-			return errors.New("error.room_is_not_available")
+			return nil, errors.New("error.room_is_not_available")
 		}
 		price, err := u.bookingRepo.GetPriceByRoomID(ctx, tx, roomID)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			tx.Rollback()
-			return errors.New("error.room_not_found")
+			return nil, errors.New("error.room_not_found")
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, errors.New("error.failed_to_get_room_price")
 		}
+		// A booking belongs to a single property, so every room must too.
+		roomPropertyID, err := u.bookingRepo.GetRoomPropertyIDTx(ctx, tx, roomID)
 		if err != nil {
 			tx.Rollback()
-			return errors.New("error.failed_to_get_room_price")
+			return nil, errors.New("error.failed_to_get_room_price")
 		}
+		if i > 0 && !sameProperty(propertyID, roomPropertyID) {
+			tx.Rollback()
+			return nil, appError.ErrRoomsInDifferentProperties
+		}
+		propertyID = roomPropertyID
 		bookingRooms = append(bookingRooms, &models.BookingRoom{
 			RoomID: uint(roomID),
 			Price:  price,
@@ -59,31 +84,93 @@ func (u *BookingUseCase) CreateBooking(ctx context.Context, createBookingRequest
 	}
 	booking := &models.Booking{
 		UserID:        uint(userID),
+		PropertyID:    propertyID,
 		BookingStatus: "booked",
 		TotalPrice:    totalPrice,
 		IsPaid:        false,
 		StartDate:     createBookingRequest.StartDate,
 		EndDate:       createBookingRequest.EndDate,
+		PaymentTiming: constant.PAYMENT_TIMING_PAY_AT_HOTEL,
+	}
+	if createBookingRequest.RatePlanID != nil {
+		if err := u.applyRatePlanTx(ctx, tx, booking, *createBookingRequest.RatePlanID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 	}
 	err := u.bookingRepo.CreateBookingTx(ctx, tx, booking)
 	if err != nil {
 		tx.Rollback()
-		return errors.New("error.failed_to_create_booking")
+		return nil, errors.New("error.failed_to_create_booking")
 	}
 	for _, bookingRoom := range bookingRooms {
 		bookingRoom.BookingID = booking.ID
 		err := u.bookingRepo.CreateBookingRoomTx(ctx, tx, bookingRoom)
 		if err != nil {
 			tx.Rollback()
-			return errors.New("error.failed_to_create_booking")
+			return nil, errors.New("error.failed_to_create_booking")
 		}
 	}
 	if err := tx.Commit().Error; err != nil {
-		return errors.New("error.failed_to_commit_transaction")
+		return nil, errors.New("error.failed_to_commit_transaction")
+	}
+	return newCreateBookingResponse(booking), nil
+}
+
+// applyRatePlanTx sets how the booking is paid from its rate plan, which must
+// be active and belong to the booking's property.
+func (u *BookingUseCase) applyRatePlanTx(ctx context.Context, tx *gorm.DB, booking *models.Booking, ratePlanID uint) error {
+	ratePlan, err := u.ratePlanRepo.FindRatePlanByIDTx(ctx, tx, ratePlanID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !ratePlan.IsActive) {
+		return appError.ErrRatePlanNotFound
+	}
+	if err != nil {
+		return appError.ErrFailedToGetRatePlan
+	}
+	if booking.PropertyID == nil || *booking.PropertyID != ratePlan.PropertyID {
+		return appError.ErrRatePlanNotForProperty
+	}
+	booking.RatePlanID = &ratePlan.ID
+	booking.PaymentTiming = ratePlan.PaymentTiming
+	switch ratePlan.PaymentTiming {
+	case constant.PAYMENT_TIMING_PAY_NOW:
+		booking.BookingStatus = constant.PENDING_PAYMENT
+	case constant.PAYMENT_TIMING_DEPOSIT:
+		booking.BookingStatus = constant.PENDING_PAYMENT
+		booking.DepositAmount = math.Round(booking.TotalPrice * float64(ratePlan.DepositPercent) / 100)
+	}
+	if booking.BookingStatus == constant.PENDING_PAYMENT {
+		holdExpiresAt := time.Now().Add(u.holdDuration)
+		booking.HoldExpiresAt = &holdExpiresAt
 	}
 	return nil
 }
 
+func newCreateBookingResponse(booking *models.Booking) *dto.CreateBookingResponse {
+	response := &dto.CreateBookingResponse{
+		ID:            booking.ID,
+		Status:        booking.BookingStatus,
+		TotalPrice:    booking.TotalPrice,
+		PaymentTiming: booking.PaymentTiming,
+		DepositAmount: booking.DepositAmount,
+		HoldExpiresAt: booking.HoldExpiresAt,
+	}
+	switch booking.PaymentTiming {
+	case constant.PAYMENT_TIMING_PAY_NOW:
+		response.AmountDueNow = booking.TotalPrice
+	case constant.PAYMENT_TIMING_DEPOSIT:
+		response.AmountDueNow = booking.DepositAmount
+	}
+	return response
+}
+
+func sameProperty(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 func (u *BookingUseCase) GetBookingHistory(ctx context.Context, userID uint) ([]dto.BookingHistoryResponse, error) {
 	var bookingHistoryResponse []dto.BookingHistoryResponse
 	bookings, err := u.bookingRepo.GetBookingByUserID(ctx, userID)
@@ -102,6 +189,16 @@ func (u *BookingUseCase) GetBookingHistory(ctx context.Context, userID uint) ([]
 				Type:   room.Room.Type,
 				BedNum: room.Room.BedNum,
 				Price:  room.Price,
+
+				BookingRoomID: room.ID,
+			})
+		}
+		charges := make([]dto.BookingHistoryCharge, 0, len(booking.Charges))
+		for _, charge := range booking.Charges {
+			charges = append(charges, dto.BookingHistoryCharge{
+				ID:          charge.ID,
+				Description: charge.Description,
+				Amount:      charge.Amount,
 			})
 		}
 		bookingHistoryResponse = append(bookingHistoryResponse, dto.BookingHistoryResponse{
@@ -112,6 +209,15 @@ func (u *BookingUseCase) GetBookingHistory(ctx context.Context, userID uint) ([]
 			Status:     booking.BookingStatus,
 			IsPaid:     booking.IsPaid,
 			Rooms:      bookingRooms,
+
+			PaymentTiming: booking.PaymentTiming,
+			DepositAmount: booking.DepositAmount,
+			HoldExpiresAt: booking.HoldExpiresAt,
+
+			PaidAmount:     booking.PaidAmount,
+			RefundedAmount: booking.RefundedAmount,
+			Balance:        booking.Balance(),
+			Charges:        charges,
 		})
 	}
 	return bookingHistoryResponse, nil
@@ -128,12 +234,53 @@ func (u *BookingUseCase) CancelBooking(ctx context.Context, bookingID uint, user
 	if err != nil {
 		return errors.New("error.failed_to_get_booking")
 	}
-	if booking.BookingStatus != "booked" {
+	if booking.BookingStatus != "booked" && booking.BookingStatus != constant.PENDING_PAYMENT {
 		return errors.New("error.failed_to_cancel_booking")
 	}
+	booking.HoldExpiresAt = nil
 	booking.BookingStatus = "cancelled"
 	if err := u.bookingRepo.UpdateBooking(ctx, booking); err != nil {
 		return errors.New("error.failed_to_cancel_booking")
 	}
 	return nil
 }
+
+// ReleaseExpiredHolds cancels pending_payment bookings whose hold has run out,
+// freeing their rooms. It returns how many bookings were released.
+func (u *BookingUseCase) ReleaseExpiredHolds(ctx context.Context) (int, error) {
+	bookings, err := u.bookingRepo.GetExpiredHolds(ctx, time.Now(), constant.HoldReleaseBatchSize)
+	if err != nil {
+		return 0, appError.ErrFailedToGetBooking
+	}
+	released := 0
+	for _, expired := range bookings {
+		err := utils.WithTransaction(u.bookingRepo.GetDB(), func(tx *gorm.DB) error {
+			// Locking the booking waits for a payment being created or
+			// settled for it, which locks the booking too.
+			booking, err := u.bookingRepo.GetBookingByIDForUpdateTx(ctx, tx, expired.ID)
+			if err != nil {
+				return err
+			}
+			// A payment may have confirmed the booking since it was listed,
+			// or have been started just before its hold ran out.
+			if booking.BookingStatus != constant.PENDING_PAYMENT {
+				return nil
+			}
+			pending, err := u.bookingRepo.HasPendingPaymentsTx(ctx, tx, booking.ID)
+			if err != nil || pending {
+				return err
+			}
+			booking.BookingStatus = constant.CANCELLED
+			booking.HoldExpiresAt = nil
+			if err := u.bookingRepo.UpdateBookingTx(ctx, tx, booking); err != nil {
+				return err
+			}
+			released++
+			return nil
+		})
+		if err != nil {
+			log.Printf("release hold of booking %d: %v", expired.ID, err)
+		}
+	}
+	return released, nil
+}
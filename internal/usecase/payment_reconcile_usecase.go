@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	paymentError "hotel-management/internal/error"
+	"hotel-management/internal/gateway"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/utils"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxReconcileDetailLength fits PaymentReconciliationEntry.Detail.
+const maxReconcileDetailLength = 255
+
+// PaymentReconcileUseCase closes payments left pending, for instance when a
+// customer abandons the VNPay page or the IPN never arrives. Payments older
+// than settleAfter are looked up with their gateway and settled as the
+// gateway reports; those still open after expireAfter are expired. Payments
+// confirmed at the front desk are left to staff, and so are payments whose
+// lookup failed constant.MaxReconcileErrors times in a row.
+type PaymentReconcileUseCase struct {
+	payments           *PaymentUseCase
+	reconciliationRepo repository.ReconciliationRepository
+	settleAfter        time.Duration
+	expireAfter        time.Duration
+	batchSize          int
+}
+
+func NewPaymentReconcileUseCase(payments *PaymentUseCase, reconciliationRepo repository.ReconciliationRepository) *PaymentReconcileUseCase {
+	return &PaymentReconcileUseCase{
+		payments:           payments,
+		reconciliationRepo: reconciliationRepo,
+		settleAfter:        payments.staleAfter,
+		expireAfter:        envMinutes("PAYMENT_EXPIRE_AFTER_MINUTES", constant.DefaultPaymentExpiryMinutes),
+		batchSize:          constant.ReconcileBatchSize,
+	}
+}
+
+func envMinutes(key string, fallback int) time.Duration {
+	minutes := fallback
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			minutes = parsed
+		} else {
+			log.Printf("invalid %s %q, using %d", key, value, minutes)
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// Reconcile checks one batch of stale pending payments and saves what it did
+// as a reconciliation report. Runs that found nothing to check are not saved.
+func (u *PaymentReconcileUseCase) Reconcile(ctx context.Context) (*models.PaymentReconciliation, error) {
+	run := &models.PaymentReconciliation{StartedAt: time.Now()}
+	var methods []string
+	for _, method := range u.payments.gateways.Methods() {
+		if paymentGateway, err := u.payments.gateways.Get(method); err == nil && !gateway.IsManual(paymentGateway) {
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return run, nil
+	}
+	payments, err := u.payments.paymentRepo.GetPendingPaymentsBefore(ctx, run.StartedAt.Add(-u.settleAfter), methods, u.batchSize)
+	if err != nil {
+		return nil, paymentError.ErrFailedToGetPayment
+	}
+
+	for i := range payments {
+		entry := u.reconcilePayment(ctx, &payments[i], run.StartedAt)
+		u.markChecked(ctx, &payments[i], entry, run.StartedAt)
+		run.Entries = append(run.Entries, entry)
+		run.Checked++
+		switch entry.Outcome {
+		case constant.RECONCILE_SETTLED:
+			run.Settled++
+		case constant.RECONCILE_FAILED:
+			run.Failed++
+		case constant.RECONCILE_EXPIRED:
+			run.Expired++
+		case constant.RECONCILE_ERROR:
+			run.Errors++
+		}
+	}
+	run.FinishedAt = time.Now()
+	if run.Checked == 0 {
+		return run, nil
+	}
+	if err := u.reconciliationRepo.CreateReconciliation(ctx, run); err != nil {
+		return nil, paymentError.ErrFailedToSaveReconciliation
+	}
+	return run, nil
+}
+
+func (u *PaymentReconcileUseCase) reconcilePayment(ctx context.Context, payment *models.Payment, now time.Time) models.PaymentReconciliationEntry {
+	entry := models.PaymentReconciliationEntry{
+		PaymentID:     payment.ID,
+		TxnRef:        payment.TxnRef,
+		PaymentMethod: payment.PaymentMethod,
+	}
+	paymentGateway, err := u.payments.gateways.Get(payment.PaymentMethod)
+	if err != nil {
+		return withOutcome(entry, constant.RECONCILE_ERROR, err)
+	}
+	result, queryErr := paymentGateway.QueryStatus(ctx, gateway.Transaction{
+		TxnRef:        payment.TxnRef,
+		TransactionID: payment.TransactionID,
+		Amount:        int64(payment.Amount),
+		CreatedAt:     payment.CreatedAt,
+	})
+	if queryErr == nil && result.Status != constant.PAYMENT_PENDING {
+		err := u.settle(ctx, payment.TxnRef, result)
+		switch {
+		case errors.Is(err, paymentError.ErrPaymentAlreadyProcessed):
+			return withOutcome(entry, constant.RECONCILE_UNCHANGED, err)
+		case err != nil:
+			return withOutcome(entry, constant.RECONCILE_ERROR, err)
+		case result.Status == constant.PAYMENT_SUCCESS:
+			return withOutcome(entry, constant.RECONCILE_SETTLED, nil)
+		default:
+			return withOutcome(entry, constant.RECONCILE_FAILED, nil)
+		}
+	}
+
+	// A payment is only expired on a definite answer: the gateway still
+	// reports it open after its payment page has expired, or does not know
+	// it, which is what VNPay answers for a payment page never submitted.
+	// Any other error says nothing about the payment, which may have been
+	// made, so it stays pending and is looked up again on the next run.
+	if queryErr != nil && !errors.Is(queryErr, paymentError.ErrGatewayTransactionNotFound) {
+		return withOutcome(entry, constant.RECONCILE_ERROR, queryErr)
+	}
+	if payment.CreatedAt.After(now.Add(-u.expireAfter)) {
+		return withOutcome(entry, constant.RECONCILE_UNCHANGED, queryErr)
+	}
+	if err := u.expire(ctx, payment.TxnRef); err != nil {
+		if errors.Is(err, paymentError.ErrPaymentAlreadyProcessed) {
+			return withOutcome(entry, constant.RECONCILE_UNCHANGED, err)
+		}
+		return withOutcome(entry, constant.RECONCILE_ERROR, err)
+	}
+	return withOutcome(entry, constant.RECONCILE_EXPIRED, queryErr)
+}
+
+// markChecked records the lookup of a payment that is still pending, so the
+// next run starts with other payments, and counts it when it failed.
+func (u *PaymentReconcileUseCase) markChecked(ctx context.Context, payment *models.Payment, entry models.PaymentReconciliationEntry, checkedAt time.Time) {
+	failed := entry.Outcome == constant.RECONCILE_ERROR
+	if !failed && entry.Outcome != constant.RECONCILE_UNCHANGED {
+		return
+	}
+	if err := u.payments.paymentRepo.MarkReconcileChecked(ctx, payment.ID, checkedAt, failed); err != nil {
+		log.Printf("mark payment %d (%s) checked: %v", payment.ID, payment.TxnRef, err)
+		return
+	}
+	if failed && payment.ReconcileErrors+1 >= constant.MaxReconcileErrors {
+		log.Printf("payment %d (%s) is stuck after %d failed lookups", payment.ID, payment.TxnRef, constant.MaxReconcileErrors)
+	}
+}
+
+// settle applies a gateway result the same way a callback would, holding
+// the payment's lock so a callback arriving meanwhile waits and then sees it
+// settled.
+func (u *PaymentReconcileUseCase) settle(ctx context.Context, txnRef string, result *gateway.Result) error {
+	return utils.WithTransaction(u.payments.paymentRepo.GetDB(), func(tx *gorm.DB) error {
+		payment, err := u.payments.paymentRepo.GetPaymentByTxnRefForUpdateTx(ctx, tx, txnRef)
+		if err != nil {
+			return paymentError.ErrFailedToGetPayment
+		}
+		return u.payments.applyResultTx(ctx, tx, payment, result)
+	})
+}
+
+// expire closes a payment that is still pending, re-reading it under lock in
+// case a late callback settled it in the meantime.
+func (u *PaymentReconcileUseCase) expire(ctx context.Context, txnRef string) error {
+	return utils.WithTransaction(u.payments.paymentRepo.GetDB(), func(tx *gorm.DB) error {
+		payment, err := u.payments.paymentRepo.GetPaymentByTxnRefForUpdateTx(ctx, tx, txnRef)
+		if err != nil {
+			return paymentError.ErrFailedToGetPayment
+		}
+		if payment.PaymentStatus != constant.PAYMENT_PENDING {
+			return paymentError.ErrPaymentAlreadyProcessed
+		}
+		payment.PaymentStatus = constant.PAYMENT_EXPIRED
+		if err := u.payments.paymentRepo.UpdatePaymentTx(ctx, tx, payment); err != nil {
+			return paymentError.ErrFailedToUpdatePayment
+		}
+		return nil
+	})
+}
+
+func withOutcome(entry models.PaymentReconciliationEntry, outcome string, err error) models.PaymentReconciliationEntry {
+	entry.Outcome = outcome
+	if err != nil {
+		entry.Detail = err.Error()
+		if len(entry.Detail) > maxReconcileDetailLength {
+			entry.Detail = entry.Detail[:maxReconcileDetailLength]
+		}
+		if outcome == constant.RECONCILE_ERROR {
+			log.Printf("reconcile payment %d (%s): %v", entry.PaymentID, entry.TxnRef, err)
+		}
+	}
+	return entry
+}
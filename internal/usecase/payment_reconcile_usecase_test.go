@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/gateway"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/testutil"
+	"testing"
+	"time"
+)
+
+// unreachableGateway fails every lookup of the transactions in down, the way
+// a gateway does when it times out on them.
+type unreachableGateway struct {
+	*gateway.FakeGateway
+	down map[string]bool
+}
+
+func (g *unreachableGateway) QueryStatus(ctx context.Context, txn gateway.Transaction) (*gateway.Result, error) {
+	if g.down[txn.TxnRef] {
+		return nil, errors.New("gateway timeout")
+	}
+	return g.FakeGateway.QueryStatus(ctx, txn)
+}
+
+func TestReconcileDoesNotStarveBehindFailingLookups(t *testing.T) {
+	db := testutil.NewDB(t)
+	fake := &unreachableGateway{FakeGateway: gateway.NewFakeGateway(), down: map[string]bool{}}
+	payments := NewPaymentUseCase(
+		repository.NewPaymentRepository(db),
+		repository.NewBookingRepository(db),
+		repository.NewBillRepository(db),
+		gateway.NewRegistry(fake),
+	)
+	payments.staleAfter = testStaleAfter
+	reconciler := NewPaymentReconcileUseCase(payments, repository.NewReconciliationRepository(db))
+	reconciler.expireAfter = 24 * time.Hour
+	reconciler.batchSize = 1
+	ctx := context.Background()
+
+	booking := createHeldBooking(t, db, constant.PAYMENT_TIMING_PAY_NOW)
+	failing, err := payFake(payments, booking)
+	if err != nil {
+		t.Fatalf("first CreatePayment: %v", err)
+	}
+	// The first payment is abandoned so the booking can be paid again; it is
+	// the oldest, so it is the one looked up first.
+	db.Model(&models.Payment{}).Where("id = ?", failing.PaymentID).
+		Update("created_at", time.Now().Add(-2*testStaleAfter))
+	healthy, err := payFake(payments, booking)
+	if err != nil {
+		t.Fatalf("second CreatePayment: %v", err)
+	}
+	db.Model(&models.Payment{}).Where("id = ?", healthy.PaymentID).
+		Update("created_at", time.Now().Add(-testStaleAfter-time.Minute))
+	fake.down[failing.TxnRef] = true
+	fake.SetStatus(healthy.TxnRef, int64(healthy.Amount), constant.PAYMENT_SUCCESS)
+
+	run, err := reconciler.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if run.Errors != 1 || run.Entries[0].PaymentID != failing.PaymentID {
+		t.Fatalf("first run = %+v, want a failed lookup of payment %d", run.Entries, failing.PaymentID)
+	}
+	run, err = reconciler.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if run.Settled != 1 || run.Entries[0].PaymentID != healthy.PaymentID {
+		t.Fatalf("second run = %+v, want payment %d settled", run.Entries, healthy.PaymentID)
+	}
+
+	// The failing payment is retried until it is given up on.
+	for i := 1; i < constant.MaxReconcileErrors; i++ {
+		if run, err = reconciler.Reconcile(ctx); err != nil || run.Errors != 1 {
+			t.Fatalf("run %d: errors = %d, err = %v", i, run.Errors, err)
+		}
+	}
+	if run, err = reconciler.Reconcile(ctx); err != nil || run.Checked != 0 {
+		t.Fatalf("run after the cap: checked = %d, err = %v, want 0", run.Checked, err)
+	}
+
+	paymentRepo := repository.NewPaymentRepository(db)
+	stuck, err := paymentRepo.GetStuckPayments(ctx, constant.AllProperties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stuck) != 1 || stuck[0].ID != failing.PaymentID {
+		t.Fatalf("stuck payments = %+v, want payment %d", stuck, failing.PaymentID)
+	}
+
+	// Checking it again once the gateway answers settles it.
+	if err := paymentRepo.ResetReconcileErrors(ctx, failing.PaymentID); err != nil {
+		t.Fatal(err)
+	}
+	delete(fake.down, failing.TxnRef)
+	fake.SetStatus(failing.TxnRef, int64(failing.Amount), constant.PAYMENT_FAILED)
+	if run, err = reconciler.Reconcile(ctx); err != nil || run.Failed != 1 {
+		t.Fatalf("run after the retry: failed = %d, err = %v, want 1", run.Failed, err)
+	}
+	if stuck, _ := paymentRepo.GetStuckPayments(ctx, constant.AllProperties); len(stuck) != 0 {
+		t.Errorf("%d stuck payments after the retry, want 0", len(stuck))
+	}
+}
@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	paymentError "hotel-management/internal/error"
+	"hotel-management/internal/models"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// allocateTx sets what a new payment pays for. A booking holding its rooms
+// for payment pays in full or its deposit, one payment at a time. After
+// checkout guests pay the balance, or split it between payers by line items
+// or by amount. Payments still in progress count as paid, so that two payers
+// cannot pay for the same thing, until they go stale: a guest who abandoned a
+// payment can then pay again. A stale payment that still succeeds is recorded
+// all the same; the booking is then listed as overpaid on the admin payments
+// page until an admin refunds the excess.
+func (u *PaymentUseCase) allocateTx(ctx context.Context, tx *gorm.DB, booking *models.Booking, req *dto.CreatePaymentRequest, payment *models.Payment) error {
+	pendingSince := time.Now().Add(-u.staleAfter)
+	switch booking.BookingStatus {
+	case constant.PENDING_PAYMENT:
+		if req.IsSplit() {
+			return paymentError.ErrSplitPaymentNotAllowed
+		}
+		if booking.HoldExpiresAt != nil && booking.HoldExpiresAt.Before(time.Now()) {
+			return paymentError.ErrBookingHoldExpired
+		}
+		open, err := u.paymentRepo.SumOpenPaymentsTx(ctx, tx, booking.ID, pendingSince)
+		if err != nil {
+			return paymentError.ErrFailedToGetPayment
+		}
+		if open > 0 {
+			return paymentError.ErrPaymentInProgress
+		}
+		payment.Amount = booking.TotalPrice
+		if booking.PaymentTiming == constant.PAYMENT_TIMING_DEPOSIT {
+			payment.Amount = booking.DepositAmount
+		}
+		return nil
+	case constant.CHECKED_OUT:
+	default:
+		return paymentError.ErrBookingNotCheckedOut
+	}
+
+	open, err := u.paymentRepo.SumOpenPaymentsTx(ctx, tx, booking.ID, pendingSince)
+	if err != nil {
+		return paymentError.ErrFailedToGetPayment
+	}
+	remaining := booking.TotalPrice - open
+	if remaining <= 0 {
+		if booking.Balance() > 0 {
+			return paymentError.ErrPaymentInProgress
+		}
+		return paymentError.ErrBookingHasPaid
+	}
+
+	switch {
+	case len(req.LineItems) > 0:
+		if err := u.bookingRepo.LoadLineItemsTx(ctx, tx, booking); err != nil {
+			return paymentError.ErrFailedToGetBooking
+		}
+		taken, err := u.paymentRepo.GetOpenAllocationsTx(ctx, tx, booking.ID, pendingSince)
+		if err != nil {
+			return paymentError.ErrFailedToGetPayment
+		}
+		allocations, err := allocateLineItems(booking, req.LineItems, taken)
+		if err != nil {
+			return err
+		}
+		payment.Allocations = allocations
+		for _, allocation := range allocations {
+			payment.Amount += allocation.Amount
+		}
+	case req.Amount > 0:
+		payment.Amount = math.Round(req.Amount)
+	default:
+		payment.Amount = remaining
+	}
+	if payment.Amount <= 0 {
+		return paymentError.ErrInvalidPaymentAmount
+	}
+	if payment.Amount > remaining {
+		return paymentError.ErrPaymentExceedsBalance
+	}
+	return nil
+}
+
+// allocateLineItems allocates what is left to pay on each of the given line
+// items, after the allocations already taken by other payments.
+func allocateLineItems(booking *models.Booking, items []dto.PaymentLineItem, taken []models.PaymentAllocation) ([]models.PaymentAllocation, error) {
+	takenRooms := make(map[uint]float64)
+	takenCharges := make(map[uint]float64)
+	for _, allocation := range taken {
+		if allocation.BookingRoomID != nil {
+			takenRooms[*allocation.BookingRoomID] += allocation.Amount
+		}
+		if allocation.BookingChargeID != nil {
+			takenCharges[*allocation.BookingChargeID] += allocation.Amount
+		}
+	}
+
+	allocations := make([]models.PaymentAllocation, 0, len(items))
+	for _, item := range items {
+		var allocation models.PaymentAllocation
+		switch item.Type {
+		case constant.LINE_ITEM_ROOM:
+			room := findBookingRoom(booking.BookingRooms, item.ID)
+			if room == nil {
+				return nil, paymentError.ErrInvalidLineItem
+			}
+			roomID := room.ID
+			allocation = models.PaymentAllocation{BookingRoomID: &roomID, Amount: booking.RoomAmount(*room) - takenRooms[roomID]}
+			// The same line item listed twice is left nothing to pay the
+			// second time.
+			takenRooms[roomID] = booking.RoomAmount(*room)
+		case constant.LINE_ITEM_CHARGE:
+			charge := findBookingCharge(booking.Charges, item.ID)
+			if charge == nil {
+				return nil, paymentError.ErrInvalidLineItem
+			}
+			chargeID := charge.ID
+			allocation = models.PaymentAllocation{BookingChargeID: &chargeID, Amount: charge.Amount - takenCharges[chargeID]}
+			takenCharges[chargeID] = charge.Amount
+		default:
+			return nil, paymentError.ErrInvalidLineItem
+		}
+		allocation.Amount = math.Round(allocation.Amount)
+		if allocation.Amount <= 0 {
+			return nil, paymentError.ErrLineItemAlreadyPaid
+		}
+		allocations = append(allocations, allocation)
+	}
+	return allocations, nil
+}
+
+func findBookingRoom(rooms []models.BookingRoom, id uint) *models.BookingRoom {
+	for i := range rooms {
+		if rooms[i].ID == id {
+			return &rooms[i]
+		}
+	}
+	return nil
+}
+
+func findBookingCharge(charges []models.BookingCharge, id uint) *models.BookingCharge {
+	for i := range charges {
+		if charges[i].ID == id {
+			return &charges[i]
+		}
+	}
+	return nil
+}
+
+// issueBillsTx invoices the successful payments of a paid booking that are
+// not on a bill yet, with one bill per payer. Refunds made before the bill
+// was issued are carried onto it.
+func (u *PaymentUseCase) issueBillsTx(ctx context.Context, tx *gorm.DB, bookingID uint) error {
+	payments, err := u.paymentRepo.GetUnbilledPaymentsTx(ctx, tx, bookingID)
+	if err != nil {
+		return paymentError.ErrFailedToGetPayment
+	}
+	var payers []string
+	byPayer := make(map[string][]models.Payment)
+	for _, payment := range payments {
+		if _, ok := byPayer[payment.PayerName]; !ok {
+			payers = append(payers, payment.PayerName)
+		}
+		byPayer[payment.PayerName] = append(byPayer[payment.PayerName], payment)
+	}
+
+	for _, payer := range payers {
+		bill := &models.Bill{
+			BookingID: bookingID,
+			PayerName: payer,
+			ExportAt:  time.Now(),
+		}
+		paymentIDs := make([]uint, 0, len(byPayer[payer]))
+		for _, payment := range byPayer[payer] {
+			bill.TotalAmount += payment.Amount
+			for _, refund := range payment.Refunds {
+				if refund.Status == constant.REFUND_SUCCESS {
+					bill.RefundedAmount += refund.Amount
+				}
+			}
+			paymentIDs = append(paymentIDs, payment.ID)
+		}
+		if err := u.billRepo.CreateBillTx(ctx, tx, bill); err != nil {
+			return paymentError.ErrFailedToCreateBill
+		}
+		if err := u.paymentRepo.SetPaymentsBillTx(ctx, tx, paymentIDs, bill.ID); err != nil {
+			return paymentError.ErrFailedToUpdatePayment
+		}
+	}
+	return nil
+}
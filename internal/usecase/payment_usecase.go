@@ -5,108 +5,354 @@ import (
 	"errors"
 	"fmt"
 	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
 	paymentError "hotel-management/internal/error"
+	"hotel-management/internal/gateway"
 	"hotel-management/internal/models"
 	"hotel-management/internal/repository"
 	"hotel-management/internal/utils"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// maxCallbackOutcomeLength fits PaymentCallback.Outcome.
+const maxCallbackOutcomeLength = 100
+
 type PaymentUseCase struct {
 	paymentRepo repository.PaymentRepository
 	bookingRepo repository.BookingRepository
 	billRepo    repository.BillRepository
+	gateways    *gateway.Registry
+	// staleAfter is how long a pending payment counts against the balance.
+	// By then its payment page has expired and the reconciler looks it up.
+	staleAfter time.Duration
 }
 
-func NewPaymentUseCase(paymentRepo repository.PaymentRepository, bookingRepo repository.BookingRepository, billRepo repository.BillRepository) *PaymentUseCase {
-	return &PaymentUseCase{paymentRepo: paymentRepo, bookingRepo: bookingRepo, billRepo: billRepo}
+func NewPaymentUseCase(paymentRepo repository.PaymentRepository, bookingRepo repository.BookingRepository, billRepo repository.BillRepository, gateways *gateway.Registry) *PaymentUseCase {
+	return &PaymentUseCase{
+		paymentRepo: paymentRepo,
+		bookingRepo: bookingRepo,
+		billRepo:    billRepo,
+		gateways:    gateways,
+		staleAfter:  envMinutes("PAYMENT_RECONCILE_AFTER_MINUTES", constant.DefaultReconcileAfterMinutes),
+	}
 }
 
-func (u *PaymentUseCase) GetVnPayUrl(ctx context.Context, bookingID uint, clientIP string) (string, error) {
-	paymentURL := ""
-	booking, err := u.bookingRepo.GetBookingByID(ctx, bookingID)
-	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return paymentURL, paymentError.ErrBookingNotFound
-	}
+// CreatePayment starts paying a booking through the gateway of the chosen
+// method, for the amount the booking's payment timing asks for now or, when
+// the bill is split, for the payer's share. Customers can only pay their own
+// bookings.
+func (u *PaymentUseCase) CreatePayment(ctx context.Context, bookingID uint, userID uint, req *dto.CreatePaymentRequest, clientIP string) (*dto.CreatePaymentResponse, error) {
+	paymentGateway, err := u.gateways.Get(req.PaymentMethod)
 	if err != nil {
-		return paymentURL, paymentError.ErrFailedToGetBooking
-	}
-	if booking.BookingStatus != constant.CHECKED_OUT {
-		return paymentURL, paymentError.ErrBookingNotCheckedOut
-	}
-	if booking.IsPaid {
-		return paymentURL, paymentError.ErrBookingHasPaid
+		return nil, err
 	}
 	txnRef := fmt.Sprintf("%d-%s", bookingID, uuid.New().String())
-
 	newPayment := &models.Payment{
-		BookingID:     booking.ID,
+		BookingID:     bookingID,
 		TransactionID: "",
-		PaymentMethod: "vnpay",
+		PaymentMethod: paymentGateway.Method(),
 		PaymentStatus: constant.PAYMENT_PENDING,
 		PaidAt:        time.Now(),
 		TxnRef:        txnRef,
+		PayerName:     strings.TrimSpace(req.PayerName),
 	}
-	err = u.paymentRepo.CreatePayment(ctx, newPayment)
+	err = utils.WithTransaction(u.paymentRepo.GetDB(), func(tx *gorm.DB) error {
+		booking, err := u.bookingRepo.GetBookingByIDForUpdateTx(ctx, tx, bookingID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return paymentError.ErrBookingNotFound
+		}
+		if err != nil {
+			return paymentError.ErrFailedToGetBooking
+		}
+		if booking.UserID != userID {
+			return paymentError.ErrBookingNotFound
+		}
+		if booking.IsPaid {
+			return paymentError.ErrBookingHasPaid
+		}
+		// A held booking is released once its payments are settled or
+		// expired, which only gateways reporting the outcome do. A payment
+		// waiting for staff would hold the rooms for good.
+		if booking.BookingStatus == constant.PENDING_PAYMENT && gateway.IsManual(paymentGateway) {
+			return paymentError.ErrHoldNeedsOnlinePayment
+		}
+		if err := u.allocateTx(ctx, tx, booking, req, newPayment); err != nil {
+			return err
+		}
+		if err := u.paymentRepo.CreatePaymentTx(ctx, tx, newPayment); err != nil {
+			return errors.New("error.failed_to_save_payment")
+		}
+		return nil
+	})
 	if err != nil {
-		return paymentURL, errors.New("error.failed_to_save_payment")
+		return nil, err
 	}
-	paymentURL, err = utils.CreateVnpayPaymentURL(txnRef, fmt.Sprint(booking.ID), int(booking.TotalPrice), clientIP, constant.HOTEL_ORDER_TYPE)
+	result, err := paymentGateway.CreatePayment(ctx, gateway.PaymentRequest{
+		TxnRef:    txnRef,
+		BookingID: bookingID,
+		Amount:    int64(newPayment.Amount),
+		ClientIP:  clientIP,
+	})
 	if err != nil {
-		return paymentURL, errors.New("error.failed_to_create_vnpay_payment")
+		log.Printf("create %s payment %s failed: %v", newPayment.PaymentMethod, txnRef, err)
+		return nil, paymentError.ErrFailedToCreatePayment
+	}
+	response := &dto.CreatePaymentResponse{
+		PaymentID:     newPayment.ID,
+		BookingID:     newPayment.BookingID,
+		TxnRef:        newPayment.TxnRef,
+		PaymentMethod: newPayment.PaymentMethod,
+		PayerName:     newPayment.PayerName,
+		Amount:        newPayment.Amount,
+		PaymentStatus: newPayment.PaymentStatus,
+		PaymentURL:    result.RedirectURL,
+	}
+	if transfer := result.BankTransfer; transfer != nil {
+		response.BankTransfer = &dto.BankTransferResponse{
+			BankBIN:       transfer.BankBIN,
+			AccountNumber: transfer.AccountNumber,
+			AccountName:   transfer.AccountName,
+			Reference:     transfer.Reference,
+			QRPayload:     transfer.QRPayload,
+		}
 	}
-	return paymentURL, nil
+	return response, nil
 }
 
-func (u *PaymentUseCase) HandleVnpayCallback(ctx context.Context, vnpTxnRef, vnpResponseCode, vnpTransactionNo string) error {
+func (u *PaymentUseCase) GetVnPayUrl(ctx context.Context, bookingID uint, userID uint, clientIP string) (string, error) {
+	payment, err := u.CreatePayment(ctx, bookingID, userID, &dto.CreatePaymentRequest{PaymentMethod: constant.PAYMENT_METHOD_VNPAY}, clientIP)
+	if err != nil {
+		if errors.Is(err, paymentError.ErrFailedToCreatePayment) {
+			return "", errors.New("error.failed_to_create_vnpay_payment")
+		}
+		return "", err
+	}
+	return payment.PaymentURL, nil
+}
+
+// HandleCallback applies a provider's notification about a payment, after
+// the gateway of the method has authenticated it. The order, the amount and
+// the status are checked in that order, as VNPay's IPN contract expects.
+// Authenticated callbacks are kept with their outcome for dispute
+// investigation.
+func (u *PaymentUseCase) HandleCallback(ctx context.Context, method string, params url.Values) error {
+	paymentGateway, err := u.gateways.Get(method)
+	if err != nil {
+		return err
+	}
+	result, err := paymentGateway.VerifyCallback(ctx, params)
+	if err != nil {
+		return err
+	}
+	err = u.applyCallback(ctx, paymentGateway, result)
+	outcome := "ok"
+	if err != nil {
+		outcome = err.Error()
+	}
+	callback := &models.PaymentCallback{
+		TxnRef:        result.TxnRef,
+		PaymentMethod: paymentGateway.Method(),
+		Payload:       params.Encode(),
+		Outcome:       outcome,
+	}
+	if len(callback.Outcome) > maxCallbackOutcomeLength {
+		callback.Outcome = callback.Outcome[:maxCallbackOutcomeLength]
+	}
+	if saveErr := u.paymentRepo.CreatePaymentCallback(ctx, callback); saveErr != nil {
+		log.Printf("save %s callback for %s failed: %v", callback.PaymentMethod, callback.TxnRef, saveErr)
+	}
+	return err
+}
+
+func (u *PaymentUseCase) applyCallback(ctx context.Context, paymentGateway gateway.Gateway, result *gateway.Result) error {
 	tx := u.paymentRepo.GetDB()
 	return utils.WithTransaction(tx, func(tx *gorm.DB) error {
-		payment, err := u.paymentRepo.GetPaymentByTxnRefTx(ctx, tx, vnpTxnRef)
+		// The payment is locked before its booking, as everywhere it is
+		// settled, so that a retried IPN or the reconciler waits for this
+		// callback and then finds the payment settled.
+		payment, err := u.paymentRepo.GetPaymentByTxnRefForUpdateTx(ctx, tx, result.TxnRef)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return paymentError.ErrPaymentNotFound
 		}
 		if err != nil {
 			return paymentError.ErrFailedToGetPayment
 		}
-
-		booking, err := u.bookingRepo.GetBookingByIDTx(ctx, tx, payment.BookingID)
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return paymentError.ErrBookingNotFound
-		}
-		if err != nil {
-			return paymentError.ErrFailedToGetBooking
+		// A callback only settles payments made through its own gateway.
+		if payment.PaymentMethod != paymentGateway.Method() {
+			return paymentError.ErrPaymentNotFound
 		}
+		return u.applyResultTx(ctx, tx, payment, result)
+	})
+}
 
-		if payment.PaymentStatus != constant.PAYMENT_PENDING {
-			return paymentError.ErrPaymentAlreadyProcessed
-		}
+// ConfirmManualPayment records that a guest paid at the front desk, in cash
+// or on the card terminal, or that their bank transfer arrived. Staff may
+// only confirm payments for bookings of propertyID (any booking when it is 0).
+func (u *PaymentUseCase) ConfirmManualPayment(ctx context.Context, paymentID uint, propertyID uint, req *dto.ConfirmPaymentRequest) error {
+	payment, err := u.paymentRepo.GetPaymentDetail(ctx, paymentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return paymentError.ErrPaymentNotFound
+	}
+	if err != nil {
+		return paymentError.ErrFailedToGetPayment
+	}
+	if propertyID != constant.AllProperties &&
+		(payment.Booking.PropertyID == nil || *payment.Booking.PropertyID != propertyID) {
+		return paymentError.ErrPropertyAccessDenied
+	}
+	paymentGateway, err := u.gateways.Get(payment.PaymentMethod)
+	if err != nil {
+		return err
+	}
+	if !gateway.IsManual(paymentGateway) {
+		return paymentError.ErrPaymentNotManual
+	}
+	params := url.Values{}
+	params.Set(gateway.MANUAL_PARAM_TXN_REF, payment.TxnRef)
+	params.Set(gateway.MANUAL_PARAM_AMOUNT, strconv.FormatInt(int64(req.Amount), 10))
+	params.Set(gateway.MANUAL_PARAM_REFERENCE, req.Reference)
+	return u.HandleCallback(ctx, payment.PaymentMethod, params)
+}
 
-		if vnpResponseCode == "00" {
-			payment.PaymentStatus = constant.PAYMENT_SUCCESS
-			payment.TransactionID = vnpTransactionNo
-			booking.IsPaid = true
+// applyResultTx settles a pending payment, which the caller has locked. A
+// successful payment confirms a booking holding its rooms for payment; once
+// the balance reaches zero the booking is marked paid and its bills issued.
+// A failed payment releases the hold unless another payment of the booking
+// is still in progress.
+func (u *PaymentUseCase) applyResultTx(ctx context.Context, tx *gorm.DB, payment *models.Payment, result *gateway.Result) error {
+	if result.Amount != int64(payment.Amount) {
+		return paymentError.ErrPaymentAmountMismatch
+	}
+	if payment.PaymentStatus != constant.PAYMENT_PENDING {
+		return paymentError.ErrPaymentAlreadyProcessed
+	}
 
-			if err := u.bookingRepo.UpdateBookingTx(ctx, tx, booking); err != nil {
-				return paymentError.ErrFailedToUpdateBooking
-			}
+	booking, err := u.bookingRepo.GetBookingByIDForUpdateTx(ctx, tx, payment.BookingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return paymentError.ErrBookingNotFound
+	}
+	if err != nil {
+		return paymentError.ErrFailedToGetBooking
+	}
 
-			bill := &models.Bill{
-				BookingID:   booking.ID,
-				TotalAmount: booking.TotalPrice,
-				ExportAt:    time.Now(),
-			}
-			if err := u.billRepo.CreateBillTx(ctx, tx, bill); err != nil {
-				return paymentError.ErrFailedToCreateBill
-			}
-		} else {
-			payment.PaymentStatus = constant.PAYMENT_FAILED
+	switch result.Status {
+	case constant.PAYMENT_SUCCESS:
+		payment.PaymentStatus = constant.PAYMENT_SUCCESS
+		payment.TransactionID = result.TransactionID
+		payment.PaidAt = time.Now()
+		if booking.BookingStatus == constant.PENDING_PAYMENT {
+			booking.BookingStatus = constant.BOOKED
+			booking.HoldExpiresAt = nil
+		}
+		booking.PaidAmount += payment.Amount
+		booking.IsPaid = booking.Balance() <= 0
+
+		if err := u.bookingRepo.UpdateBookingTx(ctx, tx, booking); err != nil {
+			return paymentError.ErrFailedToUpdateBooking
 		}
 		if err := u.paymentRepo.UpdatePaymentTx(ctx, tx, payment); err != nil {
 			return paymentError.ErrFailedToUpdatePayment
 		}
+		if booking.IsPaid {
+			return u.issueBillsTx(ctx, tx, booking.ID)
+		}
 		return nil
-	})
+	case constant.PAYMENT_PENDING:
+		return nil
+	default:
+		payment.PaymentStatus = constant.PAYMENT_FAILED
+		if err := u.paymentRepo.UpdatePaymentTx(ctx, tx, payment); err != nil {
+			return paymentError.ErrFailedToUpdatePayment
+		}
+		if booking.BookingStatus != constant.PENDING_PAYMENT {
+			return nil
+		}
+		// The guest may have retried with another payment, which must keep
+		// its rooms.
+		pending, err := u.bookingRepo.HasPendingPaymentsTx(ctx, tx, booking.ID)
+		if err != nil {
+			return paymentError.ErrFailedToGetPayment
+		}
+		if pending {
+			return nil
+		}
+		booking.BookingStatus = constant.CANCELLED
+		booking.HoldExpiresAt = nil
+		if err := u.bookingRepo.UpdateBookingTx(ctx, tx, booking); err != nil {
+			return paymentError.ErrFailedToUpdateBooking
+		}
+		return nil
+	}
+}
+
+// GetVnpayReturn reports the payment to a customer redirected back from
+// VNPay. It changes nothing, since the browser can be made to send anything;
+// the status shown is the one the IPN recorded.
+func (u *PaymentUseCase) GetVnpayReturn(ctx context.Context, params url.Values) (*dto.VnpayReturnResponse, error) {
+	paymentGateway, err := u.gateways.Get(constant.PAYMENT_METHOD_VNPAY)
+	if err != nil {
+		return nil, err
+	}
+	result, err := paymentGateway.VerifyCallback(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	payment, err := u.paymentRepo.GetPaymentByTxnRefTx(ctx, u.paymentRepo.GetDB(), result.TxnRef)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, paymentError.ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, paymentError.ErrFailedToGetPayment
+	}
+	return &dto.VnpayReturnResponse{
+		BookingID:     payment.BookingID,
+		TxnRef:        payment.TxnRef,
+		Amount:        payment.Amount,
+		PaymentStatus: payment.PaymentStatus,
+		ResponseCode:  result.ResponseCode,
+	}, nil
+}
+
+// ListBookingPayments lists the payments of one of the customer's bookings,
+// newest first.
+func (u *PaymentUseCase) ListBookingPayments(ctx context.Context, bookingID uint, userID uint) ([]dto.PaymentResponse, error) {
+	if _, err := u.bookingRepo.GetBookingByBookingIDAndUserID(ctx, bookingID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, paymentError.ErrBookingNotFound
+		}
+		return nil, paymentError.ErrFailedToGetBooking
+	}
+	payments, err := u.paymentRepo.GetPaymentsByBookingID(ctx, bookingID)
+	if err != nil {
+		return nil, paymentError.ErrFailedToGetPayment
+	}
+	responses := make([]dto.PaymentResponse, 0, len(payments))
+	for _, payment := range payments {
+		response := dto.PaymentResponse{
+			ID:            payment.ID,
+			BookingID:     payment.BookingID,
+			TxnRef:        payment.TxnRef,
+			TransactionID: payment.TransactionID,
+			PaymentMethod: payment.PaymentMethod,
+			PayerName:     payment.PayerName,
+			Amount:        payment.Amount,
+			PaymentStatus: payment.PaymentStatus,
+			CreatedAt:     payment.CreatedAt,
+			PaidAt:        payment.PaidAt,
+		}
+		for _, refund := range payment.Refunds {
+			if refund.Status == constant.REFUND_SUCCESS {
+				response.RefundedAmount += refund.Amount
+			}
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
 }
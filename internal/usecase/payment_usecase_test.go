@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/dto"
+	paymentError "hotel-management/internal/error"
+	"hotel-management/internal/gateway"
+	"hotel-management/internal/models"
+	"hotel-management/internal/repository"
+	"hotel-management/internal/testutil"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const testStaleAfter = 15 * time.Minute
+
+func newTestPaymentUseCase(t *testing.T) (*PaymentUseCase, *gorm.DB, *gateway.FakeGateway) {
+	t.Helper()
+	db := testutil.NewDB(t)
+	fake := gateway.NewFakeGateway()
+	payments := NewPaymentUseCase(
+		repository.NewPaymentRepository(db),
+		repository.NewBookingRepository(db),
+		repository.NewBillRepository(db),
+		gateway.NewRegistry(fake),
+	)
+	payments.staleAfter = testStaleAfter
+	return payments, db, fake
+}
+
+// createHeldBooking stores a booking holding its rooms until it is paid.
+func createHeldBooking(t *testing.T, db *gorm.DB, timing string) *models.Booking {
+	t.Helper()
+	user := models.User{Name: "Guest", Email: "guest@example.com", Role: constant.CUSTOMER}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	holdExpiresAt := time.Now().Add(time.Hour)
+	booking := models.Booking{
+		UserID:        user.ID,
+		BookingStatus: constant.PENDING_PAYMENT,
+		TotalPrice:    2_000_000,
+		StartDate:     time.Now().AddDate(0, 0, 7),
+		EndDate:       time.Now().AddDate(0, 0, 9),
+		PaymentTiming: timing,
+		DepositAmount: 600_000,
+		HoldExpiresAt: &holdExpiresAt,
+	}
+	if err := db.Create(&booking).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &booking
+}
+
+func payFake(payments *PaymentUseCase, booking *models.Booking) (*dto.CreatePaymentResponse, error) {
+	req := &dto.CreatePaymentRequest{PaymentMethod: constant.PAYMENT_METHOD_FAKE}
+	return payments.CreatePayment(context.Background(), booking.ID, booking.UserID, req, "127.0.0.1")
+}
+
+func TestCreatePaymentForHeldBooking(t *testing.T) {
+	tests := []struct {
+		timing string
+		want   float64
+	}{
+		{timing: constant.PAYMENT_TIMING_PAY_NOW, want: 2_000_000},
+		{timing: constant.PAYMENT_TIMING_DEPOSIT, want: 600_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timing, func(t *testing.T) {
+			payments, db, _ := newTestPaymentUseCase(t)
+			booking := createHeldBooking(t, db, tt.timing)
+
+			response, err := payFake(payments, booking)
+			if err != nil {
+				t.Fatalf("CreatePayment: %v", err)
+			}
+			if response.Amount != tt.want {
+				t.Errorf("amount = %v, want %v", response.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreatePaymentRejectsSecondPaymentForHeldBooking(t *testing.T) {
+	payments, db, _ := newTestPaymentUseCase(t)
+	booking := createHeldBooking(t, db, constant.PAYMENT_TIMING_PAY_NOW)
+
+	if _, err := payFake(payments, booking); err != nil {
+		t.Fatalf("first CreatePayment: %v", err)
+	}
+	if _, err := payFake(payments, booking); !errors.Is(err, paymentError.ErrPaymentInProgress) {
+		t.Fatalf("second CreatePayment: got %v, want %v", err, paymentError.ErrPaymentInProgress)
+	}
+
+	var count int64
+	db.Model(&models.Payment{}).Where("booking_id = ?", booking.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("stored %d payments, want 1", count)
+	}
+}
+
+func TestCreatePaymentForHeldBookingAfterEarlierPaymentEnded(t *testing.T) {
+	tests := []struct {
+		name string
+		end  func(db *gorm.DB, paymentID uint) error
+	}{
+		{
+			name: "failed",
+			end: func(db *gorm.DB, paymentID uint) error {
+				return db.Model(&models.Payment{}).Where("id = ?", paymentID).
+					Update("payment_status", constant.PAYMENT_FAILED).Error
+			},
+		},
+		{
+			name: "stale",
+			end: func(db *gorm.DB, paymentID uint) error {
+				return db.Model(&models.Payment{}).Where("id = ?", paymentID).
+					Update("created_at", time.Now().Add(-testStaleAfter-time.Minute)).Error
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments, db, _ := newTestPaymentUseCase(t)
+			booking := createHeldBooking(t, db, constant.PAYMENT_TIMING_PAY_NOW)
+
+			first, err := payFake(payments, booking)
+			if err != nil {
+				t.Fatalf("first CreatePayment: %v", err)
+			}
+			if err := tt.end(db, first.PaymentID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := payFake(payments, booking); err != nil {
+				t.Fatalf("second CreatePayment: %v", err)
+			}
+		})
+	}
+}
+
+func TestSettledPaymentReleasesHold(t *testing.T) {
+	tests := []struct {
+		status     string
+		wantStatus string
+	}{
+		{status: constant.PAYMENT_SUCCESS, wantStatus: constant.BOOKED},
+		{status: constant.PAYMENT_FAILED, wantStatus: constant.CANCELLED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			payments, db, _ := newTestPaymentUseCase(t)
+			booking := createHeldBooking(t, db, constant.PAYMENT_TIMING_PAY_NOW)
+
+			response, err := payFake(payments, booking)
+			if err != nil {
+				t.Fatalf("CreatePayment: %v", err)
+			}
+			if err := payments.HandleCallback(context.Background(), constant.PAYMENT_METHOD_FAKE, fakeCallback(response, tt.status)); err != nil {
+				t.Fatalf("HandleCallback: %v", err)
+			}
+
+			var stored models.Booking
+			if err := db.First(&stored, booking.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if stored.BookingStatus != tt.wantStatus || stored.HoldExpiresAt != nil {
+				t.Errorf("booking = %s hold=%v, want %s without a hold", stored.BookingStatus, stored.HoldExpiresAt, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func fakeCallback(response *dto.CreatePaymentResponse, status string) url.Values {
+	return url.Values{
+		gateway.FAKE_PARAM_TXN_REF: {response.TxnRef},
+		gateway.FAKE_PARAM_AMOUNT:  {strconv.FormatInt(int64(response.Amount), 10)},
+		gateway.FAKE_PARAM_STATUS:  {status},
+	}
+}
+
+func TestStalePaymentSucceedingLateIsFlaggedAsOverpaid(t *testing.T) {
+	payments, db, _ := newTestPaymentUseCase(t)
+	booking := createHeldBooking(t, db, constant.PAYMENT_TIMING_PAY_NOW)
+	ctx := context.Background()
+
+	abandoned, err := payFake(payments, booking)
+	if err != nil {
+		t.Fatalf("first CreatePayment: %v", err)
+	}
+	db.Model(&models.Payment{}).Where("id = ?", abandoned.PaymentID).
+		Update("created_at", time.Now().Add(-testStaleAfter-time.Minute))
+	retried, err := payFake(payments, booking)
+	if err != nil {
+		t.Fatalf("second CreatePayment: %v", err)
+	}
+	if err := payments.HandleCallback(ctx, constant.PAYMENT_METHOD_FAKE, fakeCallback(retried, constant.PAYMENT_SUCCESS)); err != nil {
+		t.Fatalf("callback of the retried payment: %v", err)
+	}
+
+	bookings := repository.NewBookingRepository(db)
+	if overpaid, _ := bookings.GetOverpaidBookings(ctx, constant.AllProperties); len(overpaid) != 0 {
+		t.Fatalf("%d bookings overpaid before the abandoned payment succeeded, want 0", len(overpaid))
+	}
+	if err := payments.HandleCallback(ctx, constant.PAYMENT_METHOD_FAKE, fakeCallback(abandoned, constant.PAYMENT_SUCCESS)); err != nil {
+		t.Fatalf("callback of the abandoned payment: %v", err)
+	}
+
+	overpaid, err := bookings.GetOverpaidBookings(ctx, constant.AllProperties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overpaid) != 1 || overpaid[0].ID != booking.ID {
+		t.Fatalf("overpaid bookings = %v, want booking %d", overpaid, booking.ID)
+	}
+	if got := overpaid[0].Overpayment(); got != booking.TotalPrice {
+		t.Errorf("overpayment = %v, want %v", got, booking.TotalPrice)
+	}
+
+	// Refunding the excess clears the flag.
+	db.Model(&models.Booking{}).Where("id = ?", booking.ID).Update("refunded_amount", booking.TotalPrice)
+	if overpaid, _ := bookings.GetOverpaidBookings(ctx, constant.AllProperties); len(overpaid) != 0 {
+		t.Errorf("%d bookings overpaid after the refund, want 0", len(overpaid))
+	}
+}
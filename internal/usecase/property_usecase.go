@@ -0,0 +1,28 @@
+package usecase
+
+import (
+	"context"
+	"hotel-management/internal/dto"
+	"hotel-management/internal/repository"
+	"strings"
+)
+
+type PropertyUseCase struct {
+	propertyRepo repository.PropertyRepository
+}
+
+func NewPropertyUseCase(propertyRepo repository.PropertyRepository) *PropertyUseCase {
+	return &PropertyUseCase{propertyRepo: propertyRepo}
+}
+
+func (u *PropertyUseCase) ListProperties(ctx context.Context, query dto.PropertyQuery) ([]dto.PropertyResponse, error) {
+	properties, err := u.propertyRepo.GetActiveProperties(ctx, strings.TrimSpace(query.City))
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.PropertyResponse, 0, len(properties))
+	for _, property := range properties {
+		responses = append(responses, dto.NewPropertyResponse(property))
+	}
+	return responses, nil
+}
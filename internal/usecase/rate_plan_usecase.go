@@ -0,0 +1,28 @@
+package usecase
+
+import (
+	"context"
+	"hotel-management/internal/dto"
+	"hotel-management/internal/repository"
+)
+
+type RatePlanUseCase struct {
+	ratePlanRepo repository.RatePlanRepository
+}
+
+func NewRatePlanUseCase(ratePlanRepo repository.RatePlanRepository) *RatePlanUseCase {
+	return &RatePlanUseCase{ratePlanRepo: ratePlanRepo}
+}
+
+// ListRatePlans returns the plans guests can pick when booking at a property.
+func (u *RatePlanUseCase) ListRatePlans(ctx context.Context, query dto.RatePlanQuery) ([]dto.RatePlanResponse, error) {
+	ratePlans, err := u.ratePlanRepo.GetRatePlans(ctx, query.PropertyID, true)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.RatePlanResponse, 0, len(ratePlans))
+	for _, ratePlan := range ratePlans {
+		responses = append(responses, dto.NewRatePlanResponse(ratePlan))
+	}
+	return responses, nil
+}
@@ -1,28 +1,58 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"hotel-management/internal/constant"
 	"hotel-management/internal/dto"
 	reviewError "hotel-management/internal/error"
 	"hotel-management/internal/models"
 	"hotel-management/internal/repository"
+	"hotel-management/internal/storage"
+	"hotel-management/internal/utils"
+	"log"
+	"mime/multipart"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type ReviewUseCase struct {
 	bookingRepo repository.BookingRepository
 	reviewRepo  repository.ReviewRepository
+	fileStorage storage.Storage
+	editWindow  time.Duration
 }
 
-func NewReviewUseCase(bookingRepo repository.BookingRepository, reviewRepo repository.ReviewRepository) *ReviewUseCase {
+func NewReviewUseCase(bookingRepo repository.BookingRepository, reviewRepo repository.ReviewRepository, fileStorage storage.Storage) *ReviewUseCase {
 	return &ReviewUseCase{
 		bookingRepo: bookingRepo,
 		reviewRepo:  reviewRepo,
+		fileStorage: fileStorage,
+		editWindow:  reviewEditWindow(),
 	}
 }
 
+// reviewEditWindow reads REVIEW_EDIT_WINDOW_DAYS, falling back to
+// constant.DefaultReviewEditWindowDays.
+func reviewEditWindow() time.Duration {
+	days := constant.DefaultReviewEditWindowDays
+	if value := strings.TrimSpace(os.Getenv("REVIEW_EDIT_WINDOW_DAYS")); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			days = parsed
+		} else {
+			log.Printf("invalid REVIEW_EDIT_WINDOW_DAYS %q, using %d", value, days)
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
 func (u *ReviewUseCase) CreateReview(ctx context.Context, createReviewRequest *dto.CreateReviewRequest, userID uint) error {
 	booking, err := u.bookingRepo.GetBookingByID(ctx, createReviewRequest.BookingID)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -34,7 +64,13 @@ func (u *ReviewUseCase) CreateReview(ctx context.Context, createReviewRequest *d
 	if booking.BookingStatus != "checked_out" {
 		return reviewError.ErrBookingNotCheckedOut
 	}
-	exists, err := u.reviewRepo.ExistsByBookingID(ctx, createReviewRequest.BookingID)
+	if !bookingHasRoom(booking, createReviewRequest.RoomID) {
+		return reviewError.ErrRoomNotInBooking
+	}
+	if len(createReviewRequest.Photos) > constant.MaxReviewPhotos {
+		return reviewError.ErrTooManyReviewPhotos
+	}
+	exists, err := u.reviewRepo.ExistsByBookingRoom(ctx, createReviewRequest.BookingID, createReviewRequest.RoomID)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return reviewError.ErrReviewCheckFailed
 	}
@@ -47,9 +83,182 @@ func (u *ReviewUseCase) CreateReview(ctx context.Context, createReviewRequest *d
 		RoomID:    createReviewRequest.RoomID,
 		Rating:    createReviewRequest.Rating,
 		Comment:   createReviewRequest.Comment,
+
+		CleanlinessRating: createReviewRequest.Cleanliness,
+		ServiceRating:     createReviewRequest.Service,
+		LocationRating:    createReviewRequest.Location,
+		ValueRating:       createReviewRequest.Value,
+		ComfortRating:     createReviewRequest.Comfort,
+	}
+	// The room's stored rating aggregate is updated with the review itself.
+	return utils.WithTransaction(u.reviewRepo.GetDB(), func(tx *gorm.DB) error {
+		if err := u.reviewRepo.CreateReviewTx(ctx, tx, review); err != nil {
+			// Lost a race with another request for the same room.
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return reviewError.ErrReviewAlreadyExists
+			}
+			return reviewError.ErrFailedToCreateReview
+		}
+		if savedFiles, err := u.saveReviewPhotos(ctx, tx, review, createReviewRequest.Photos); err != nil {
+			u.deleteStoredFiles(ctx, savedFiles)
+			return err
+		}
+		if err := u.reviewRepo.RecomputeRoomRatingTx(ctx, tx, review.RoomID); err != nil {
+			return reviewError.ErrFailedToCreateReview
+		}
+		return nil
+	})
+}
+
+// ListPendingReviews returns the booked rooms the user can still review.
+func (u *ReviewUseCase) ListPendingReviews(ctx context.Context, userID uint) ([]dto.PendingReviewResponse, error) {
+	pending, err := u.reviewRepo.GetPendingReviews(ctx, userID)
+	if err != nil {
+		return nil, reviewError.ErrFailedToGetPendingReviews
+	}
+	if pending == nil {
+		pending = []dto.PendingReviewResponse{}
+	}
+	return pending, nil
+}
+
+// UpdateReview lets the author change a review within the edit window. The
+// previous version is kept as a revision, and an edited review goes back to
+// pending so moderators see it again; a hidden review stays hidden.
+func (u *ReviewUseCase) UpdateReview(ctx context.Context, id uint, req *dto.UpdateReviewRequest, userID uint) (*dto.PublicReviewResponse, error) {
+	review, err := u.editableReview(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	revision := review.NewRevision(constant.REVIEW_REVISION_EDITED)
+	review.Rating = req.Rating
+	review.Comment = req.Comment
+	review.CleanlinessRating = req.Cleanliness
+	review.ServiceRating = req.Service
+	review.LocationRating = req.Location
+	review.ValueRating = req.Value
+	review.ComfortRating = req.Comfort
+	if review.Status != constant.REVIEW_STATUS_HIDDEN {
+		review.Status = constant.REVIEW_STATUS_PENDING
+	}
+
+	err = utils.WithTransaction(u.reviewRepo.GetDB(), func(tx *gorm.DB) error {
+		if err := u.reviewRepo.CreateReviewRevisionTx(ctx, tx, &revision); err != nil {
+			return reviewError.ErrFailedToUpdateReview
+		}
+		if err := u.reviewRepo.UpdateReviewContentTx(ctx, tx, review); err != nil {
+			return reviewError.ErrFailedToUpdateReview
+		}
+		if err := u.reviewRepo.RecomputeRoomRatingTx(ctx, tx, review.RoomID); err != nil {
+			return reviewError.ErrFailedToUpdateReview
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := newPublicReviewResponse(*review)
+	return &res, nil
+}
+
+// DeleteReview lets the author remove a review within the edit window. The
+// review is soft-deleted and its last content kept as a revision.
+func (u *ReviewUseCase) DeleteReview(ctx context.Context, id uint, userID uint) error {
+	review, err := u.editableReview(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	revision := review.NewRevision(constant.REVIEW_REVISION_DELETED)
+	return utils.WithTransaction(u.reviewRepo.GetDB(), func(tx *gorm.DB) error {
+		if err := u.reviewRepo.CreateReviewRevisionTx(ctx, tx, &revision); err != nil {
+			return reviewError.ErrFailedToDeleteReview
+		}
+		if err := u.reviewRepo.DeleteReviewTx(ctx, tx, review); err != nil {
+			return reviewError.ErrFailedToDeleteReview
+		}
+		if err := u.reviewRepo.RecomputeRoomRatingTx(ctx, tx, review.RoomID); err != nil {
+			return reviewError.ErrFailedToDeleteReview
+		}
+		return nil
+	})
+}
+
+// editableReview loads a review the user wrote that is still inside the
+// edit window.
+func (u *ReviewUseCase) editableReview(ctx context.Context, id uint, userID uint) (*models.Review, error) {
+	review, err := u.reviewRepo.FindReviewByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, reviewError.ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, reviewError.ErrFailedToGetReviews
+	}
+	if review.UserID != userID {
+		return nil, reviewError.ErrReviewNotOwned
+	}
+	if time.Since(review.CreatedAt) > u.editWindow {
+		return nil, reviewError.ErrReviewEditWindowExpired
+	}
+	return review, nil
+}
+
+func bookingHasRoom(booking *models.Booking, roomID uint) bool {
+	for _, bookingRoom := range booking.BookingRooms {
+		if bookingRoom.RoomID == roomID {
+			return true
+		}
 	}
-	if err := u.reviewRepo.CreateReview(ctx, review); err != nil {
-		return reviewError.ErrFailedToCreateReview
+	return false
+}
+
+// saveReviewPhotos runs each photo through the image pipeline, like room
+// images, and keeps its thumbnail and large variants under generated names.
+// The stored keys are returned even on error so the caller can clean them up.
+func (u *ReviewUseCase) saveReviewPhotos(ctx context.Context, tx *gorm.DB, review *models.Review, fileHeaders []*multipart.FileHeader) ([]string, error) {
+	savedFiles := []string{}
+	for _, fileHeader := range fileHeaders {
+		data, err := utils.ReadUploadedImage(fileHeader)
+		if err != nil {
+			return savedFiles, err
+		}
+		processed, err := utils.ProcessImage(data)
+		if err != nil {
+			return savedFiles, err
+		}
+
+		baseName := uuid.New().String()
+		photo := &models.ReviewPhoto{ReviewID: review.ID}
+		large := processed.Variants[constant.IMAGE_VARIANT_LARGE]
+		thumbnail := processed.Variants[constant.IMAGE_VARIANT_THUMBNAIL]
+		largeKey := fmt.Sprintf("reviews/%d/%s_%s%s", review.ID, baseName, constant.IMAGE_VARIANT_LARGE, processed.Ext)
+		if err := u.fileStorage.Put(ctx, largeKey, large, processed.ContentType); err != nil {
+			return savedFiles, reviewError.ErrFailedToSaveFile
+		}
+		savedFiles = append(savedFiles, largeKey)
+		photo.ImageURL = u.fileStorage.URL(largeKey)
+		photo.ThumbnailURL = photo.ImageURL
+		// Small photos come out identical for both variants; share one file.
+		if !bytes.Equal(thumbnail, large) {
+			thumbnailKey := fmt.Sprintf("reviews/%d/%s_%s%s", review.ID, baseName, constant.IMAGE_VARIANT_THUMBNAIL, processed.Ext)
+			if err := u.fileStorage.Put(ctx, thumbnailKey, thumbnail, processed.ContentType); err != nil {
+				return savedFiles, reviewError.ErrFailedToSaveFile
+			}
+			savedFiles = append(savedFiles, thumbnailKey)
+			photo.ThumbnailURL = u.fileStorage.URL(thumbnailKey)
+		}
+
+		if err := u.reviewRepo.CreateReviewPhotoTx(ctx, tx, photo); err != nil {
+			return savedFiles, reviewError.ErrFailedToCreateReview
+		}
+		review.Photos = append(review.Photos, *photo)
+	}
+	return savedFiles, nil
+}
+
+func (u *ReviewUseCase) deleteStoredFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := u.fileStorage.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete file %s: %v", key, err)
+		}
 	}
-	return nil
 }
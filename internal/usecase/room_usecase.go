@@ -2,44 +2,352 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"hotel-management/internal/dto"
+	appError "hotel-management/internal/error"
+	"hotel-management/internal/models"
 	"hotel-management/internal/repository"
+	"math"
+
+	"gorm.io/gorm"
 )
 
+// recentReviewLimit is how many reviews the public room detail shows.
+const recentReviewLimit = 5
+
 type RoomUseCase struct {
-	roomRepo repository.RoomRepository
+	roomRepo   repository.RoomRepository
+	reviewRepo repository.ReviewRepository
 }
 
-func NewRoomUseCase(roomRepo repository.RoomRepository) *RoomUseCase {
-	return &RoomUseCase{roomRepo: roomRepo}
+func NewRoomUseCase(roomRepo repository.RoomRepository, reviewRepo repository.ReviewRepository) *RoomUseCase {
+	return &RoomUseCase{roomRepo: roomRepo, reviewRepo: reviewRepo}
 }
 
-func (u *RoomUseCase) SearchRoom(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest) ([]dto.SearchRoomResponse, error) {
-	var responses []dto.SearchRoomResponse
-	rooms, err := u.roomRepo.FindAvailableRoom(ctx, searchRoomRequest)
+func (u *RoomUseCase) SearchRoom(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest, lang string) (*dto.SearchRoomResult, error) {
+	page := dto.PageQuery{Page: searchRoomRequest.Page, Limit: searchRoomRequest.Limit}
+	page.Normalize()
+	rooms, total, err := u.roomRepo.FindAvailableRoom(ctx, searchRoomRequest, page)
+	if err != nil {
+		return nil, err
+	}
+	facets, err := u.roomRepo.GetSearchFacets(ctx, searchRoomRequest)
+	if err != nil {
+		return nil, err
+	}
+	roomIDs := make([]uint, 0, len(rooms))
+	for _, room := range rooms {
+		roomIDs = append(roomIDs, room.ID)
+	}
+	ratings, err := u.reviewRepo.GetRatingSummaries(ctx, roomIDs)
 	if err != nil {
-		return responses, err
+		return nil, err
 	}
 
+	responses := make([]dto.SearchRoomResponse, 0, len(rooms))
 	for _, room := range rooms {
-		imageURLs := make([]string, 0, len(room.Images))
-		for _, img := range room.Images {
-			imageURLs = append(imageURLs, img.ImageURL)
-		}
-
-		res := dto.SearchRoomResponse{
-			ID:            room.ID,
-			Name:          room.Name,
-			Type:          room.Type,
-			PricePerNight: room.PricePerNight,
-			BedNum:        room.BedNum,
-			HasAircon:     room.HasAircon,
-			ViewType:      room.ViewType,
-			Description:   room.Description,
-			ImageURLs:     imageURLs,
+		responses = append(responses, newSearchRoomResponse(room, ratings[room.ID], lang))
+	}
+
+	result := &dto.SearchRoomResult{
+		Rooms:      responses,
+		Pagination: dto.NewPageMeta(page, total),
+		Facets:     *facets,
+	}
+	if searchRoomRequest.Family {
+		result.ConnectingRooms, err = u.suggestConnectingRooms(ctx, searchRoomRequest, lang)
+		if err != nil {
+			return nil, err
 		}
-		responses = append(responses, res)
 	}
+	return result, nil
+}
+
+// suggestConnectingRooms pairs up available connecting rooms for families.
+func (u *RoomUseCase) suggestConnectingRooms(ctx context.Context, searchRoomRequest *dto.SearchRoomRequest, lang string) ([]dto.ConnectingRoomSuggestion, error) {
+	pairs, err := u.roomRepo.FindConnectingRoomPairs(ctx, searchRoomRequest)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(pairs)*2)
+	for _, pair := range pairs {
+		ids = append(ids, pair.RoomID, pair.ConnectedRoomID)
+	}
+	rooms, err := u.roomRepo.FindRoomsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	ratings, err := u.reviewRepo.GetRatingSummaries(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Room, len(rooms))
+	for _, room := range rooms {
+		byID[room.ID] = room
+	}
+
+	suggestions := make([]dto.ConnectingRoomSuggestion, 0, len(pairs))
+	for _, pair := range pairs {
+		first, ok := byID[pair.RoomID]
+		second, ok2 := byID[pair.ConnectedRoomID]
+		if !ok || !ok2 {
+			continue
+		}
+		suggestions = append(suggestions, dto.ConnectingRoomSuggestion{
+			Rooms: []dto.SearchRoomResponse{
+				newSearchRoomResponse(first, ratings[first.ID], lang),
+				newSearchRoomResponse(second, ratings[second.ID], lang),
+			},
+			TotalPricePerNight: first.PricePerNight + second.PricePerNight,
+			TotalBedNum:        first.BedNum + second.BedNum,
+		})
+	}
+	return suggestions, nil
+}
+
+func newSearchRoomResponse(room models.Room, rating dto.RatingSummary, lang string) dto.SearchRoomResponse {
+	imageURLs := make([]string, 0, len(room.Images))
+	images := make([]dto.RoomImageResponse, 0, len(room.Images))
+	for _, img := range room.Images {
+		imageURLs = append(imageURLs, img.ImageURL)
+		images = append(images, dto.NewRoomImageResponse(img))
+	}
+	amenities := make([]dto.AmenityResponse, 0, len(room.Amenities))
+	for _, amenity := range room.Amenities {
+		amenities = append(amenities, dto.NewAmenityResponse(amenity, lang))
+	}
+	return dto.SearchRoomResponse{
+		ID:                room.ID,
+		Name:              room.Name,
+		Type:              room.Type,
+		PricePerNight:     room.PricePerNight,
+		BedNum:            room.BedNum,
+		HasAircon:         room.HasAircon,
+		ViewType:          room.ViewType,
+		Description:       room.Description,
+		ImageURLs:         imageURLs,
+		Images:            images,
+		Amenities:         amenities,
+		AvgRating:         math.Round(rating.AvgRating*10) / 10,
+		ReviewCount:       rating.ReviewCount,
+		Property:          roomPropertyResponse(room),
+		Number:            room.Number,
+		Floor:             room.Floor,
+		Building:          room.Building,
+		ConnectingRoomIDs: connectingRoomIDs(room),
+	}
+}
+
+func (u *RoomUseCase) ListRooms(ctx context.Context, query dto.PublicRoomQuery, lang string) (*dto.PublicRoomListResponse, error) {
+	query.Normalize()
+	rooms, total, err := u.roomRepo.ListPublicRooms(ctx, query)
+	if err != nil {
+		return nil, errors.New("error.failed_to_get_room")
+	}
+	roomIDs := make([]uint, 0, len(rooms))
+	for _, room := range rooms {
+		roomIDs = append(roomIDs, room.ID)
+	}
+	ratings, err := u.reviewRepo.GetRatingSummaries(ctx, roomIDs)
+	if err != nil {
+		return nil, errors.New("error.failed_to_get_room")
+	}
+
+	responses := make([]dto.PublicRoomResponse, 0, len(rooms))
+	for _, room := range rooms {
+		responses = append(responses, newPublicRoomResponse(room, ratings[room.ID], lang))
+	}
+	return &dto.PublicRoomListResponse{
+		Rooms:    responses,
+		PageMeta: dto.NewPageMeta(query.PageQuery, total),
+	}, nil
+}
 
+func (u *RoomUseCase) ListRoomTypes(ctx context.Context) ([]dto.RoomTypeResponse, error) {
+	summaries, err := u.roomRepo.GetRoomTypeSummaries(ctx)
+	if err != nil {
+		return nil, errors.New("error.failed_to_get_room")
+	}
+	responses := make([]dto.RoomTypeResponse, 0, len(summaries))
+	for _, summary := range summaries {
+		res := dto.RoomTypeResponse{
+			Type:      summary.Type,
+			RoomCount: summary.RoomCount,
+			MinPrice:  summary.MinPrice,
+			MaxPrice:  summary.MaxPrice,
+		}
+		image, err := u.roomRepo.FindCoverImageByRoomType(ctx, summary.Type)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("error.failed_to_get_room")
+		}
+		if image != nil {
+			cover := dto.NewRoomImageResponse(*image)
+			res.CoverImage = &cover
+		}
+		responses = append(responses, res)
+	}
 	return responses, nil
 }
+
+func (u *RoomUseCase) GetRoomDetail(ctx context.Context, id uint, lang string) (*dto.PublicRoomDetailResponse, error) {
+	room, err := u.roomRepo.FindPublicRoomByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appError.ErrRoomNotFound
+		}
+		return nil, errors.New("error.failed_to_get_room")
+	}
+	rating, err := u.reviewRepo.GetRoomRating(ctx, room.ID)
+	if err != nil {
+		return nil, errors.New("error.failed_to_get_room")
+	}
+	reviews, err := u.reviewRepo.GetRecentReviewsByRoomID(ctx, room.ID, recentReviewLimit)
+	if err != nil {
+		return nil, errors.New("error.failed_to_get_room")
+	}
+
+	recentReviews := make([]dto.PublicReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		recentReviews = append(recentReviews, newPublicReviewResponse(review))
+	}
+	summary := dto.RatingSummary{RoomID: room.ID, AvgRating: rating.AvgRating, ReviewCount: rating.ReviewCount}
+	return &dto.PublicRoomDetailResponse{
+		PublicRoomResponse: newPublicRoomResponse(*room, summary, lang),
+		Description:        room.Description,
+		RecentReviews:      recentReviews,
+		RatingDistribution: ratingDistribution(*rating),
+		CriteriaRatings:    criteriaRatings(*rating),
+	}, nil
+}
+
+// ListRoomReviews pages through the reviews of a bookable room, newest or
+// highest rated first, together with the room's rating histogram.
+func (u *RoomUseCase) ListRoomReviews(ctx context.Context, id uint, query dto.RoomReviewQuery) (*dto.RoomReviewListResponse, error) {
+	if _, err := u.roomRepo.FindPublicRoomByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appError.ErrRoomNotFound
+		}
+		return nil, errors.New("error.failed_to_get_room")
+	}
+	query.Normalize()
+	rating, err := u.reviewRepo.GetRoomRating(ctx, id)
+	if err != nil {
+		return nil, appError.ErrFailedToGetReviews
+	}
+	reviews, total, err := u.reviewRepo.GetReviewsByRoomID(ctx, id, query.Sort, query.PageQuery)
+	if err != nil {
+		return nil, appError.ErrFailedToGetReviews
+	}
+
+	responses := make([]dto.PublicReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		responses = append(responses, newPublicReviewResponse(review))
+	}
+	return &dto.RoomReviewListResponse{
+		RoomID:             id,
+		AvgRating:          math.Round(rating.AvgRating*10) / 10,
+		ReviewCount:        rating.ReviewCount,
+		RatingDistribution: ratingDistribution(*rating),
+		CriteriaRatings:    criteriaRatings(*rating),
+		Reviews:            responses,
+		PageMeta:           dto.NewPageMeta(query.PageQuery, total),
+	}, nil
+}
+
+// ratingDistribution lists the review count per star rating, five stars
+// first.
+func ratingDistribution(rating models.RoomRating) []dto.RatingBucket {
+	counts := rating.Counts()
+	buckets := make([]dto.RatingBucket, 0, len(counts))
+	for stars := len(counts); stars >= 1; stars-- {
+		buckets = append(buckets, dto.RatingBucket{Rating: stars, Count: counts[stars-1]})
+	}
+	return buckets
+}
+
+// criteriaRatings rounds a room's per-criterion averages to one decimal.
+func criteriaRatings(rating models.RoomRating) dto.CriteriaRatings {
+	round := func(avg float64) float64 { return math.Round(avg*10) / 10 }
+	return dto.CriteriaRatings{
+		Cleanliness: round(rating.AvgCleanliness),
+		Service:     round(rating.AvgService),
+		Location:    round(rating.AvgLocation),
+		Value:       round(rating.AvgValue),
+		Comfort:     round(rating.AvgComfort),
+	}
+}
+
+func newPublicReviewResponse(review models.Review) dto.PublicReviewResponse {
+	res := dto.PublicReviewResponse{
+		ID:           review.ID,
+		Rating:       review.Rating,
+		Comment:      review.Comment,
+		ReviewerName: review.User.Name,
+		CreatedAt:    review.CreatedAt,
+		Photos:       make([]dto.ReviewPhotoResponse, 0, len(review.Photos)),
+	}
+	if review.HasCriteriaRatings() {
+		res.Criteria = &dto.CriteriaRatings{
+			Cleanliness: float64(review.CleanlinessRating),
+			Service:     float64(review.ServiceRating),
+			Location:    float64(review.LocationRating),
+			Value:       float64(review.ValueRating),
+			Comfort:     float64(review.ComfortRating),
+		}
+	}
+	for _, photo := range review.Photos {
+		res.Photos = append(res.Photos, dto.ReviewPhotoResponse{ImageURL: photo.ImageURL, ThumbnailURL: photo.ThumbnailURL})
+	}
+	if review.ManagementResponse != "" && review.RespondedAt != nil {
+		res.ManagementResponse = &dto.ManagementResponse{
+			Comment:     review.ManagementResponse,
+			RespondedAt: *review.RespondedAt,
+		}
+	}
+	return res
+}
+
+func newPublicRoomResponse(room models.Room, rating dto.RatingSummary, lang string) dto.PublicRoomResponse {
+	images := make([]dto.RoomImageResponse, 0, len(room.Images))
+	for _, img := range room.Images {
+		images = append(images, dto.NewRoomImageResponse(img))
+	}
+	amenities := make([]dto.AmenityResponse, 0, len(room.Amenities))
+	for _, amenity := range room.Amenities {
+		amenities = append(amenities, dto.NewAmenityResponse(amenity, lang))
+	}
+	return dto.PublicRoomResponse{
+		ID:                room.ID,
+		Name:              room.Name,
+		Type:              room.Type,
+		PricePerNight:     room.PricePerNight,
+		BedNum:            room.BedNum,
+		HasAircon:         room.HasAircon,
+		ViewType:          room.ViewType,
+		Images:            images,
+		Amenities:         amenities,
+		AvgRating:         math.Round(rating.AvgRating*10) / 10,
+		ReviewCount:       rating.ReviewCount,
+		Property:          roomPropertyResponse(room),
+		Number:            room.Number,
+		Floor:             room.Floor,
+		Building:          room.Building,
+		ConnectingRoomIDs: connectingRoomIDs(room),
+	}
+}
+
+func connectingRoomIDs(room models.Room) []uint {
+	ids := make([]uint, 0, len(room.ConnectingRooms))
+	for _, connected := range room.ConnectingRooms {
+		ids = append(ids, connected.ID)
+	}
+	return ids
+}
+
+func roomPropertyResponse(room models.Room) *dto.PropertyResponse {
+	if room.Property == nil {
+		return nil
+	}
+	property := dto.NewPropertyResponse(*room.Property)
+	return &property
+}
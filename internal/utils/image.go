@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"bytes"
+	"hotel-management/internal/constant"
+	appError "hotel-management/internal/error"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	// Registers the WebP decoder with image.Decode.
+	_ "golang.org/x/image/webp"
+)
+
+const jpegQuality = 85
+
+// ReadUploadedImage reads an uploaded image, rejecting files over
+// constant.MaxImageBytes.
+func ReadUploadedImage(fileHeader *multipart.FileHeader) ([]byte, error) {
+	if fileHeader.Size > constant.MaxImageBytes {
+		return nil, appError.ErrImageTooLarge
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, appError.ErrFailedToSaveFile
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, constant.MaxImageBytes+1))
+	if err != nil {
+		return nil, appError.ErrFailedToSaveFile
+	}
+	return data, nil
+}
+
+// ValidateUploadedImage checks an upload before it is processed: it must fit
+// within constant.MaxImageBytes and sniff as one of the types ProcessImage
+// accepts.
+func ValidateUploadedImage(fileHeader *multipart.FileHeader) error {
+	if fileHeader.Size > constant.MaxImageBytes {
+		return appError.ErrImageTooLarge
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return appError.ErrInvalidImageType
+	}
+	defer file.Close()
+
+	buffer := make([]byte, 512)
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return appError.ErrInvalidImageType
+	}
+	if !isSupportedImageType(http.DetectContentType(buffer[:n])) {
+		return appError.ErrInvalidImageType
+	}
+	return nil
+}
+
+func isSupportedImageType(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/webp":
+		return true
+	}
+	return false
+}
+
+// ProcessedImage holds the re-encoded variants of an uploaded image, keyed by
+// variant name (see constant.ImageVariants).
+type ProcessedImage struct {
+	ContentType string
+	Ext         string
+	Variants    map[string][]byte
+}
+
+// ProcessImage validates an uploaded image and produces its resized variants.
+// JPEG, PNG and WebP images are decoded and re-encoded, which drops EXIF and
+// any other metadata; the EXIF orientation is applied to the pixels first so
+// photos taken on phones keep the right way up. There is no WebP encoder, so
+// WebP images are stored as JPEG, or as PNG when they have transparency.
+func ProcessImage(data []byte) (*ProcessedImage, error) {
+	if len(data) == 0 {
+		return nil, appError.ErrInvalidImage
+	}
+	if len(data) > constant.MaxImageBytes {
+		return nil, appError.ErrImageTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !isSupportedImageType(contentType) {
+		return nil, appError.ErrInvalidImageType
+	}
+	return processRasterImage(data, contentType)
+}
+
+func processRasterImage(data []byte, contentType string) (*ProcessedImage, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, appError.ErrInvalidImage
+	}
+	if cfg.Width*cfg.Height > constant.MaxImagePixels {
+		return nil, appError.ErrImageDimensionsTooLarge
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, appError.ErrInvalidImage
+	}
+	src := toNRGBA(decoded)
+	switch contentType {
+	case "image/jpeg":
+		src = applyOrientation(src, jpegOrientation(data))
+	case "image/webp":
+		src = applyOrientation(src, webpOrientation(data))
+		contentType = "image/jpeg"
+		if !src.Opaque() {
+			contentType = "image/png"
+		}
+	}
+
+	result := &ProcessedImage{
+		ContentType: contentType,
+		Ext:         ".jpg",
+		Variants:    make(map[string][]byte, len(constant.ImageVariants)),
+	}
+	if contentType == "image/png" {
+		result.Ext = ".png"
+	}
+
+	for _, variant := range constant.ImageVariants {
+		resized := resizeToFit(src, variant.MaxDimension)
+		var buf bytes.Buffer
+		if contentType == "image/png" {
+			err = png.Encode(&buf, resized)
+		} else {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: jpegQuality})
+		}
+		if err != nil {
+			return nil, appError.ErrInvalidImage
+		}
+		result.Variants[variant.Name] = buf.Bytes()
+	}
+	return result, nil
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	if img, ok := src.(*image.NRGBA); ok && img.Rect.Min == (image.Point{}) {
+		return img
+	}
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+	return dst
+}
+
+// resizeToFit scales src down so its longest edge is at most maxDimension,
+// averaging the source pixels covered by each destination pixel.
+func resizeToFit(src *image.NRGBA, maxDimension int) *image.NRGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	if sw <= maxDimension && sh <= maxDimension {
+		return src
+	}
+	dw, dh := maxDimension, maxDimension
+	if sw >= sh {
+		dh = max(1, sh*maxDimension/sw)
+	} else {
+		dw = max(1, sw*maxDimension/sh)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		sy0 := y * sh / dh
+		sy1 := max(sy0+1, (y+1)*sh/dh)
+		for x := 0; x < dw; x++ {
+			sx0 := x * sw / dw
+			sx1 := max(sx0+1, (x+1)*sw/dw)
+
+			// Colour channels are weighted by alpha so transparent pixels do
+			// not bleed their (meaningless) colour into the result.
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := sx0; sx < sx1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					pa := uint64(p[3])
+					r += uint64(p[0]) * pa
+					g += uint64(p[1]) * pa
+					b += uint64(p[2]) * pa
+					a += pa
+					n++
+				}
+			}
+
+			o := dst.Pix[y*dst.Stride+x*4:]
+			if a > 0 {
+				o[0] = uint8(r / a)
+				o[1] = uint8(g / a)
+				o[2] = uint8(b / a)
+			}
+			o[3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// applyOrientation rotates or flips src according to an EXIF orientation
+// value (1-8) so that the pixels are stored upright.
+func applyOrientation(src *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // rotated 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // needs 90 clockwise rotation
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // needs 90 counter-clockwise rotation
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:sy*src.Stride+sx*4+4])
+		}
+	}
+	return dst
+}
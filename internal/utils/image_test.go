@@ -0,0 +1,498 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"hotel-management/internal/constant"
+	appError "hotel-management/internal/error"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"os"
+	"strings"
+	"testing"
+)
+
+// secretMarker stands for metadata that must not survive processing, such
+// as the GPS position of a phone photo.
+const secretMarker = "GPS-10.7769N-106.7009E"
+
+var labelColors = map[byte]color.NRGBA{
+	'a': {R: 255, A: 255},
+	'b': {G: 255, A: 255},
+	'c': {B: 255, A: 255},
+	'd': {R: 255, G: 255, A: 255},
+	'e': {G: 255, B: 255, A: 255},
+	'f': {R: 255, B: 255, A: 255},
+}
+
+// labeled builds an image whose pixels are named by letters, one string per
+// row.
+func labeled(rows ...string) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x := range row {
+			img.SetNRGBA(x, y, labelColors[row[x]])
+		}
+	}
+	return img
+}
+
+func labels(img *image.NRGBA) []string {
+	rows := make([]string, img.Rect.Dy())
+	for y := range rows {
+		var row strings.Builder
+		for x := 0; x < img.Rect.Dx(); x++ {
+			pixel := img.NRGBAAt(x, y)
+			for label, c := range labelColors {
+				if c == pixel {
+					row.WriteByte(label)
+				}
+			}
+		}
+		rows[y] = row.String()
+	}
+	return rows
+}
+
+func TestApplyOrientation(t *testing.T) {
+	tests := []struct {
+		orientation int
+		want        []string
+	}{
+		{orientation: 0, want: []string{"abc", "def"}},
+		{orientation: 1, want: []string{"abc", "def"}},
+		{orientation: 2, want: []string{"cba", "fed"}},
+		{orientation: 3, want: []string{"fed", "cba"}},
+		{orientation: 4, want: []string{"def", "abc"}},
+		{orientation: 5, want: []string{"ad", "be", "cf"}},
+		{orientation: 6, want: []string{"da", "eb", "fc"}},
+		{orientation: 7, want: []string{"fc", "eb", "da"}},
+		{orientation: 8, want: []string{"cf", "be", "ad"}},
+		{orientation: 9, want: []string{"abc", "def"}},
+	}
+
+	for _, tt := range tests {
+		got := labels(applyOrientation(labeled("abc", "def"), tt.orientation))
+		if strings.Join(got, "/") != strings.Join(tt.want, "/") {
+			t.Errorf("orientation %d: got %v, want %v", tt.orientation, got, tt.want)
+		}
+	}
+}
+
+// exifBlock builds a TIFF-structured EXIF block whose IFD0 holds a
+// description tag carrying secretMarker and, unless orientation is 0, an
+// orientation tag.
+func exifBlock(order binary.ByteOrder, orientation int) []byte {
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II")
+	} else {
+		buf.WriteString("MM")
+	}
+	word := func(v uint16) { _ = binary.Write(&buf, order, v) }
+	long := func(v uint32) { _ = binary.Write(&buf, order, v) }
+	word(42)
+	long(8)
+
+	entries := 1
+	if orientation != 0 {
+		entries++
+	}
+	word(uint16(entries))
+	valueOffset := 8 + 2 + 12*entries + 4
+	// ImageDescription, ASCII, stored after the IFD.
+	word(0x010E)
+	word(2)
+	long(uint32(len(secretMarker) + 1))
+	long(uint32(valueOffset))
+	if orientation != 0 {
+		word(exifOrientationTag)
+		word(3)
+		long(1)
+		word(uint16(orientation))
+		word(0)
+	}
+	long(0)
+	buf.WriteString(secretMarker + "\x00")
+	return buf.Bytes()
+}
+
+// withJPEGExif inserts an APP1 EXIF segment right after the SOI marker.
+func withJPEGExif(data, exif []byte) []byte {
+	payload := append([]byte("Exif\x00\x00"), exif...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	out = append(out, payload...)
+	return append(out, data[2:]...)
+}
+
+// halves is a w×h image, red on the left half and blue on the right.
+func halves(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+			} else {
+				img.SetNRGBA(x, y, color.NRGBA{B: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestJpegOrientation(t *testing.T) {
+	plain := encodeJPEG(t, halves(8, 8))
+	if got := jpegOrientation(plain); got != 1 {
+		t.Errorf("JPEG without EXIF: orientation %d, want 1", got)
+	}
+	if got := jpegOrientation(withJPEGExif(plain, exifBlock(binary.BigEndian, 0))); got != 1 {
+		t.Errorf("EXIF without orientation tag: orientation %d, want 1", got)
+	}
+	if got := jpegOrientation(withJPEGExif(plain, exifBlock(binary.BigEndian, 9))); got != 1 {
+		t.Errorf("out of range orientation: orientation %d, want 1", got)
+	}
+	if got := jpegOrientation([]byte("not a jpeg")); got != 1 {
+		t.Errorf("not a JPEG: orientation %d, want 1", got)
+	}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for orientation := 1; orientation <= 8; orientation++ {
+			data := withJPEGExif(plain, exifBlock(order, orientation))
+			if got := jpegOrientation(data); got != orientation {
+				t.Errorf("%s orientation %d: got %d", order, orientation, got)
+			}
+		}
+	}
+}
+
+// riffChunk encodes a RIFF chunk, padded to an even size.
+func riffChunk(fourCC string, payload []byte) []byte {
+	chunk := append([]byte(fourCC), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(payload)))
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// withWebPExif rewrites a simple (VP8) WebP file as an extended one carrying
+// an EXIF chunk after the image data, where encoders put it.
+func withWebPExif(t *testing.T, data, exif []byte) []byte {
+	t.Helper()
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeConfig: %v", err)
+	}
+	if string(data[12:16]) != "VP8 " {
+		t.Fatalf("want a simple lossy WebP, got chunk %q", data[12:16])
+	}
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x08 // EXIF flag
+	w, h := cfg.Width-1, cfg.Height-1
+	vp8x[4], vp8x[5], vp8x[6] = byte(w), byte(w>>8), byte(w>>16)
+	vp8x[7], vp8x[8], vp8x[9] = byte(h), byte(h>>8), byte(h>>16)
+
+	body := append([]byte("WEBP"), riffChunk("VP8X", vp8x)...)
+	body = append(body, data[12:]...)
+	body = append(body, riffChunk("EXIF", exif)...)
+	out := append([]byte("RIFF"), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(body)))
+	return append(out, body...)
+}
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return data
+}
+
+func TestWebpOrientation(t *testing.T) {
+	plain := readTestdata(t, "blue-purple-pink.lossy.webp")
+	if got := webpOrientation(plain); got != 1 {
+		t.Errorf("WebP without EXIF: orientation %d, want 1", got)
+	}
+	for orientation := 1; orientation <= 8; orientation++ {
+		exif := exifBlock(binary.LittleEndian, orientation)
+		if got := webpOrientation(withWebPExif(t, plain, exif)); got != orientation {
+			t.Errorf("orientation %d: got %d", orientation, got)
+		}
+		prefixed := append([]byte("Exif\x00\x00"), exif...)
+		if got := webpOrientation(withWebPExif(t, plain, prefixed)); got != orientation {
+			t.Errorf("orientation %d with Exif header: got %d", orientation, got)
+		}
+	}
+}
+
+func decodeVariant(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode variant: %v", err)
+	}
+	return img
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func isBlue(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return b > 0xC000 && r < 0x4000 && g < 0x4000
+}
+
+func TestProcessImageAppliesJPEGOrientation(t *testing.T) {
+	// Stored sideways, as a phone held upright writes it: orientation 6
+	// turns the 40×20 image into a 20×40 one with red on top.
+	data := withJPEGExif(encodeJPEG(t, halves(40, 20)), exifBlock(binary.BigEndian, 6))
+
+	processed, err := ProcessImage(data)
+	if err != nil {
+		t.Fatalf("ProcessImage: %v", err)
+	}
+	img := decodeVariant(t, processed.Variants[constant.IMAGE_VARIANT_LARGE])
+	if got := img.Bounds().Size(); got != (image.Point{X: 20, Y: 40}) {
+		t.Fatalf("size = %v, want 20x40", got)
+	}
+	if !isRed(img.At(10, 5)) || !isBlue(img.At(10, 35)) {
+		t.Errorf("top = %v, bottom = %v, want red on top and blue at the bottom", img.At(10, 5), img.At(10, 35))
+	}
+}
+
+// pngWithText inserts a tEXt chunk after the IHDR chunk of a PNG.
+func pngWithText(data []byte, text string) []byte {
+	const ihdrEnd = 8 + 8 + 13 + 4
+	payload := append([]byte("Comment\x00"), text...)
+	chunk := make([]byte, 8, 12+len(payload))
+	binary.BigEndian.PutUint32(chunk, uint32(len(payload)))
+	copy(chunk[4:], "tEXt")
+	chunk = append(chunk, payload...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	out := append([]byte{}, data[:ihdrEnd]...)
+	out = append(out, chunk...)
+	return append(out, data[ihdrEnd:]...)
+}
+
+func TestProcessImageStripsMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+	}{
+		{
+			name: "jpeg exif",
+			data: func(t *testing.T) []byte {
+				return withJPEGExif(encodeJPEG(t, halves(40, 20)), exifBlock(binary.LittleEndian, 1))
+			},
+		},
+		{
+			name: "png text",
+			data: func(t *testing.T) []byte {
+				return pngWithText(encodePNG(t, halves(40, 20)), secretMarker)
+			},
+		},
+		{
+			name: "webp exif",
+			data: func(t *testing.T) []byte {
+				return withWebPExif(t, readTestdata(t, "blue-purple-pink.lossy.webp"), exifBlock(binary.LittleEndian, 1))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data(t)
+			if !bytes.Contains(data, []byte(secretMarker)) {
+				t.Fatal("test image does not carry the metadata")
+			}
+			processed, err := ProcessImage(data)
+			if err != nil {
+				t.Fatalf("ProcessImage: %v", err)
+			}
+			for name, variant := range processed.Variants {
+				if bytes.Contains(variant, []byte(secretMarker)) || bytes.Contains(variant, []byte("Exif\x00\x00")) {
+					t.Errorf("%s variant kept the metadata", name)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessImageWebP(t *testing.T) {
+	tests := []struct {
+		file            string
+		wantContentType string
+		wantExt         string
+		wantSize        image.Point
+	}{
+		{file: "blue-purple-pink.lossy.webp", wantContentType: "image/jpeg", wantExt: ".jpg", wantSize: image.Point{X: 150, Y: 100}},
+		{file: "yellow_rose.lossy-with-alpha.webp", wantContentType: "image/png", wantExt: ".png", wantSize: image.Point{X: 320, Y: 240}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			processed, err := ProcessImage(readTestdata(t, tt.file))
+			if err != nil {
+				t.Fatalf("ProcessImage: %v", err)
+			}
+			if processed.ContentType != tt.wantContentType || processed.Ext != tt.wantExt {
+				t.Errorf("stored as %s %s, want %s %s", processed.ContentType, processed.Ext, tt.wantContentType, tt.wantExt)
+			}
+			img := decodeVariant(t, processed.Variants[constant.IMAGE_VARIANT_THUMBNAIL])
+			if got := img.Bounds().Size(); got != tt.wantSize {
+				t.Errorf("thumbnail size = %v, want %v", got, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestProcessImageVariantSizes(t *testing.T) {
+	tests := []struct {
+		name  string
+		width int
+		// height is half the width in every case.
+		want map[string]image.Point
+	}{
+		{
+			name:  "oversized",
+			width: 2000,
+			want: map[string]image.Point{
+				constant.IMAGE_VARIANT_THUMBNAIL: {X: 320, Y: 160},
+				constant.IMAGE_VARIANT_MEDIUM:    {X: 800, Y: 400},
+				constant.IMAGE_VARIANT_LARGE:     {X: 1600, Y: 800},
+			},
+		},
+		{
+			name:  "already small",
+			width: 100,
+			want: map[string]image.Point{
+				constant.IMAGE_VARIANT_THUMBNAIL: {X: 100, Y: 50},
+				constant.IMAGE_VARIANT_MEDIUM:    {X: 100, Y: 50},
+				constant.IMAGE_VARIANT_LARGE:     {X: 100, Y: 50},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed, err := ProcessImage(encodePNG(t, halves(tt.width, tt.width/2)))
+			if err != nil {
+				t.Fatalf("ProcessImage: %v", err)
+			}
+			for name, want := range tt.want {
+				if got := decodeVariant(t, processed.Variants[name]).Bounds().Size(); got != want {
+					t.Errorf("%s size = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResizeToFit(t *testing.T) {
+	tests := []struct {
+		width, height int
+		maxDimension  int
+		want          image.Point
+	}{
+		{width: 2000, height: 1000, maxDimension: 800, want: image.Point{X: 800, Y: 400}},
+		{width: 1000, height: 2000, maxDimension: 320, want: image.Point{X: 160, Y: 320}},
+		{width: 5000, height: 1, maxDimension: 1600, want: image.Point{X: 1600, Y: 1}},
+		{width: 320, height: 320, maxDimension: 320, want: image.Point{X: 320, Y: 320}},
+		{width: 300, height: 200, maxDimension: 320, want: image.Point{X: 300, Y: 200}},
+	}
+
+	for _, tt := range tests {
+		src := image.NewNRGBA(image.Rect(0, 0, tt.width, tt.height))
+		got := resizeToFit(src, tt.maxDimension)
+		if size := got.Rect.Size(); size != tt.want {
+			t.Errorf("%dx%d fit in %d: got %v, want %v", tt.width, tt.height, tt.maxDimension, size, tt.want)
+		}
+		if tt.width <= tt.maxDimension && tt.height <= tt.maxDimension && got != src {
+			t.Errorf("%dx%d fit in %d: small image was copied", tt.width, tt.height, tt.maxDimension)
+		}
+	}
+}
+
+func TestResizeToFitIgnoresTransparentColour(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	src.SetNRGBA(1, 0, color.NRGBA{G: 255, A: 0})
+
+	got := resizeToFit(src, 1).NRGBAAt(0, 0)
+	if want := (color.NRGBA{R: 255, A: 127}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// uploadedFile builds the multipart.FileHeader a handler would see for an
+// upload of data.
+func uploadedFile(t *testing.T, data []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("photos", "photo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(int64(len(data)) + 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["photos"][0]
+}
+
+func TestValidateUploadedImage(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{name: "jpeg", data: encodeJPEG(t, halves(8, 8))},
+		{name: "png", data: encodePNG(t, halves(8, 8))},
+		{name: "webp", data: readTestdata(t, "blue-purple-pink.lossy.webp")},
+		{name: "gif", data: []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"), want: appError.ErrInvalidImageType},
+		{name: "text", data: []byte("not an image"), want: appError.ErrInvalidImageType},
+		{name: "too large", data: append(encodePNG(t, halves(8, 8)), make([]byte, constant.MaxImageBytes)...), want: appError.ErrImageTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateUploadedImage(uploadedFile(t, tt.data)); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,16 @@
 package router
 
 import (
+	"context"
 	"hotel-management/database"
+	"hotel-management/internal/constant"
+	"hotel-management/internal/gateway"
 	"hotel-management/internal/handler"
 	"hotel-management/internal/handler/admin"
+	"hotel-management/internal/job"
 	"hotel-management/internal/middleware"
 	"hotel-management/internal/repository"
+	"hotel-management/internal/storage"
 	"hotel-management/internal/usecase"
 	"hotel-management/internal/usecase/admin_usecase"
 
@@ -14,7 +19,7 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRoutes(r *gin.Engine) {
+func SetupRoutes(r *gin.Engine, fileStorage storage.Storage, paymentGateways *gateway.Registry) {
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Auth routes
@@ -50,35 +55,74 @@ func SetupRoutes(r *gin.Engine) {
 	roomRepository := repository.NewRoomRepository(database.DB)
 	reviewRepository := repository.NewReviewRepository(database.DB)
 	bookingRepository := repository.NewBookingRepository(database.DB)
-	roomAdminUseCase := admin_usecase.NewRoomUseCase(roomRepository, bookingRepository, reviewRepository)
-	roomAdminHandler := admin.NewRoomHandler(roomAdminUseCase)
+	amenityRepository := repository.NewAmenityRepository(database.DB)
+	roomAdminUseCase := admin_usecase.NewRoomUseCase(roomRepository, bookingRepository, reviewRepository, amenityRepository, fileStorage)
+	propertyRepository := repository.NewPropertyRepository(database.DB)
+	propertyAdminUseCase := admin_usecase.NewPropertyUseCase(propertyRepository)
+	propertyAdminHandler := admin.NewPropertyHandler(propertyAdminUseCase)
+	// Pages listing rooms, bookings or bills only show the current property.
+	propertyScope := middleware.PropertyScope(propertyAdminUseCase)
+	roomAdminHandler := admin.NewRoomHandler(roomAdminUseCase, propertyAdminUseCase)
 	adminBookingUseCase := admin_usecase.NewBookingUseCase(bookingRepository)
-	adminBookingHandler := admin.NewAdminBookingHandler(adminBookingUseCase)
 	billRepository := repository.NewBillRepository(database.DB)
+	paymentRepository := repository.NewPaymentRepository(database.DB)
+	refundRepository := repository.NewRefundRepository(database.DB)
+	refundUseCase := admin_usecase.NewRefundUseCase(paymentRepository, refundRepository, bookingRepository, billRepository, paymentGateways)
+	adminBookingHandler := admin.NewAdminBookingHandler(adminBookingUseCase, refundUseCase)
+	reconciliationRepository := repository.NewReconciliationRepository(database.DB)
+	reconciliationAdminUseCase := admin_usecase.NewReconciliationUseCase(reconciliationRepository)
+	reconciliationAdminHandler := admin.NewReconciliationHandler(reconciliationAdminUseCase)
+	paymentUseCase := usecase.NewPaymentUseCase(paymentRepository, bookingRepository, billRepository, paymentGateways)
+	paymentAdminUseCase := admin_usecase.NewPaymentUseCase(paymentRepository, bookingRepository, paymentGateways, paymentUseCase)
+	paymentAdminHandler := admin.NewPaymentHandler(paymentAdminUseCase)
+	ratePlanRepository := repository.NewRatePlanRepository(database.DB)
+	ratePlanAdminUseCase := admin_usecase.NewRatePlanUseCase(ratePlanRepository, propertyRepository)
+	ratePlanAdminHandler := admin.NewRatePlanHandler(ratePlanAdminUseCase)
 	billUseCase := admin_usecase.NewBillUseCase(billRepository)
 	billHandler := admin.NewBillHandler(billUseCase)
 	staffUseCase := admin_usecase.NewStaffUseCase(userRepository)
 	staffHandler := admin.NewStaffHandler(staffUseCase)
+	workOrderRepository := repository.NewWorkOrderRepository(database.DB)
+	workOrderUseCase := admin_usecase.NewWorkOrderUseCase(workOrderRepository, roomRepository, userRepository, fileStorage)
+	workOrderAdminHandler := admin.NewWorkOrderHandler(workOrderUseCase)
+	reviewAdminUseCase := admin_usecase.NewReviewUseCase(reviewRepository, roomRepository)
+	reviewAdminHandler := admin.NewReviewHandler(reviewAdminUseCase)
+	amenityAdminUseCase := admin_usecase.NewAmenityUseCase(amenityRepository)
+	amenityAdminHandler := admin.NewAmenityHandler(amenityAdminUseCase)
 	adminGroup := r.Group("/admin")
 	{
-		adminGroup.GET("/", middleware.RequireLogin(), middleware.RequireRoles("admin", "staff"), adminHandler.AdminDashboard)
+		adminGroup.GET("/", middleware.RequireLogin(), middleware.RequireRoles("admin", "staff"), propertyScope, adminHandler.AdminDashboard)
 		adminGroup.GET("/login", adminHandler.AdminLoginPage)
 		adminGroup.POST("/login", adminHandler.HandleLogin)
 		adminGroup.GET("/logout", adminHandler.HandleLogout)
 
-		adminGroup.GET("/rooms", middleware.RequireRoles("admin", "staff"), roomAdminHandler.RoomManagementPage)
-		adminGroup.GET("/rooms/create", middleware.RequireRoles("admin", "staff"), roomAdminHandler.CreateRoomPage)
-		adminGroup.POST("/rooms/create", middleware.RequireRoles("admin", "staff"), roomAdminHandler.CreateRoom)
-		adminGroup.GET("/rooms/:id", middleware.RequireRoles("admin", "staff"), roomAdminHandler.RoomDetailPage)
-		adminGroup.GET("/rooms/edit/:id", middleware.RequireRoles("admin", "staff"), roomAdminHandler.EditRoomPage)
-		adminGroup.POST("/rooms/edit/:id", middleware.RequireRoles("admin", "staff"), roomAdminHandler.UpdateRoom)
-		adminGroup.POST("/rooms/delete/:id", middleware.RequireRoles("admin", "staff"), roomAdminHandler.DeleteRoom)
-		adminGroup.GET("/bookings", middleware.RequireRoles("admin", "staff"), adminBookingHandler.ListBookings)
-		adminGroup.GET("/bookings/:id", middleware.RequireRoles("admin", "staff"), adminBookingHandler.GetBookingDetail)
-		adminGroup.GET("/bookings/edit/:id", middleware.RequireRoles("admin", "staff"), adminBookingHandler.EditBookingPage)
-		adminGroup.POST("/bookings/edit/:id", middleware.RequireRoles("admin", "staff"), adminBookingHandler.EditBookingStatus)
+		adminGroup.GET("/rooms", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.RoomManagementPage)
+		adminGroup.GET("/rooms/create", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.CreateRoomPage)
+		adminGroup.GET("/rooms/trash", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.RoomTrashPage)
+		adminGroup.GET("/rooms/import", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.RoomImportPage)
+		adminGroup.POST("/rooms/import", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.ImportRooms)
+		adminGroup.GET("/rooms/export", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.ExportRooms)
+		adminGroup.POST("/rooms/create", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.CreateRoom)
+		adminGroup.GET("/rooms/:id", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.RoomDetailPage)
+		adminGroup.GET("/rooms/edit/:id", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.EditRoomPage)
+		adminGroup.POST("/rooms/edit/:id", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.UpdateRoom)
+		adminGroup.POST("/rooms/delete/:id", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.DeleteRoom)
+		adminGroup.POST("/rooms/restore/:id", middleware.RequireRoles("admin", "staff"), propertyScope, roomAdminHandler.RestoreRoom)
+		adminGroup.GET("/bookings", middleware.RequireRoles("admin", "staff"), propertyScope, adminBookingHandler.ListBookings)
+		adminGroup.GET("/bookings/:id", middleware.RequireRoles("admin", "staff"), propertyScope, adminBookingHandler.GetBookingDetail)
+		adminGroup.GET("/bookings/edit/:id", middleware.RequireRoles("admin", "staff"), propertyScope, adminBookingHandler.EditBookingPage)
+		adminGroup.POST("/bookings/edit/:id", middleware.RequireRoles("admin", "staff"), propertyScope, adminBookingHandler.EditBookingStatus)
+		adminGroup.POST("/bookings/:id/refunds", middleware.RequireRoles("admin"), propertyScope, adminBookingHandler.CreateRefund)
+		adminGroup.POST("/bookings/:id/charges", middleware.RequireRoles("admin", "staff"), propertyScope, adminBookingHandler.CreateCharge)
 
-		adminGroup.GET("/bills", middleware.RequireRoles("admin", "staff"), billHandler.ListBills)
+		adminGroup.GET("/bills", middleware.RequireRoles("admin", "staff"), propertyScope, billHandler.ListBills)
+		adminGroup.GET("/payments", middleware.RequireRoles("admin"), propertyScope, paymentAdminHandler.ListPayments)
+		adminGroup.GET("/payments/:id", middleware.RequireRoles("admin"), propertyScope, paymentAdminHandler.GetPaymentDetail)
+		adminGroup.POST("/payments/:id/retry-reconcile", middleware.RequireRoles("admin"), propertyScope, paymentAdminHandler.RetryReconcile)
+		adminGroup.GET("/payments/reconciliations", middleware.RequireRoles("admin"), reconciliationAdminHandler.ListReconciliations)
+		adminGroup.GET("/payments/bank-transfers", middleware.RequireRoles("admin", "staff"), propertyScope, paymentAdminHandler.BankTransfersPage)
+		adminGroup.POST("/payments/bank-transfers/import", middleware.RequireRoles("admin", "staff"), propertyScope, paymentAdminHandler.ImportBankStatement)
+		adminGroup.POST("/payments/:id/confirm-transfer", middleware.RequireRoles("admin", "staff"), propertyScope, paymentAdminHandler.ConfirmBankTransfer)
 
 		adminGroup.GET("/staffs", middleware.RequireRoles("admin"), staffHandler.ListStaffs)
 		adminGroup.GET("/staffs/create", middleware.RequireRoles("admin"), staffHandler.CreateStaffPage)
@@ -88,37 +132,108 @@ func SetupRoutes(r *gin.Engine) {
 		adminGroup.POST("/staffs/delete/:id", middleware.RequireRoles("admin"), staffHandler.DeleteStaff)
 
 		adminGroup.GET("/customers", middleware.RequireRoles("admin"), staffHandler.ListCustomers)
+
+		adminGroup.GET("/work-orders", middleware.RequireRoles("admin", "staff"), propertyScope, workOrderAdminHandler.ListWorkOrders)
+		adminGroup.GET("/work-orders/:id", middleware.RequireRoles("admin", "staff"), propertyScope, workOrderAdminHandler.WorkOrderDetailPage)
+		adminGroup.POST("/work-orders/:id/status", middleware.RequireRoles("admin", "staff"), propertyScope, workOrderAdminHandler.UpdateWorkOrderStatus)
+		adminGroup.POST("/work-orders/:id/assign", middleware.RequireRoles("admin", "staff"), propertyScope, workOrderAdminHandler.AssignWorkOrder)
+		adminGroup.GET("/reviews", middleware.RequireRoles("admin", "staff"), propertyScope, reviewAdminHandler.ListReviews)
+		adminGroup.GET("/reviews/:id", middleware.RequireRoles("admin", "staff"), propertyScope, reviewAdminHandler.ReviewDetailPage)
+		adminGroup.POST("/reviews/:id/moderate", middleware.RequireRoles("admin", "staff"), propertyScope, reviewAdminHandler.ModerateReview)
+		adminGroup.POST("/reviews/:id/response", middleware.RequireRoles("admin", "staff"), propertyScope, reviewAdminHandler.RespondToReview)
+
+		adminGroup.GET("/amenities", middleware.RequireRoles("admin", "staff"), amenityAdminHandler.AmenityManagementPage)
+		adminGroup.GET("/amenities/create", middleware.RequireRoles("admin", "staff"), amenityAdminHandler.CreateAmenityPage)
+		adminGroup.POST("/amenities/create", middleware.RequireRoles("admin", "staff"), amenityAdminHandler.CreateAmenity)
+		adminGroup.GET("/amenities/edit/:id", middleware.RequireRoles("admin", "staff"), amenityAdminHandler.EditAmenityPage)
+		adminGroup.POST("/amenities/edit/:id", middleware.RequireRoles("admin", "staff"), amenityAdminHandler.UpdateAmenity)
+		adminGroup.POST("/amenities/delete/:id", middleware.RequireRoles("admin", "staff"), amenityAdminHandler.DeleteAmenity)
+
+		adminGroup.GET("/properties", middleware.RequireRoles("admin"), propertyAdminHandler.PropertyManagementPage)
+		adminGroup.GET("/properties/create", middleware.RequireRoles("admin"), propertyAdminHandler.CreatePropertyPage)
+		adminGroup.POST("/properties/create", middleware.RequireRoles("admin"), propertyAdminHandler.CreateProperty)
+		adminGroup.GET("/properties/edit/:id", middleware.RequireRoles("admin"), propertyAdminHandler.EditPropertyPage)
+		adminGroup.POST("/properties/edit/:id", middleware.RequireRoles("admin"), propertyAdminHandler.UpdateProperty)
+		adminGroup.GET("/rate-plans", middleware.RequireRoles("admin"), propertyScope, ratePlanAdminHandler.RatePlanManagementPage)
+		adminGroup.POST("/rate-plans/create", middleware.RequireRoles("admin"), ratePlanAdminHandler.CreateRatePlan)
+		adminGroup.GET("/rate-plans/edit/:id", middleware.RequireRoles("admin"), ratePlanAdminHandler.EditRatePlanPage)
+		adminGroup.POST("/rate-plans/edit/:id", middleware.RequireRoles("admin"), ratePlanAdminHandler.UpdateRatePlan)
+		adminGroup.GET("/properties/switcher", middleware.RequireRoles("admin", "staff"), propertyScope, propertyAdminHandler.PropertySwitcher)
+		adminGroup.POST("/properties/switch", middleware.RequireRoles("admin", "staff"), propertyAdminHandler.SwitchProperty)
 	}
 	//User routes
 	userHandler := handler.NewUserHandler(userUseCase)
 	r.PUT("/users/update-profile", middleware.RequireAuth(userRepository), userHandler.UpdateProfile)
 
 	//Room routes
-	roomUseCase := usecase.NewRoomUseCase(roomRepository)
+	roomUseCase := usecase.NewRoomUseCase(roomRepository, reviewRepository)
 	roomHandler := handler.NewRoomHandler(roomUseCase)
-	r.POST("/rooms/search", middleware.RequireAuth(userRepository), roomHandler.FindAvailableRoom)
+	// Browsing is public; only booking needs an account.
+	publicRoomGroup := r.Group("/rooms", middleware.ETag(constant.PublicCacheMaxAge))
+	{
+		publicRoomGroup.GET("", roomHandler.ListRooms)
+		publicRoomGroup.GET("/types", roomHandler.ListRoomTypes)
+		publicRoomGroup.GET("/:id", roomHandler.GetRoomDetail)
+		publicRoomGroup.GET("/:id/reviews", roomHandler.ListRoomReviews)
+	}
+	r.POST("/rooms/search", roomHandler.FindAvailableRoom)
+	amenityUseCase := usecase.NewAmenityUseCase(amenityRepository)
+	amenityHandler := handler.NewAmenityHandler(amenityUseCase)
+	r.GET("/amenities", middleware.ETag(constant.PublicCacheMaxAge), amenityHandler.ListAmenities)
+	propertyUseCase := usecase.NewPropertyUseCase(propertyRepository)
+	propertyHandler := handler.NewPropertyHandler(propertyUseCase)
+	r.GET("/properties", middleware.ETag(constant.PublicCacheMaxAge), propertyHandler.ListProperties)
 
 	//Booking routes
-	bookingUseCase := usecase.NewBookingUseCase(bookingRepository)
+	ratePlanUseCase := usecase.NewRatePlanUseCase(ratePlanRepository)
+	ratePlanHandler := handler.NewRatePlanHandler(ratePlanUseCase)
+	r.GET("/rate-plans", ratePlanHandler.ListRatePlans)
+	bookingUseCase := usecase.NewBookingUseCase(bookingRepository, ratePlanRepository)
 	bookingHandler := handler.NewBookingHandler(bookingUseCase)
+	// Retried requests with the same Idempotency-Key get the first response.
+	idempotencyRepository := repository.NewIdempotencyRepository(database.DB)
+	idempotent := middleware.Idempotency(idempotencyRepository)
 	bookingGroup := r.Group("/bookings")
 	{
-		bookingGroup.POST("/", middleware.RequireAuth(userRepository), bookingHandler.CreateBooking)
+		bookingGroup.POST("/", middleware.RequireAuth(userRepository), idempotent, bookingHandler.CreateBooking)
 		bookingGroup.GET("/history", middleware.RequireAuth(userRepository), bookingHandler.GetBookingHistory)
 		bookingGroup.GET("/:id/cancel", middleware.RequireAuth(userRepository), bookingHandler.CancelBooking)
 	}
 	//Review
-	reviewUseCase := usecase.NewReviewUseCase(bookingRepository, reviewRepository)
+	reviewUseCase := usecase.NewReviewUseCase(bookingRepository, reviewRepository, fileStorage)
 	reviewHandler := handler.NewReviewHandler(reviewUseCase)
 	r.POST("/reviews", middleware.RequireAuth(userRepository), reviewHandler.CreateReview)
+	r.GET("/reviews/pending", middleware.RequireAuth(userRepository), reviewHandler.ListPendingReviews)
+	r.PUT("/reviews/:id", middleware.RequireAuth(userRepository), reviewHandler.UpdateReview)
+	r.DELETE("/reviews/:id", middleware.RequireAuth(userRepository), reviewHandler.DeleteReview)
+
+	//Staff work order routes
+	workOrderHandler := handler.NewWorkOrderHandler(workOrderUseCase)
+	staffGroup := r.Group("/staff", middleware.RequireStaffAuth(userRepository), middleware.StaffPropertyScope(propertyAdminUseCase))
+	{
+		staffGroup.POST("/work-orders", workOrderHandler.CreateWorkOrder)
+		staffGroup.GET("/work-orders", workOrderHandler.ListWorkOrders)
+		staffGroup.GET("/work-orders/:id", workOrderHandler.GetWorkOrder)
+		staffGroup.PUT("/work-orders/:id/status", workOrderHandler.UpdateWorkOrderStatus)
+		staffGroup.PUT("/work-orders/:id/assign", workOrderHandler.AssignWorkOrder)
+	}
 
 	//Payment routes
-	paymentRepository := repository.NewPaymentRepository(database.DB)
-	paymentUseCase := usecase.NewPaymentUseCase(paymentRepository, bookingRepository, billRepository)
 	paymentHandler := handler.NewPaymentHandler(paymentUseCase)
 	paymentGroup := r.Group("/payments")
 	{
-		paymentGroup.GET("/:id/vnpay", paymentHandler.GetVnPayUrl)
-		paymentGroup.GET("/vnpay_return", paymentHandler.HandleVnpayCallback)
+		paymentGroup.POST("/:id", middleware.RequireAuth(userRepository), paymentHandler.CreatePayment)
+		paymentGroup.GET("/:id/vnpay", middleware.RequireAuth(userRepository), idempotent, paymentHandler.GetVnPayUrl)
+		paymentGroup.GET("/vnpay_return", paymentHandler.HandleVnpayReturn)
+		paymentGroup.GET("/vnpay_ipn", paymentHandler.HandleVnpayIPN)
 	}
+	staffGroup.POST("/payments/:id/confirm", paymentHandler.ConfirmPayment)
+	bookingGroup.GET("/:id/payments", middleware.RequireAuth(userRepository), paymentHandler.ListBookingPayments)
+
+	//Background jobs
+	paymentReconcileUseCase := usecase.NewPaymentReconcileUseCase(paymentUseCase, reconciliationRepository)
+	job.StartPaymentReconciler(context.Background(), paymentReconcileUseCase)
+	job.StartRefundReconciler(context.Background(), refundUseCase)
+	job.StartBookingHoldReleaser(context.Background(), bookingUseCase)
+	job.StartIdempotencyKeyCleaner(context.Background(), idempotencyRepository)
 }